@@ -0,0 +1,236 @@
+// Package apperrors is the error model of the API. Handlers report failures
+// as *Error values with c.Error; the middleware.Errors middleware renders
+// them, every error response has the same shape:
+//
+//	{
+//	  "error": {
+//	    "code": "VALIDATION_FAILED",
+//	    "message": "the request is invalid",
+//	    "fields": [{"field": "email", "rule": "required", "message": "is required"}]
+//	  },
+//	  "request_id": "..."
+//	}
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type Code string
+
+const (
+	// CodeBadRequest is a request that cannot be read, e.g. malformed JSON.
+	CodeBadRequest Code = "BAD_REQUEST"
+	// CodeValidationFailed is a well formed request with invalid values,
+	// Fields tells which.
+	CodeValidationFailed Code = "VALIDATION_FAILED"
+	CodeUnauthorized     Code = "UNAUTHORIZED"
+	CodeForbidden        Code = "FORBIDDEN"
+	CodeNotFound         Code = "NOT_FOUND"
+	// CodeConflict is a request that clashes with the current state, e.g. a
+	// duplicate email or an illegal status change.
+	CodeConflict Code = "CONFLICT"
+	CodeInternal Code = "INTERNAL"
+)
+
+var statuses = map[Code]int{
+	CodeBadRequest:       http.StatusBadRequest,
+	CodeValidationFailed: http.StatusBadRequest,
+	CodeUnauthorized:     http.StatusUnauthorized,
+	CodeForbidden:        http.StatusForbidden,
+	CodeNotFound:         http.StatusNotFound,
+	CodeConflict:         http.StatusConflict,
+	CodeInternal:         http.StatusInternalServerError,
+}
+
+// FieldError describes one invalid field of a request.
+type FieldError struct {
+	// Field is the JSON path of the field, e.g. "groups[0].order_item_ids".
+	Field string `json:"field"`
+	// Rule is the validation rule that failed, e.g. "required".
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Code    Code         `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+	// Err is the underlying cause. It is logged, never sent to the client.
+	Err error `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status is the HTTP status the error is answered with.
+func (e *Error) Status() int {
+	if status, ok := statuses[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Response is the body of every error response.
+type Response struct {
+	Error      *Error `json:"error"`
+	Request_id string `json:"request_id,omitempty"`
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func BadRequest(message string) *Error {
+	return New(CodeBadRequest, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(CodeConflict, message)
+}
+
+// Internal reports a failure of the service. message is shown to the
+// client, cause only in the logs.
+func Internal(message string, cause error) *Error {
+	return &Error{Code: CodeInternal, Message: message, Err: cause}
+}
+
+// Invalid reports a single invalid field found outside of the validator.
+func Invalid(field, message string) *Error {
+	return &Error{
+		Code:    CodeValidationFailed,
+		Message: "the request is invalid",
+		Fields:  []FieldError{{Field: field, Rule: "invalid", Message: message}},
+	}
+}
+
+// Required reports fields that are missing from the request.
+func Required(fields ...string) *Error {
+	err := &Error{Code: CodeValidationFailed, Message: "the request is invalid"}
+	for _, field := range fields {
+		err.Fields = append(err.Fields, FieldError{Field: field, Rule: "required", Message: "is required"})
+	}
+	return err
+}
+
+// Decoding reports a request body that could not be read into its struct.
+func Decoding(err error) *Error {
+	return &Error{Code: CodeBadRequest, Message: "the request body is invalid: " + err.Error(), Err: err}
+}
+
+// Validation converts the error of validator.Struct into a VALIDATION_FAILED
+// error listing every invalid field. The validator must name the fields by
+// their JSON tag, see TagName.
+func Validation(err error) *Error {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return &Error{Code: CodeValidationFailed, Message: err.Error(), Err: err}
+	}
+	fields := make([]FieldError, 0, len(invalid))
+	for _, fe := range invalid {
+		fields = append(fields, FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fieldMessage(fe),
+		})
+	}
+	return &Error{Code: CodeValidationFailed, Message: "the request is invalid", Fields: fields, Err: err}
+}
+
+// From returns err as *Error. Errors that are not, e.g. raw driver errors,
+// become an internal error so their details are not leaked.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal("internal server error", err)
+}
+
+// TagName names struct fields by their JSON tag in validation errors. It is
+// registered with validator.Validate.RegisterTagNameFunc.
+func TagName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// fieldPath drops the struct name the validator puts in front of the field,
+// "User.email" becomes "email".
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	// alternatives such as eq=CASH|eq=CARD are reported with the whole tag
+	if strings.Contains(fe.Tag(), "|") {
+		var values []string
+		for _, alternative := range strings.Split(fe.Tag(), "|") {
+			values = append(values, strings.TrimPrefix(alternative, "eq="))
+		}
+		return "must be one of " + strings.Join(values, ", ")
+	}
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "eq":
+		return "must be " + fe.Param()
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "len":
+		return "must have length " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "lte":
+		return "must be at most " + fe.Param()
+	case "email":
+		return "must be a valid email address"
+	case "datetime":
+		return "must be a time written as " + fe.Param()
+	}
+	return fmt.Sprintf("failed the %s rule", fe.Tag())
+}
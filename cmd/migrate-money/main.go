@@ -0,0 +1,131 @@
+// Command migrate-money rewrites prices stored as floats into the Money
+// format, {amount: <minor units>, currency: <code>}, and replaces the old
+// S/M/L ordered item quantities by a count of one, keeping the size as a
+// "Size" modifier of the item.
+//
+// The service reads both formats, so the migration can run while it is up.
+// Run it once after deploying Money:
+//
+//	CURRENCY=USD go run ./cmd/migrate-money [-dry-run] [-config config.yaml]
+//
+// The database and the currency are taken from the same configuration as
+// the service.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/minhtran241/restaurant-management/config"
+	"github.com/minhtran241/restaurant-management/database"
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only count the documents that would change")
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML configuration file")
+	flag.Parse()
+
+	// the migration does not sign tokens, only the database and billing
+	// settings matter
+	cfg, err := config.Read(*configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.Database.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.Billing.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	models.DefaultCurrency = cfg.Billing.Currency
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	client, err := database.DBinstance(cfg.Database)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db := database.Database(client, cfg.Database)
+	foods := db.Collection("food")
+	orderItems := db.Collection("orderItem")
+
+	migrated, err := migratePrices(ctx, foods, "food_id", "price", *dryRun)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("food prices: %d\n", migrated)
+
+	migrated, err = migratePrices(ctx, orderItems, "order_item_id", "unit_price", *dryRun)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("ordered item prices: %d\n", migrated)
+
+	filter := bson.M{"quantity": bson.M{"$type": "string"}}
+	if *dryRun {
+		count, err := orderItems.CountDocuments(ctx, filter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("ordered item quantities: %d\n", count)
+		return
+	}
+	// an update pipeline, so the size can be read from the quantity it
+	// replaces
+	sizes := mongo.Pipeline{{{Key: "$set", Value: bson.D{
+		{Key: "modifiers", Value: bson.A{bson.D{
+			{Key: "group", Value: "Size"},
+			{Key: "option", Value: "$quantity"},
+			{Key: "price_delta", Value: models.NewMoney(0, models.DefaultCurrency)},
+		}}},
+		{Key: "quantity", Value: 1},
+	}}}}
+	result, err := orderItems.UpdateMany(ctx, filter, sizes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("ordered item quantities: %d\n", result.ModifiedCount)
+}
+
+// migratePrices converts every numeric field of the collection into Money.
+// Decoding into models.Money already understands the legacy numbers, so the
+// value is read through it and written back in the new format.
+func migratePrices(ctx context.Context, collection *mongo.Collection, idField, field string, dryRun bool) (int, error) {
+	cursor, err := collection.Find(ctx, bson.M{field: bson.M{"$type": "number"}})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var writes []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var price models.Money
+		if err := cursor.Current.Lookup(field).Unmarshal(&price); err != nil {
+			return 0, err
+		}
+		id := cursor.Current.Lookup(idField)
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{idField: id}).
+			SetUpdate(bson.M{"$set": bson.M{field: price}}))
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+	if dryRun || len(writes) == 0 {
+		return len(writes), nil
+	}
+
+	if _, err := collection.BulkWrite(ctx, writes); err != nil {
+		return 0, err
+	}
+	return len(writes), nil
+}
@@ -0,0 +1,409 @@
+// Package config holds the settings of the service. They start from
+// defaults, are overridden by an optional YAML or TOML file and then by
+// environment variables, and are validated before the service starts.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	// the restaurant time zone must load where the system has no zoneinfo
+	_ "time/tzdata"
+
+	"github.com/pelletier/go-toml/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v2"
+)
+
+// Duration is a time.Duration written as "30s", "15m" or "24h" in files and
+// environment variables.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+type Config struct {
+	Server     Server     `yaml:"server" toml:"server"`
+	Database   Database   `yaml:"database" toml:"database"`
+	Auth       Auth       `yaml:"auth" toml:"auth"`
+	Restaurant Restaurant `yaml:"restaurant" toml:"restaurant"`
+	Billing    Billing    `yaml:"billing" toml:"billing"`
+}
+
+type Server struct {
+	// Address is the host:port the HTTP server listens on.
+	Address string `yaml:"address" toml:"address"`
+	// Public_url is where clients reach the service, the API documentation
+	// is loaded from it.
+	Public_url string `yaml:"public_url" toml:"public_url"`
+	// Cors_origins lists the origins browsers may call the API from, "*"
+	// allows any.
+	Cors_origins []string `yaml:"cors_origins" toml:"cors_origins"`
+	// Shutdown_timeout is how long in-flight requests may take to finish
+	// once the service is asked to stop.
+	Shutdown_timeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+}
+
+type Database struct {
+	Uri             string   `yaml:"uri" toml:"uri"`
+	Name            string   `yaml:"name" toml:"name"`
+	Connect_timeout Duration `yaml:"connect_timeout" toml:"connect_timeout"`
+	// Query_timeout bounds the database work of a single request.
+	Query_timeout Duration `yaml:"query_timeout" toml:"query_timeout"`
+}
+
+type Auth struct {
+	Secret_key        string   `yaml:"secret_key" toml:"secret_key"`
+	Access_token_ttl  Duration `yaml:"access_token_ttl" toml:"access_token_ttl"`
+	Refresh_token_ttl Duration `yaml:"refresh_token_ttl" toml:"refresh_token_ttl"`
+	Bcrypt_cost       int      `yaml:"bcrypt_cost" toml:"bcrypt_cost"`
+}
+
+type Restaurant struct {
+	// Time_zone is the IANA name of the zone the restaurant is in, menu
+	// schedules are read in it.
+	Time_zone string `yaml:"time_zone" toml:"time_zone"`
+}
+
+// Billing holds the currency and the tax and service charge rules applied
+// to every bill. Rates are percentages.
+type Billing struct {
+	// Currency is the ISO 4217 code of prices and payments.
+	Currency string `yaml:"currency" toml:"currency"`
+	// Default_tax_rate applies to the menu categories without a rate in
+	// Tax_rates.
+	Default_tax_rate float64            `yaml:"default_tax_rate" toml:"default_tax_rate"`
+	Tax_rates        map[string]float64 `yaml:"tax_rates" toml:"tax_rates"`
+	// Service_charge_rate is taken on the subtotal, 0 disables it.
+	Service_charge_rate float64 `yaml:"service_charge_rate" toml:"service_charge_rate"`
+	// Rounding_increment is what the amount due is rounded to a multiple
+	// of, e.g. 0.05.
+	Rounding_increment float64 `yaml:"rounding_increment" toml:"rounding_increment"`
+	// Rounding_mode is HALF_UP, HALF_EVEN, UP or DOWN.
+	Rounding_mode string `yaml:"rounding_mode" toml:"rounding_mode"`
+}
+
+// roundingModes are the valid values of Billing.Rounding_mode.
+var roundingModes = []string{"HALF_UP", "HALF_EVEN", "UP", "DOWN"}
+
+// Location returns the time zone of the restaurant, UTC when it does not
+// load.
+func (r Restaurant) Location() *time.Location {
+	location, err := time.LoadLocation(r.Time_zone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// Default returns the settings used when nothing overrides them. It has no
+// secret key, one must always be configured.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Address:          ":8000",
+			Public_url:       "http://localhost:8000",
+			Cors_origins:     []string{"*"},
+			Shutdown_timeout: Duration(15 * time.Second),
+		},
+		Database: Database{
+			Uri:             "mongodb://localhost:27017",
+			Name:            "restaurant",
+			Connect_timeout: Duration(10 * time.Second),
+			Query_timeout:   Duration(30 * time.Second),
+		},
+		Auth: Auth{
+			Access_token_ttl:  Duration(30 * time.Minute),
+			Refresh_token_ttl: Duration(24 * time.Hour),
+			Bcrypt_cost:       14,
+		},
+		Restaurant: Restaurant{
+			Time_zone: "UTC",
+		},
+		Billing: Billing{
+			Currency:           "USD",
+			Tax_rates:          map[string]float64{},
+			Rounding_increment: 0.01,
+			Rounding_mode:      "HALF_UP",
+		},
+	}
+}
+
+// Load reads the settings and validates them. path names a .yaml, .yml or
+// .toml file; it is optional, an empty path only uses the defaults and the
+// environment.
+func Load(path string) (Config, error) {
+	cfg, err := Read(path)
+	if err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// Read is Load without the validation, for tools that only need part of
+// the settings.
+func Read(path string) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, cfg.readEnv()
+}
+
+func (cfg *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, cfg)
+	case ".toml":
+		err = toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields().Decode(cfg)
+	default:
+		return fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// readEnv applies the environment variables that are set:
+//
+//	LISTEN_ADDR              server address, PORT alone sets ":<PORT>"
+//	PUBLIC_URL               base URL of the service
+//	CORS_ORIGINS             comma separated origins
+//	SHUTDOWN_TIMEOUT         e.g. 15s
+//	MONGODB_URI              connection string
+//	MONGODB_DATABASE         database name
+//	MONGODB_CONNECT_TIMEOUT  e.g. 10s
+//	MONGODB_QUERY_TIMEOUT    e.g. 30s
+//	SECRET_KEY               key signing the tokens
+//	ACCESS_TOKEN_TTL         e.g. 30m
+//	REFRESH_TOKEN_TTL        e.g. 24h
+//	BCRYPT_COST              cost of password hashes
+//	RESTAURANT_TIME_ZONE     e.g. Europe/Paris
+//	CURRENCY                 ISO 4217 code, e.g. EUR
+//	TAX_RATE_DEFAULT         percentage, e.g. 8
+//	TAX_RATES                per menu category, e.g. Drinks=10,Food=8
+//	SERVICE_CHARGE_RATE      percentage of the subtotal
+//	ROUNDING_INCREMENT       e.g. 0.05
+//	ROUNDING_MODE            HALF_UP, HALF_EVEN, UP or DOWN
+func (cfg *Config) readEnv() error {
+	if port := os.Getenv("PORT"); port != "" {
+		cfg.Server.Address = ":" + port
+	}
+	setString(&cfg.Server.Address, "LISTEN_ADDR")
+	setString(&cfg.Server.Public_url, "PUBLIC_URL")
+	if origins := os.Getenv("CORS_ORIGINS"); origins != "" {
+		cfg.Server.Cors_origins = nil
+		for _, origin := range strings.Split(origins, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				cfg.Server.Cors_origins = append(cfg.Server.Cors_origins, origin)
+			}
+		}
+	}
+
+	setString(&cfg.Database.Uri, "MONGODB_URI")
+	setString(&cfg.Database.Name, "MONGODB_DATABASE")
+	setString(&cfg.Auth.Secret_key, "SECRET_KEY")
+	setString(&cfg.Restaurant.Time_zone, "RESTAURANT_TIME_ZONE")
+	setString(&cfg.Billing.Currency, "CURRENCY")
+	setString(&cfg.Billing.Rounding_mode, "ROUNDING_MODE")
+	cfg.Billing.Currency = strings.ToUpper(cfg.Billing.Currency)
+	cfg.Billing.Rounding_mode = strings.ToUpper(cfg.Billing.Rounding_mode)
+
+	durations := []struct {
+		name  string
+		value *Duration
+	}{
+		{"SHUTDOWN_TIMEOUT", &cfg.Server.Shutdown_timeout},
+		{"MONGODB_CONNECT_TIMEOUT", &cfg.Database.Connect_timeout},
+		{"MONGODB_QUERY_TIMEOUT", &cfg.Database.Query_timeout},
+		{"ACCESS_TOKEN_TTL", &cfg.Auth.Access_token_ttl},
+		{"REFRESH_TOKEN_TTL", &cfg.Auth.Refresh_token_ttl},
+	}
+	for _, d := range durations {
+		if value := os.Getenv(d.name); value != "" {
+			if err := d.value.UnmarshalText([]byte(value)); err != nil {
+				return fmt.Errorf("%s: %w", d.name, err)
+			}
+		}
+	}
+
+	if value := os.Getenv("BCRYPT_COST"); value != "" {
+		cost, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("BCRYPT_COST: %w", err)
+		}
+		cfg.Auth.Bcrypt_cost = cost
+	}
+
+	rates := []struct {
+		name  string
+		value *float64
+	}{
+		{"TAX_RATE_DEFAULT", &cfg.Billing.Default_tax_rate},
+		{"SERVICE_CHARGE_RATE", &cfg.Billing.Service_charge_rate},
+		{"ROUNDING_INCREMENT", &cfg.Billing.Rounding_increment},
+	}
+	for _, r := range rates {
+		if value := os.Getenv(r.name); value != "" {
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %w", r.name, err)
+			}
+			*r.value = rate
+		}
+	}
+	if value := os.Getenv("TAX_RATES"); value != "" {
+		cfg.Billing.Tax_rates = map[string]float64{}
+		for _, pair := range strings.Split(value, ",") {
+			category, rate, found := strings.Cut(pair, "=")
+			if !found {
+				return fmt.Errorf("TAX_RATES: %q is not category=rate", pair)
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+			if err != nil {
+				return fmt.Errorf("TAX_RATES: %s: %w", category, err)
+			}
+			cfg.Billing.Tax_rates[strings.TrimSpace(category)] = parsed
+		}
+	}
+	return nil
+}
+
+func setString(field *string, name string) {
+	if value := os.Getenv(name); value != "" {
+		*field = value
+	}
+}
+
+// Validate reports every invalid setting at once.
+func (cfg Config) Validate() error {
+	problems := append(cfg.Server.problems(), cfg.Database.problems()...)
+	problems = append(problems, cfg.Auth.problems()...)
+	problems = append(problems, cfg.Restaurant.problems()...)
+	return invalid(append(problems, cfg.Billing.problems()...))
+}
+
+func (b Billing) Validate() error {
+	return invalid(b.problems())
+}
+
+func (d Database) Validate() error {
+	return invalid(d.problems())
+}
+
+func invalid(problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+}
+
+func (s Server) problems() []string {
+	var problems []string
+	if s.Address == "" {
+		problems = append(problems, "server address is required")
+	}
+	if s.Public_url == "" {
+		problems = append(problems, "server public_url is required")
+	}
+	if len(s.Cors_origins) == 0 {
+		problems = append(problems, `at least one CORS origin is required, use "*" to allow any`)
+	}
+	if s.Shutdown_timeout <= 0 {
+		problems = append(problems, "server shutdown_timeout must be positive")
+	}
+	return problems
+}
+
+func (d Database) problems() []string {
+	var problems []string
+	if d.Uri == "" {
+		problems = append(problems, "database uri is required")
+	}
+	if d.Name == "" {
+		problems = append(problems, "database name is required")
+	}
+	if d.Connect_timeout <= 0 || d.Query_timeout <= 0 {
+		problems = append(problems, "database timeouts must be positive")
+	}
+	return problems
+}
+
+func (a Auth) problems() []string {
+	var problems []string
+	if a.Secret_key == "" {
+		problems = append(problems, "auth secret_key is required, set SECRET_KEY")
+	}
+	if a.Access_token_ttl <= 0 || a.Refresh_token_ttl <= 0 {
+		problems = append(problems, "token lifetimes must be positive")
+	} else if a.Refresh_token_ttl < a.Access_token_ttl {
+		problems = append(problems, "refresh_token_ttl cannot be shorter than access_token_ttl")
+	}
+	if a.Bcrypt_cost < bcrypt.MinCost || a.Bcrypt_cost > bcrypt.MaxCost {
+		problems = append(problems, fmt.Sprintf("bcrypt_cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost))
+	}
+	return problems
+}
+
+func (r Restaurant) problems() []string {
+	var problems []string
+	if _, err := time.LoadLocation(r.Time_zone); err != nil || r.Time_zone == "" {
+		problems = append(problems, fmt.Sprintf("restaurant time_zone %q is not a known time zone", r.Time_zone))
+	}
+	return problems
+}
+
+func (b Billing) problems() []string {
+	var problems []string
+	if len(b.Currency) != 3 || strings.Trim(b.Currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		problems = append(problems, fmt.Sprintf("billing currency %q is not an ISO 4217 code", b.Currency))
+	}
+	if !validRate(b.Default_tax_rate) {
+		problems = append(problems, "billing default_tax_rate must be between 0 and 100")
+	}
+	for category, rate := range b.Tax_rates {
+		if !validRate(rate) {
+			problems = append(problems, fmt.Sprintf("billing tax rate of %s must be between 0 and 100", category))
+		}
+	}
+	if !validRate(b.Service_charge_rate) {
+		problems = append(problems, "billing service_charge_rate must be between 0 and 100")
+	}
+	if b.Rounding_increment <= 0 {
+		problems = append(problems, "billing rounding_increment must be positive")
+	}
+	known := false
+	for _, mode := range roundingModes {
+		known = known || b.Rounding_mode == mode
+	}
+	if !known {
+		problems = append(problems, fmt.Sprintf("billing rounding_mode %q must be one of %s", b.Rounding_mode, strings.Join(roundingModes, ", ")))
+	}
+	return problems
+}
+
+func validRate(rate float64) bool {
+	return rate >= 0 && rate <= 100
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+var auditListParams = listParams{
+	filters: map[string]filterParam{
+		"entity_type": {"entity_type", repository.OpEq, paramString},
+		"entity_id":   {"entity_id", repository.OpEq, paramString},
+		"actor_id":    {"actor_id", repository.OpEq, paramString},
+		"action":      {"action", repository.OpIn, paramList},
+		"from":        {"created_at", repository.OpGte, paramTime},
+		"to":          {"created_at", repository.OpLt, paramTime},
+	},
+	sorts: map[string]string{
+		"created_at": "created_at",
+	},
+	defaultSort: "-created_at",
+}
+
+// GetAudit responds with a page of the audit log as JSON.
+// GetAudit             godoc
+//  @Summary      Get the audit log
+//  @Description  Responds with a page of the changes made through the API, newest first, filtered by entity, user and time range. Every entry holds who made the change, the action, the entity and its state before and after.
+//  @Tags         audit
+//  @Produce      json
+//  @Param        limit        query  int     false  "page size, 20 by default, at most 100"
+//  @Param        offset       query  int     false  "number of items to skip"
+//  @Param        page         query  int     false  "1-based page, used when offset is not given"
+//  @Param        entity_type  query  string  false  "food, menu, table, order, order_item, invoice, reservation, user, note, ingredient, supplier or purchase_order"
+//  @Param        entity_id    query  string  false  "only changes of the entity"
+//  @Param        actor_id     query  string  false  "only changes made by the user"
+//  @Param        action       query  string  false  "comma separated actions: CREATE, UPDATE, DELETE, RESTORE"
+//  @Param        from         query  string  false  "changes made at or after, RFC 3339"
+//  @Param        to           query  string  false  "changes made before, RFC 3339"
+//  @Param        sort         query  string  false  "created_at, prefixed with - for descending"
+//  @Success      200  {object}  controllers.ListResponse
+//  @Router       /audit [get]
+func (ctrl *Controller) GetAudit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		query, queryErr := auditListParams.parse(c)
+		if queryErr != nil {
+			c.Error(queryErr)
+			return
+		}
+		entries, total, err := ctrl.repos.Audit.List(ctx, query)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing the audit log", err))
+			return
+		}
+		c.JSON(http.StatusOK, newListResponse(entries, total, query))
+	}
+}
+
+// audit records a change made by the request, before is nil for a
+// creation. Failures are only logged, the change has already happened.
+func (ctrl *Controller) audit(c *gin.Context, action, entityType, entityId string, before, after interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+	defer cancel()
+
+	entry := models.AuditEntry{
+		ID:          primitive.NewObjectID(),
+		Actor_id:    c.GetString("uid"),
+		Action:      action,
+		Entity_type: entityType,
+		Entity_id:   entityId,
+		Before:      snapshot(before),
+		After:       snapshot(after),
+		Request_id:  c.GetString("request_id"),
+	}
+	entry.Audit_id = entry.ID.Hex()
+	entry.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	if err := ctrl.repos.Audit.Append(ctx, entry); err != nil {
+		log.Printf("failed to audit %s of %s %s: %v", action, entityType, entityId, err)
+	}
+}
+
+// snapshot returns v as the API renders it, which leaves out passwords and
+// tokens.
+func snapshot(v interface{}) map[string]interface{} {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
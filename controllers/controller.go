@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/minhtran241/restaurant-management/config"
+	"github.com/minhtran241/restaurant-management/events"
+	"github.com/minhtran241/restaurant-management/helpers"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+// Controller serves the HTTP handlers. It holds the repositories the
+// handlers read and write, so the same handlers run against MongoDB or the
+// in-memory store, and the bus they publish live events to.
+type Controller struct {
+	repos  *repository.Repositories
+	events *events.Bus
+	// queryTimeout bounds the database work of a single request.
+	queryTimeout time.Duration
+	bcryptCost   int
+	// location is the time zone of the restaurant.
+	location *time.Location
+	// billing holds the tax, service charge and rounding rules of bills.
+	billing helpers.BillingSettings
+	// readinessChecks are the dependencies reported by Readiness.
+	readinessChecks []namedCheck
+}
+
+func New(repos *repository.Repositories, bus *events.Bus, cfg config.Config) *Controller {
+	return &Controller{
+		repos:        repos,
+		events:       bus,
+		queryTimeout: time.Duration(cfg.Database.Query_timeout),
+		bcryptCost:   cfg.Auth.Bcrypt_cost,
+		location:     cfg.Restaurant.Location(),
+		billing:      helpers.NewBillingSettings(cfg.Billing),
+	}
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+// Statuses of the orders and ordered items still in progress, records they
+// refer to cannot be deleted.
+var (
+	openOrderStatuses  = []string{models.OrderOpen, models.OrderSentToKitchen, models.OrderServed, models.OrderBilled}
+	activeItemStatuses = []string{models.ItemQueued, models.ItemCooking, models.ItemReady}
+)
+
+// referenced reports whether list finds a record that is not deleted and
+// matches query, i.e. one that still refers to the record being deleted.
+func referenced[T any](ctx context.Context, list func(context.Context, repository.Query) ([]T, int64, error), query repository.Query) (bool, error) {
+	query.Limit = 1
+	_, total, err := list(ctx, query.NotDeleted())
+	return total > 0, err
+}
+
+// deletionError reports the error of a Delete or Restore of the repositories
+// on the named resource.
+func deletionError(resource string, err error, restoring bool) *apperrors.Error {
+	switch {
+	case err == repository.ErrNotFound:
+		return apperrors.NotFound(resource + " was not found")
+	case err == repository.ErrConflict && restoring:
+		return apperrors.Conflict(resource + " is not deleted")
+	case err == repository.ErrConflict:
+		return apperrors.Conflict(resource + " is already deleted")
+	case restoring:
+		return apperrors.Internal(fmt.Sprintf("error occurred while restoring the %s", resource), err)
+	}
+	return apperrors.Internal(fmt.Sprintf("error occurred while deleting the %s", resource), err)
+}
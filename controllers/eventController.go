@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/events"
+)
+
+const (
+	heartbeatInterval = 15 * time.Second
+	// reconnectDelay is the retry hint sent to EventSource clients, in
+	// milliseconds.
+	reconnectDelay = 3000
+)
+
+// StreamEvents streams order, kitchen and invoice events as Server-Sent Events.
+// StreamEvents             godoc
+//  @Summary      Stream live events
+//  @Description  Streams order, kitchen and invoice events as Server-Sent Events. Filter with table_id, order_id and a comma separated list of event types. Reconnecting clients send the Last-Event-ID header (or last_event_id query) to receive the events they missed. Browsers may pass the token as a query parameter.
+//  @Tags         events
+//  @Produce      text/event-stream
+//  @Param        table_id       query  string  false  "only events of this table"
+//  @Param        order_id       query  string  false  "only events of this order"
+//  @Param        type           query  string  false  "comma separated event types"
+//  @Param        last_event_id  query  int     false  "resume after this event"
+//  @Success      200
+//  @Router       /events/stream [get]
+func (ctrl *Controller) StreamEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := events.Filter{
+			Table_id: c.Query("table_id"),
+			Order_id: c.Query("order_id"),
+		}
+		if types := c.Query("type"); types != "" {
+			filter.Types = strings.Split(types, ",")
+		}
+
+		lastEventId := c.GetHeader("Last-Event-ID")
+		if lastEventId == "" {
+			lastEventId = c.Query("last_event_id")
+		}
+		lastId, _ := strconv.ParseUint(lastEventId, 10, 64)
+
+		sub, backlog := ctrl.events.Subscribe(filter, lastId)
+		defer ctrl.events.Unsubscribe(sub)
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+
+		fmt.Fprintf(c.Writer, "retry: %d\n\n", reconnectDelay)
+		for _, e := range backlog {
+			renderEvent(c, e)
+		}
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case e, ok := <-sub.Events:
+				if !ok {
+					// dropped for being too slow, the client reconnects
+					// with its last event ID
+					return
+				}
+				renderEvent(c, e)
+				c.Writer.Flush()
+			case <-heartbeat.C:
+				c.Writer.WriteString(": heartbeat\n\n")
+				c.Writer.Flush()
+			}
+		}
+	}
+}
+
+func renderEvent(c *gin.Context, e events.Event) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatUint(e.ID, 10),
+		Event: e.Type,
+		Data:  e,
+	})
+}
+
+// tableOfOrder returns the table the order is placed at, or an empty string
+// when it cannot be found. It is only used to label published events.
+func (ctrl *Controller) tableOfOrder(ctx context.Context, orderId string) string {
+	order, err := ctrl.repos.Orders.Get(ctx, orderId)
+	if err != nil || order.Table_id == nil {
+		return ""
+	}
+	return *order.Table_id
+}
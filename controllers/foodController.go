@@ -3,88 +3,81 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"log"
-	"math"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 
-	"github.com/minhtran241/restaurant-management/database"
+	"github.com/minhtran241/restaurant-management/apperrors"
 	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
 )
 
-var foodCollection *mongo.Collection = database.OpenCollection(database.Client, "food")
-var validate = validator.New()
+var validate = newValidator()
 
-// GetFoods responds with the list of all foods as JSON.
+// newValidator reports invalid fields by their JSON name.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(apperrors.TagName)
+	return v
+}
+
+var foodListParams = listParams{
+	filters: map[string]filterParam{
+		"menu_id":   {"menu_id", repository.OpEq, paramString},
+		"name":      {"name", repository.OpEq, paramString},
+		"min_price": {"price.amount", repository.OpGte, paramMoney},
+		"max_price": {"price.amount", repository.OpLte, paramMoney},
+	},
+	sorts: map[string]string{
+		"name": "name", "price": "price.amount", "created_at": "created_at", "updated_at": "updated_at",
+	},
+	defaultSort: "created_at",
+	softDeleted: true,
+}
+
+// foodLists validates the modifier groups and the recipe of a food update
+// on their own.
+type foodLists struct {
+	Modifier_groups []models.ModifierGroup    `json:"modifier_groups" validate:"dive"`
+	Recipe          []models.RecipeIngredient `json:"recipe" validate:"dive"`
+}
+
+// GetFoods responds with a page of food items as JSON.
 // GetFoods             godoc
 //  @Summary      Get all foods
-//  @Description  Responds with the list of all foods as JSON.
+//  @Description  Responds with a page of foods, filtered by menu, name and price range.
 //  @Tags         foods
 //  @Produce      json
-//  @Success      200  {array}  models.Food
+//  @Param        limit   query  int     false  "page size, 20 by default, at most 100"
+//  @Param        offset  query  int     false  "number of items to skip"
+//  @Param        page    query  int     false  "1-based page, used when offset is not given"
+//  @Param        menu_id    query  string  false  "only foods of the menu"
+//  @Param        name       query  string  false  "only foods with this name"
+//  @Param        min_price  query  string  false  "lowest price, e.g. 4.50"
+//  @Param        max_price  query  string  false  "highest price"
+//  @Param        sort       query  string  false  "name, price, created_at or updated_at, prefixed with - for descending"
+//  @Param        include_deleted  query  bool  false  "also list deleted records, admins only"
+//  @Success      200  {object}  controllers.ListResponse
 //  @Router       /foods [get]
-func GetFoods() gin.HandlerFunc {
+func (ctrl *Controller) GetFoods() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 
-		recordPerPage, err := strconv.Atoi(c.Query("recordPerPage"))
-		if err != nil || recordPerPage < 1 {
-			recordPerPage = 10
-		}
-
-		page, err := strconv.Atoi(c.Query("page"))
-		if err != nil || page < 1 {
-			page = 1
-		}
-
-		startIndex := (page - 1) * recordPerPage
-		startIndex, err = strconv.Atoi(c.Query("startIndex"))
-
-		matchStage := bson.D{{Key: "$match", Value: bson.D{{}}}}
-		groupStage := bson.D{
-			{Key: "$group", Value: bson.D{
-				{Key: "_id", Value: bson.D{{Key: "_id", Value: "null"}}},
-				{Key: "total_count", Value: bson.D{{Key: "$sum", Value: 1}}},
-				{Key: "data", Value: bson.D{{Key: "$push", Value: "$$ROOT"}}},
-			}},
-		}
-		projectStage := bson.D{
-			{
-				Key: "$project", Value: bson.D{
-					{Key: "_id", Value: 0},
-					{Key: "total_count", Value: 1},
-					{Key: "food_items", Value: bson.D{
-						{Key: "$slice", Value: []interface{}{"$data", startIndex, recordPerPage}},
-					}},
-				},
-			},
+		query, queryErr := foodListParams.parse(c)
+		if queryErr != nil {
+			c.Error(queryErr)
+			return
 		}
-
-		result, err := foodCollection.Aggregate(
-			ctx, mongo.Pipeline{matchStage, groupStage, projectStage},
-		)
+		foods, total, err := ctrl.repos.Foods.List(ctx, query)
 		if err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "error occurred while listing food items"},
-			)
+			c.Error(apperrors.Internal("error occurred while listing food items", err))
 			return
 		}
-
-		var allFoods []bson.M
-		if err := result.All(ctx, &allFoods); err != nil {
-			log.Fatal(err)
-		}
-		c.JSON(http.StatusOK, allFoods[0])
+		c.JSON(http.StatusOK, newListResponse(foods, total, query))
 	}
 }
 
@@ -95,24 +88,22 @@ func GetFoods() gin.HandlerFunc {
 //  @Tags         foods
 //  @Produce      json
 //  @Success      200  {object}  models.Food
+//  @Header       200  {string}  ETag  "version of the food"
 //  @Router       /foods/{food_id} [get]
-func GetFood() gin.HandlerFunc {
+func (ctrl *Controller) GetFood() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 		foodId := c.Param("food_id")
-		var food models.Food
-		err := foodCollection.FindOne(ctx, bson.M{"food_id": foodId}).Decode(&food)
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "food item was not found"})
+		food, err := ctrl.repos.Foods.Get(ctx, foodId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("food item was not found"))
 			return
 		} else if err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "error occurred when fetching the food item"},
-			)
+			c.Error(apperrors.Internal("error occurred when fetching the food item", err))
 			return
 		}
+		setETag(c, food.Version)
 		c.JSON(http.StatusOK, food)
 	}
 }
@@ -120,37 +111,40 @@ func GetFood() gin.HandlerFunc {
 // CreateFood takes a food JSON and store in DB.
 // CreateFood             godoc
 //  @Summary      Store a new food
-//  @Description  Takes a food JSON and store in DB. Return saved JSON.
+//  @Description  Takes a food JSON and store in DB. The ingredients of its recipe must exist, out_of_stock is worked out from their stock. Return saved JSON.
 //  @Tags         foods
 //  @Produce      json
 //  @Success      200  {object}  models.Food
+//  @Failure      404  {object}  map[string]interface{}
 //  @Router       /foods [post]
-func CreateFood() gin.HandlerFunc {
+func (ctrl *Controller) CreateFood() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
-		var menu models.Menu
 		var food models.Food
 
-		if err := c.BindJSON(&food); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&food); err != nil {
+			c.Error(apperrors.Decoding(err))
 			return
 		}
 
 		validationErr := validate.Struct(food)
 		if validationErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			c.Error(apperrors.Validation(validationErr))
 			return
 		}
 
 		if food.Menu_id != nil {
-			err := menuCollection.FindOne(ctx, bson.M{"menu_id": food.Menu_id}).Decode(&menu)
+			menu, err := ctrl.repos.Menus.Get(ctx, *food.Menu_id)
+			if err == nil && menu.Deleted_at != nil {
+				err = repository.ErrNotFound
+			}
 
-			if err == mongo.ErrNoDocuments {
-				c.JSON(http.StatusNotFound, gin.H{"error": "menu was not found"})
+			if err == repository.ErrNotFound {
+				c.Error(apperrors.NotFound("menu was not found"))
 				return
 			} else if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				c.Error(apperrors.Internal("error occurred when fetching the menu", err))
 				return
 			}
 		}
@@ -158,97 +152,264 @@ func CreateFood() gin.HandlerFunc {
 		food.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		food.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		food.ID = primitive.NewObjectID()
+		food.Deleted_at, food.Deleted_by = nil, nil
+		food.Version = 1
 		food.Food_id = food.ID.Hex()
-		var num = toFixed(*food.Price, 2)
-		food.Price = &num
+		price := models.NewMoney(food.Price.Amount, food.Price.Currency)
+		if price.Currency != models.DefaultCurrency {
+			msg := fmt.Sprintf("must be in %s", models.DefaultCurrency)
+			c.Error(apperrors.Invalid("price", msg))
+			return
+		}
+		food.Price = &price
+		if modifiersErr := checkModifierGroups(food.Modifier_groups); modifiersErr != nil {
+			c.Error(modifiersErr)
+			return
+		}
+		outOfStock, recipeErr := ctrl.checkRecipe(ctx, food.Recipe)
+		if recipeErr != nil {
+			c.Error(recipeErr)
+			return
+		}
+		food.Out_of_stock = outOfStock
+		if food.Available == nil {
+			available := true
+			food.Available = &available
+		}
 
-		result, insertErr := foodCollection.InsertOne(ctx, food)
+		insertErr := ctrl.repos.Foods.Create(ctx, food)
 		if insertErr != nil {
 			msg := fmt.Sprintf("Failed to created food item")
-			c.JSON(http.StatusInternalServerError, msg)
+			c.Error(apperrors.Internal(msg, insertErr))
 			return
 		}
-		c.JSON(http.StatusOK, result)
+		ctrl.audit(c, models.AuditCreate, "food", food.Food_id, nil, food)
+		c.JSON(http.StatusOK, food)
 	}
 }
 
-func round(num float64) int {
-	return int(num + math.Copysign(0.5, num))
-}
-
-func toFixed(num float64, precision int) float64 {
-	output := math.Pow(10, float64(precision))
-	return float64(round(num*output)) / output
-}
-
 // UpdateFood takes a food JSON and update food stored in DB.
 // UpdateFood             godoc
 //  @Summary      Update a food
-//  @Description  Takes a food JSON and update food stored in DB. Return saved JSON.
+//  @Description  Takes a food JSON and update food stored in DB. A recipe replaces the current one, its ingredients must exist and out_of_stock is worked out again. Return saved JSON.
 //  @Tags         foods
 //  @Produce      json
+//  @Param        If-Match  header  string  false  "ETag of the food as read, the update is refused with 409 when it changed since"
 //  @Success      200  {object}  models.Food
+//  @Header       200  {string}  ETag  "version of the food"
+//  @Failure      404  {object}  map[string]interface{}
+//  @Failure      409  {object}  map[string]interface{}
 //  @Router       /foods/{food_id} [patch]
-func UpdateFood() gin.HandlerFunc {
+func (ctrl *Controller) UpdateFood() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
-		var menu models.Menu
 		var food models.Food
 
 		foodId := c.Param("food_id")
 
-		if err := c.BindJSON(&food); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&food); err != nil {
+			c.Error(apperrors.Decoding(err))
 			return
 		}
 
-		var updateObj primitive.D
-
-		if food.Name != nil {
-			updateObj = append(updateObj, bson.E{Key: "name", Value: food.Name})
+		version, matchErr := ifMatch(c)
+		if matchErr != nil {
+			c.Error(matchErr)
+			return
 		}
 
-		if food.Price != nil {
-			updateObj = append(updateObj, bson.E{Key: "price", Value: food.Price})
+		validationErr := validate.StructPartial(food, "Description")
+		if validationErr == nil {
+			validationErr = validate.Struct(foodLists{food.Modifier_groups, food.Recipe})
+		}
+		if validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+		if modifiersErr := checkModifierGroups(food.Modifier_groups); modifiersErr != nil {
+			c.Error(modifiersErr)
+			return
+		}
+		outOfStock, recipeErr := ctrl.checkRecipe(ctx, food.Recipe)
+		if recipeErr != nil {
+			c.Error(recipeErr)
+			return
 		}
+		food.Out_of_stock = outOfStock
 
-		if food.Food_image != nil {
-			updateObj = append(updateObj, bson.E{Key: "foodImage", Value: food.Food_image})
+		if food.Price != nil {
+			price := models.NewMoney(food.Price.Amount, food.Price.Currency)
+			if price.Currency != models.DefaultCurrency {
+				msg := fmt.Sprintf("must be in %s", models.DefaultCurrency)
+				c.Error(apperrors.Invalid("price", msg))
+				return
+			}
+			food.Price = &price
 		}
 
 		if food.Menu_id != nil {
-			err := menuCollection.FindOne(ctx, bson.M{"menu_id": food.Menu_id}).Decode(&menu)
-			if err != nil {
-				msg := fmt.Sprintf("Menu was not found")
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			menu, err := ctrl.repos.Menus.Get(ctx, *food.Menu_id)
+			if err == nil && menu.Deleted_at != nil {
+				err = repository.ErrNotFound
+			}
+			if err == repository.ErrNotFound {
+				c.Error(apperrors.NotFound("menu was not found"))
+				return
+			} else if err != nil {
+				c.Error(apperrors.Internal("error occurred when fetching the menu", err))
 				return
 			}
-			updateObj = append(updateObj, bson.E{Key: "menu_id", Value: food.Menu_id})
+		}
+
+		before, err := ctrl.repos.Foods.Get(ctx, foodId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("food item was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the food item", err))
+			return
 		}
 
 		food.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: food.Updated_at})
 
-		upsert := true
-		filter := bson.M{"food_id": foodId}
+		result, err := ctrl.repos.Foods.Update(ctx, foodId, version, food)
+		if err != nil {
+			c.Error(updateError("food item", err))
+			return
+		}
+		ctrl.audit(c, models.AuditUpdate, "food", foodId, before, result)
+		setETag(c, result.Version)
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// DeleteFood soft deletes the food with provided ID.
+// DeleteFood             godoc
+//  @Summary      Delete a food
+//  @Description  Marks the food deleted, it is hidden from the list of foods and cannot be ordered until restored. A food the kitchen is still preparing for an order cannot be deleted.
+//  @Tags         foods
+//  @Produce      json
+//  @Success      200  {object}  models.Food
+//  @Router       /foods/{food_id} [delete]
+func (ctrl *Controller) DeleteFood() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		foodId := c.Param("food_id")
 
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		preparing, err := referenced(ctx, ctrl.repos.OrderItems.List, repository.Query{}.
+			Where("food_id", repository.OpEq, foodId).
+			Where("status", repository.OpIn, activeItemStatuses))
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing ordered items", err))
+			return
+		}
+		if preparing {
+			c.Error(apperrors.Conflict("food item is still being prepared for an order"))
+			return
 		}
 
-		result, err := foodCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{{Key: "$set", Value: updateObj}},
-			&opt,
-		)
+		deleted_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		food, err := ctrl.repos.Foods.Delete(ctx, foodId, c.GetString("uid"), deleted_at)
+		if err != nil {
+			c.Error(deletionError("food item", err, false))
+			return
+		}
+		before := food
+		before.Deleted_at, before.Deleted_by = nil, nil
+		ctrl.audit(c, models.AuditDelete, "food", foodId, before, food)
+		c.JSON(http.StatusOK, food)
+	}
+}
 
+// RestoreFood clears the deletion of the food with provided ID.
+// RestoreFood             godoc
+//  @Summary      Restore a deleted food
+//  @Description  Clears the deletion of the food. The menu of the food must not be deleted.
+//  @Tags         foods
+//  @Produce      json
+//  @Success      200  {object}  models.Food
+//  @Router       /foods/{food_id}/restore [post]
+func (ctrl *Controller) RestoreFood() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		foodId := c.Param("food_id")
+
+		food, err := ctrl.repos.Foods.Get(ctx, foodId)
 		if err != nil {
-			msg := "Failed to update the food item"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(deletionError("food item", err, true))
 			return
 		}
-		c.JSON(http.StatusOK, result)
+		if food.Menu_id != nil {
+			menu, err := ctrl.repos.Menus.Get(ctx, *food.Menu_id)
+			if err != nil && err != repository.ErrNotFound {
+				c.Error(apperrors.Internal("error occurred when fetching the menu", err))
+				return
+			}
+			if err == nil && menu.Deleted_at != nil {
+				c.Error(apperrors.Conflict("menu of the food item is deleted, restore it first"))
+				return
+			}
+		}
+
+		restored, err := ctrl.repos.Foods.Restore(ctx, foodId)
+		if err != nil {
+			c.Error(deletionError("food item", err, true))
+			return
+		}
+		// the stock of its ingredients may have changed while it was deleted
+		if len(restored.Recipe) > 0 {
+			updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+			ctrl.refreshOutOfStock(ctx, recipeIngredientIds(restored.Recipe), updated_at)
+			if refreshed, err := ctrl.repos.Foods.Get(ctx, foodId); err == nil {
+				restored = refreshed
+			}
+		}
+		ctrl.audit(c, models.AuditRestore, "food", foodId, food, restored)
+		c.JSON(http.StatusOK, restored)
+	}
+}
+
+// checkModifierGroups checks what the validator cannot see in the modifier
+// groups of a food: unique names, consistent bounds and price deltas in the
+// default currency that are not negative. Missing deltas become zero.
+func checkModifierGroups(groups []models.ModifierGroup) *apperrors.Error {
+	groupNames := map[string]bool{}
+	for i, group := range groups {
+		field := fmt.Sprintf("modifier_groups[%d]", i)
+		if groupNames[group.Name] {
+			return apperrors.Invalid(field+".name", "must be unique within the food")
+		}
+		groupNames[group.Name] = true
+		if group.Max > 0 && group.Max < group.Min {
+			return apperrors.Invalid(field+".max", "must be 0 or at least min")
+		}
+		if group.Min > len(group.Options) {
+			return apperrors.Invalid(field+".min", "cannot exceed the number of options")
+		}
+
+		optionNames := map[string]bool{}
+		for j, option := range group.Options {
+			optionField := fmt.Sprintf("%s.options[%d]", field, j)
+			if optionNames[option.Name] {
+				return apperrors.Invalid(optionField+".name", "must be unique within the group")
+			}
+			optionNames[option.Name] = true
+			delta := models.NewMoney(0, "")
+			if option.Price_delta != nil {
+				delta = models.NewMoney(option.Price_delta.Amount, option.Price_delta.Currency)
+			}
+			if delta.Currency != models.DefaultCurrency {
+				msg := fmt.Sprintf("must be in %s", models.DefaultCurrency)
+				return apperrors.Invalid(optionField+".price_delta", msg)
+			}
+			if delta.Amount < 0 {
+				return apperrors.Invalid(optionField+".price_delta", "cannot be negative")
+			}
+			groups[i].Options[j].Price_delta = &delta
+		}
 	}
+	return nil
 }
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds every readiness check, a dependency that does not
+// answer in time counts as down.
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck reports whether a dependency of the service can be used.
+type ReadinessCheck func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check ReadinessCheck
+}
+
+type DependencyStatus struct {
+	Status     string  `json:"status"`
+	Latency_ms float64 `json:"latency_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+type Readiness struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+// AddReadinessCheck registers a dependency checked by Readiness. Checks are
+// registered at startup, before the server accepts requests.
+func (ctrl *Controller) AddReadinessCheck(name string, check ReadinessCheck) {
+	ctrl.readinessChecks = append(ctrl.readinessChecks, namedCheck{name, check})
+}
+
+// Liveness reports that the process is up and serving requests.
+// Liveness             godoc
+//  @Summary      Liveness probe
+//  @Description  Responds with 200 as long as the process serves requests. It does not look at the dependencies.
+//  @Tags         health
+//  @Produce      json
+//  @Success      200  {object}  map[string]interface{}
+//  @Router       /healthz [get]
+func (ctrl *Controller) Liveness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "alive"})
+	}
+}
+
+// Readiness runs every readiness check and reports the status and latency
+// of each dependency.
+// Readiness             godoc
+//  @Summary      Readiness probe
+//  @Description  Pings every dependency (MongoDB and its required indexes) and reports its status and latency. Responds with 503 when one of them is down, so no traffic should be routed to the instance.
+//  @Tags         health
+//  @Produce      json
+//  @Success      200  {object}  controllers.Readiness
+//  @Failure      503  {object}  controllers.Readiness
+//  @Router       /readyz [get]
+func (ctrl *Controller) Readiness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		readiness := Readiness{
+			Status:       "ready",
+			Dependencies: make(map[string]DependencyStatus, len(ctrl.readinessChecks)),
+		}
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, check := range ctrl.readinessChecks {
+			wg.Add(1)
+			go func(check namedCheck) {
+				defer wg.Done()
+				status := runCheck(c.Request.Context(), check.check)
+				mu.Lock()
+				defer mu.Unlock()
+				readiness.Dependencies[check.name] = status
+				if status.Status != "up" {
+					readiness.Status = "not_ready"
+				}
+			}(check)
+		}
+		wg.Wait()
+
+		if readiness.Status != "ready" {
+			c.JSON(http.StatusServiceUnavailable, readiness)
+			return
+		}
+		c.JSON(http.StatusOK, readiness)
+	}
+}
+
+func runCheck(parent context.Context, check ReadinessCheck) DependencyStatus {
+	ctx, cancel := context.WithTimeout(parent, readinessTimeout)
+	defer cancel()
+	start := time.Now()
+	err := check(ctx)
+	status := DependencyStatus{
+		Status:     "up",
+		Latency_ms: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = "down"
+		status.Error = err.Error()
+	}
+	return status
+}
@@ -0,0 +1,420 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+var ingredientListParams = listParams{
+	filters: map[string]filterParam{
+		"name":      {"name", repository.OpEq, paramString},
+		"unit":      {"unit", repository.OpEq, paramString},
+		"low_stock": {"low_stock", repository.OpEq, paramBool},
+	},
+	sorts: map[string]string{
+		"name": "name", "on_hand": "on_hand", "created_at": "created_at", "updated_at": "updated_at",
+	},
+	defaultSort: "name",
+	softDeleted: true,
+}
+
+var stockMovementListParams = listParams{
+	filters: map[string]filterParam{
+		"kind":              {"kind", repository.OpIn, paramList},
+		"order_item_id":     {"order_item_id", repository.OpEq, paramString},
+		"purchase_order_id": {"purchase_order_id", repository.OpEq, paramString},
+		"from":              {"created_at", repository.OpGte, paramTime},
+		"to":                {"created_at", repository.OpLt, paramTime},
+	},
+	sorts: map[string]string{
+		"created_at": "created_at",
+	},
+	defaultSort: "-created_at",
+}
+
+// StockAdjustment is a change of the stock recorded by staff: a delivery or
+// waste of quantity, or a count finding quantity on hand.
+type StockAdjustment struct {
+	Kind     string   `json:"kind" validate:"required,oneof=DELIVERY WASTE COUNT"`
+	Quantity *float64 `json:"quantity" validate:"required,min=0"`
+	Reason   string   `json:"reason" validate:"max=200"`
+}
+
+type StockAdjustmentResponse struct {
+	Ingredient models.Ingredient    `json:"ingredient"`
+	Movement   models.StockMovement `json:"movement"`
+}
+
+// GetIngredients responds with a page of ingredients as JSON.
+// GetIngredients             godoc
+//  @Summary      Get all ingredients
+//  @Description  Responds with a page of ingredients, filtered by name, unit and whether they are low on stock.
+//  @Tags         ingredients
+//  @Produce      json
+//  @Param        limit      query  int     false  "page size, 20 by default, at most 100"
+//  @Param        offset     query  int     false  "number of items to skip"
+//  @Param        page       query  int     false  "1-based page, used when offset is not given"
+//  @Param        name       query  string  false  "only ingredients with this name"
+//  @Param        unit       query  string  false  "g, kg, ml, l or piece"
+//  @Param        low_stock  query  bool    false  "only ingredients at or below their low stock threshold, or only the others"
+//  @Param        sort       query  string  false  "name, on_hand, created_at or updated_at, prefixed with - for descending"
+//  @Param        include_deleted  query  bool  false  "also list deleted records, admins only"
+//  @Success      200  {object}  controllers.ListResponse
+//  @Router       /ingredients [get]
+func (ctrl *Controller) GetIngredients() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		query, queryErr := ingredientListParams.parse(c)
+		if queryErr != nil {
+			c.Error(queryErr)
+			return
+		}
+		ingredients, total, err := ctrl.repos.Ingredients.List(ctx, query)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing ingredients", err))
+			return
+		}
+		c.JSON(http.StatusOK, newListResponse(ingredients, total, query))
+	}
+}
+
+// GetIngredient responds with the ingredient with provided ID as JSON.
+// GetIngredient             godoc
+//  @Summary      Get single ingredient by ID
+//  @Description  Responds with the ingredient with provided ID as JSON.
+//  @Tags         ingredients
+//  @Produce      json
+//  @Success      200  {object}  models.Ingredient
+//  @Header       200  {string}  ETag  "version of the ingredient"
+//  @Router       /ingredients/{ingredient_id} [get]
+func (ctrl *Controller) GetIngredient() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		ingredient, err := ctrl.repos.Ingredients.Get(ctx, c.Param("ingredient_id"))
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("ingredient was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the ingredient", err))
+			return
+		}
+		setETag(c, ingredient.Version)
+		c.JSON(http.StatusOK, ingredient)
+	}
+}
+
+// CreateIngredient takes an ingredient JSON and store in DB.
+// CreateIngredient             godoc
+//  @Summary      Store a new ingredient
+//  @Description  Takes an ingredient JSON and store in DB. A stock on hand is recorded as the first count of the ingredient, the supplier must exist. Return saved JSON.
+//  @Tags         ingredients
+//  @Produce      json
+//  @Success      200  {object}  models.Ingredient
+//  @Router       /ingredients [post]
+func (ctrl *Controller) CreateIngredient() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var ingredient models.Ingredient
+
+		if err := c.ShouldBindJSON(&ingredient); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+
+		validationErr := validate.Struct(ingredient)
+		if validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+		if ingredient.Supplier_id != nil {
+			if _, supplierErr := ctrl.activeSupplier(ctx, *ingredient.Supplier_id); supplierErr != nil {
+				c.Error(supplierErr)
+				return
+			}
+		}
+
+		ingredient.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		ingredient.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		ingredient.ID = primitive.NewObjectID()
+		ingredient.Deleted_at, ingredient.Deleted_by = nil, nil
+		ingredient.Version = 1
+		ingredient.Ingredient_id = ingredient.ID.Hex()
+		ingredient.Low_stock = ingredient.BelowThreshold()
+
+		if err := ctrl.repos.Ingredients.Create(ctx, ingredient); err != nil {
+			c.Error(apperrors.Internal("Failed to create the ingredient", err))
+			return
+		}
+		ctrl.audit(c, models.AuditCreate, "ingredient", ingredient.Ingredient_id, nil, ingredient)
+		if ingredient.On_hand > 0 {
+			ctrl.recordStock(ctx, []models.StockMovement{
+				newStockMovement(c, ingredient, models.StockCount, ingredient.On_hand, "initial stock"),
+			}, ingredient.Created_at)
+		}
+		c.JSON(http.StatusOK, ingredient)
+	}
+}
+
+// UpdateIngredient takes an ingredient JSON and update ingredient stored in DB.
+// UpdateIngredient             godoc
+//  @Summary      Update an ingredient
+//  @Description  Takes an ingredient JSON and changes its name, unit, low stock threshold, par level and supplier. The stock only changes through adjustments, fired orders and received purchase orders. Return saved JSON.
+//  @Tags         ingredients
+//  @Produce      json
+//  @Param        If-Match  header  string  false  "ETag of the ingredient as read, the update is refused with 409 when it changed since"
+//  @Success      200  {object}  models.Ingredient
+//  @Header       200  {string}  ETag  "version of the ingredient"
+//  @Failure      404  {object}  map[string]interface{}
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /ingredients/{ingredient_id} [patch]
+func (ctrl *Controller) UpdateIngredient() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var ingredient models.Ingredient
+		ingredientId := c.Param("ingredient_id")
+
+		if err := c.ShouldBindJSON(&ingredient); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+
+		version, matchErr := ifMatch(c)
+		if matchErr != nil {
+			c.Error(matchErr)
+			return
+		}
+
+		fields := []string{"Low_stock_threshold", "Par_level"}
+		if ingredient.Name != "" {
+			fields = append(fields, "Name")
+		}
+		if ingredient.Unit != "" {
+			fields = append(fields, "Unit")
+		}
+		validationErr := validate.StructPartial(ingredient, fields...)
+		if validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+		if ingredient.Supplier_id != nil {
+			if _, supplierErr := ctrl.activeSupplier(ctx, *ingredient.Supplier_id); supplierErr != nil {
+				c.Error(supplierErr)
+				return
+			}
+		}
+
+		before, err := ctrl.repos.Ingredients.Get(ctx, ingredientId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("ingredient was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the ingredient", err))
+			return
+		}
+
+		ingredient.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		result, err := ctrl.repos.Ingredients.Update(ctx, ingredientId, version, ingredient)
+		if err != nil {
+			c.Error(updateError("ingredient", err))
+			return
+		}
+		ctrl.audit(c, models.AuditUpdate, "ingredient", ingredientId, before, result)
+		setETag(c, result.Version)
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// AdjustStock records a delivery, waste or count of an ingredient.
+// AdjustStock             godoc
+//  @Summary      Adjust the stock of an ingredient
+//  @Description  Takes a stock adjustment JSON: a DELIVERY adds quantity to the stock, WASTE takes it away and a COUNT sets the stock to what was counted. Every adjustment is recorded as a stock movement, and the foods using the ingredient are 86'd or back on once it runs out or is restocked. Waste of more than is on hand is refused with 409.
+//  @Tags         ingredients
+//  @Produce      json
+//  @Success      200  {object}  controllers.StockAdjustmentResponse
+//  @Header       200  {string}  ETag  "version of the ingredient"
+//  @Failure      404  {object}  map[string]interface{}
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /ingredients/{ingredient_id}/adjustments [post]
+func (ctrl *Controller) AdjustStock() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var adjustment StockAdjustment
+		ingredientId := c.Param("ingredient_id")
+
+		if err := c.ShouldBindJSON(&adjustment); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+		if validationErr := validate.Struct(adjustment); validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+		if adjustment.Kind != models.StockCount && *adjustment.Quantity == 0 {
+			c.Error(apperrors.Invalid("quantity", "must be greater than 0"))
+			return
+		}
+
+		before, err := ctrl.repos.Ingredients.Get(ctx, ingredientId)
+		if err == nil && before.Deleted_at != nil {
+			err = repository.ErrNotFound
+		}
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("ingredient was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the ingredient", err))
+			return
+		}
+
+		updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		var result models.Ingredient
+		switch adjustment.Kind {
+		case models.StockDelivery:
+			result, err = ctrl.repos.Ingredients.Adjust(ctx, ingredientId, *adjustment.Quantity, updated_at)
+		case models.StockWaste:
+			result, err = ctrl.repos.Ingredients.Adjust(ctx, ingredientId, -*adjustment.Quantity, updated_at)
+		case models.StockCount:
+			result, err = ctrl.repos.Ingredients.Count(ctx, ingredientId, *adjustment.Quantity, updated_at)
+		}
+		if err == repository.ErrConflict {
+			msg := fmt.Sprintf("less than %v %s of %s is on hand", *adjustment.Quantity, before.Unit, before.Name)
+			c.Error(apperrors.Conflict(msg))
+			return
+		} else if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("ingredient was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("Failed to adjust the stock", err))
+			return
+		}
+
+		// a count changes the stock by what it finds missing or extra
+		movement := newStockMovement(c, result, adjustment.Kind, result.On_hand-before.On_hand, adjustment.Reason)
+		ctrl.recordStock(ctx, []models.StockMovement{movement}, updated_at)
+		ctrl.audit(c, models.AuditUpdate, "ingredient", ingredientId, before, result)
+		setETag(c, result.Version)
+		c.JSON(http.StatusOK, StockAdjustmentResponse{Ingredient: result, Movement: movement})
+	}
+}
+
+// GetStockMovements responds with a page of the stock movements of an
+// ingredient as JSON.
+// GetStockMovements             godoc
+//  @Summary      Get the stock movements of an ingredient
+//  @Description  Responds with a page of the changes of the stock of the ingredient, newest first, filtered by kind, ordered item, purchase order and time range. Usage is recorded when the kitchen fires an ordered item, deliveries when a purchase order is received.
+//  @Tags         ingredients
+//  @Produce      json
+//  @Param        limit          query  int     false  "page size, 20 by default, at most 100"
+//  @Param        offset         query  int     false  "number of items to skip"
+//  @Param        page           query  int     false  "1-based page, used when offset is not given"
+//  @Param        kind           query  string  false  "comma separated kinds: DELIVERY, WASTE, COUNT, USAGE"
+//  @Param        order_item_id  query  string  false  "only the usage of the ordered item"
+//  @Param        purchase_order_id  query  string  false  "only the deliveries of the purchase order"
+//  @Param        from           query  string  false  "movements made at or after, RFC 3339"
+//  @Param        to             query  string  false  "movements made before, RFC 3339"
+//  @Param        sort           query  string  false  "created_at, prefixed with - for descending"
+//  @Success      200  {object}  controllers.ListResponse
+//  @Router       /ingredients/{ingredient_id}/movements [get]
+func (ctrl *Controller) GetStockMovements() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		query, queryErr := stockMovementListParams.parse(c)
+		if queryErr != nil {
+			c.Error(queryErr)
+			return
+		}
+		movements, total, err := ctrl.repos.StockMovements.List(ctx, query.
+			Where("ingredient_id", repository.OpEq, c.Param("ingredient_id")))
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing stock movements", err))
+			return
+		}
+		c.JSON(http.StatusOK, newListResponse(movements, total, query))
+	}
+}
+
+// DeleteIngredient soft deletes the ingredient with provided ID.
+// DeleteIngredient             godoc
+//  @Summary      Delete an ingredient
+//  @Description  Marks the ingredient deleted, it is hidden from the list of ingredients and its stock is no longer tracked until restored. An ingredient in the recipe of a food cannot be deleted.
+//  @Tags         ingredients
+//  @Produce      json
+//  @Success      200  {object}  models.Ingredient
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /ingredients/{ingredient_id} [delete]
+func (ctrl *Controller) DeleteIngredient() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		ingredientId := c.Param("ingredient_id")
+
+		using, err := ctrl.repos.Foods.ListByIngredient(ctx, ingredientId)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing food items", err))
+			return
+		}
+		if len(using) > 0 {
+			c.Error(apperrors.Conflict(fmt.Sprintf("ingredient is in the recipe of %s", foodName(using[0]))))
+			return
+		}
+
+		deleted_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		ingredient, err := ctrl.repos.Ingredients.Delete(ctx, ingredientId, c.GetString("uid"), deleted_at)
+		if err != nil {
+			c.Error(deletionError("ingredient", err, false))
+			return
+		}
+		before := ingredient
+		before.Deleted_at, before.Deleted_by = nil, nil
+		ctrl.audit(c, models.AuditDelete, "ingredient", ingredientId, before, ingredient)
+		c.JSON(http.StatusOK, ingredient)
+	}
+}
+
+// RestoreIngredient clears the deletion of the ingredient with provided ID.
+// RestoreIngredient             godoc
+//  @Summary      Restore a deleted ingredient
+//  @Description  Clears the deletion of the ingredient.
+//  @Tags         ingredients
+//  @Produce      json
+//  @Success      200  {object}  models.Ingredient
+//  @Router       /ingredients/{ingredient_id}/restore [post]
+func (ctrl *Controller) RestoreIngredient() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		ingredientId := c.Param("ingredient_id")
+
+		ingredient, err := ctrl.repos.Ingredients.Get(ctx, ingredientId)
+		if err != nil {
+			c.Error(deletionError("ingredient", err, true))
+			return
+		}
+
+		restored, err := ctrl.repos.Ingredients.Restore(ctx, ingredientId)
+		if err != nil {
+			c.Error(deletionError("ingredient", err, true))
+			return
+		}
+		ctrl.audit(c, models.AuditRestore, "ingredient", ingredientId, ingredient, restored)
+		c.JSON(http.StatusOK, restored)
+	}
+}
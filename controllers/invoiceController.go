@@ -3,59 +3,97 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 
-	"github.com/minhtran241/restaurant-management/database"
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/events"
+	"github.com/minhtran241/restaurant-management/helpers"
 	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
 )
 
 type InvoiceViewFormat struct {
-	Invoice_id       string
-	Payment_method   string
-	Order_id         string
-	Payment_status   *string
-	Payment_due      interface{}
-	Table_number     interface{}
-	Payment_due_date time.Time
-	Order_details    interface{}
+	Invoice_id          string
+	Payment_method      string
+	Order_id            string
+	Payment_status      *string
+	Subtotal            models.Money
+	Taxes               []helpers.TaxLine
+	Tax_total           models.Money
+	Service_charge_rate float64
+	Service_charge      models.Money
+	Rounding_adjustment models.Money
+	Payment_due         models.Money
+	Amount_paid         models.Money
+	Tip_total           models.Money
+	Balance_due         models.Money
+	Table_number        int
+	Payment_due_date    time.Time
+	Order_details       []helpers.BillLine
+	Payments            []models.Payment
+	Splits              []SplitView
 }
 
-var invoiceCollection *mongo.Collection = database.OpenCollection(database.Client, "invoice")
+// SplitView is a split of an invoice with what has been paid towards it.
+type SplitView struct {
+	models.InvoiceSplit
+	Amount_paid models.Money
+	Balance_due models.Money
+}
+
+var invoiceListParams = listParams{
+	filters: map[string]filterParam{
+		"order_id":       {"order_id", repository.OpEq, paramString},
+		"payment_status": {"payment_status", repository.OpIn, paramList},
+		"payment_method": {"payment_method", repository.OpEq, paramString},
+		"due_from":       {"payment_due_date", repository.OpGte, paramTime},
+		"due_to":         {"payment_due_date", repository.OpLt, paramTime},
+	},
+	sorts: map[string]string{
+		"created_at": "created_at", "payment_due_date": "payment_due_date", "updated_at": "updated_at",
+	},
+	defaultSort: "-created_at",
+	softDeleted: true,
+}
 
-// GetInvoices responds with the list of all invoices as JSON.
+// GetInvoices responds with a page of invoices as JSON.
 // GetInvoices             godoc
 //  @Summary      Get all invoices
-//  @Description  Responds with the list of all invoices as JSON.
+//  @Description  Responds with a page of invoices, newest first, filtered by order, payment status and method and due date.
 //  @Tags         invoices
 //  @Produce      json
-//  @Success      200  {array}  models.Invoice
+//  @Param        limit   query  int     false  "page size, 20 by default, at most 100"
+//  @Param        offset  query  int     false  "number of items to skip"
+//  @Param        page    query  int     false  "1-based page, used when offset is not given"
+//  @Param        order_id        query  string  false  "only the invoice of the order"
+//  @Param        payment_status  query  string  false  "comma separated payment statuses"
+//  @Param        payment_method  query  string  false  "CARD or CASH"
+//  @Param        due_from        query  string  false  "due at or after, RFC 3339"
+//  @Param        due_to          query  string  false  "due before, RFC 3339"
+//  @Param        sort            query  string  false  "created_at, payment_due_date or updated_at, prefixed with - for descending"
+//  @Param        include_deleted  query  bool  false  "also list deleted records, admins only"
+//  @Success      200  {object}  controllers.ListResponse
 //  @Router       /invoices [get]
-func GetInvoices() gin.HandlerFunc {
+func (ctrl *Controller) GetInvoices() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
-		result, err := invoiceCollection.Find(ctx, bson.M{})
-		if err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "error occurred while listing invoices"},
-			)
+
+		query, queryErr := invoiceListParams.parse(c)
+		if queryErr != nil {
+			c.Error(queryErr)
 			return
 		}
-		var allInvoices []bson.M
-
-		if err = result.All(ctx, &allInvoices); err != nil {
-			log.Fatal(err)
+		invoices, total, err := ctrl.repos.Invoices.List(ctx, query)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing invoices", err))
+			return
 		}
-		c.JSON(http.StatusOK, allInvoices)
+		c.JSON(http.StatusOK, newListResponse(invoices, total, query))
 	}
 }
 
@@ -65,99 +103,200 @@ func GetInvoices() gin.HandlerFunc {
 //  @Description  Responds with the invoice with provided ID as JSON
 //  @Tags         invoices
 //  @Produce      json
-//  @Success      200  {object}  models.Invoice
+//  @Success      200  {object}  InvoiceViewFormat
+//  @Header       200  {string}  ETag  "version of the invoice"
 //  @Router       /invoices/{invoice_id} [get]
-func GetInvoice() gin.HandlerFunc {
+func (ctrl *Controller) GetInvoice() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 		invoiceId := c.Param("invoice_id")
 
-		var invoice models.Invoice
-
-		err := invoiceCollection.FindOne(ctx, bson.M{"invoice_id": invoiceId}).Decode(&invoice)
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "invoice was not found"})
+		invoice, err := ctrl.repos.Invoices.Get(ctx, invoiceId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("invoice was not found"))
 			return
 		} else if err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "error occurred when fetching the invoice"},
-			)
+			c.Error(apperrors.Internal("error occurred when fetching the invoice", err))
 			return
 		}
 
-		var invoiceView InvoiceViewFormat
-		allOrderItems, err := ItemsByOrder(invoice.Order_id)
-		invoiceView.Order_id = invoice.Order_id
-		invoiceView.Payment_due_date = invoice.Payment_due_date
-		invoiceView.Payment_method = "null"
-		if invoice.Payment_method != nil {
-			invoiceView.Payment_method = *invoice.Payment_method
+		invoiceView, err := ctrl.buildInvoiceView(ctx, invoice)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("order of the invoice was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when computing the invoice", err))
+			return
 		}
-		invoiceView.Invoice_id = invoice.Invoice_id
-		invoiceView.Payment_status = *&invoice.Payment_status
-		invoiceView.Payment_due = allOrderItems[0]["payment_due"]
-		invoiceView.Table_number = allOrderItems[0]["table_number"]
-		invoiceView.Order_details = allOrderItems[0]["order_items"]
 
+		setETag(c, invoice.Version)
 		c.JSON(http.StatusOK, invoiceView)
 	}
 }
 
+// buildInvoiceView prices the order of the invoice and sets the payments
+// against it. It returns repository.ErrNotFound when the order is gone.
+func (ctrl *Controller) buildInvoiceView(ctx context.Context, invoice models.Invoice) (*InvoiceViewFormat, error) {
+	var invoiceView InvoiceViewFormat
+	bill, err := ctrl.BillForOrder(ctx, invoice.Order_id)
+	if err != nil {
+		return nil, err
+	}
+	invoiceView.Order_id = invoice.Order_id
+	invoiceView.Payment_due_date = invoice.Payment_due_date
+	invoiceView.Payment_method = "null"
+	if invoice.Payment_method != nil {
+		invoiceView.Payment_method = *invoice.Payment_method
+	}
+	invoiceView.Invoice_id = invoice.Invoice_id
+	invoiceView.Payment_status = invoice.Payment_status
+	invoiceView.Subtotal = bill.Subtotal
+	invoiceView.Taxes = bill.Taxes
+	invoiceView.Tax_total = bill.Tax_total
+	invoiceView.Service_charge_rate = bill.Service_charge_rate
+	invoiceView.Service_charge = bill.Service_charge
+	invoiceView.Rounding_adjustment = bill.Rounding_adjustment
+	invoiceView.Payment_due = bill.Total
+	invoiceView.Table_number = bill.Table_number
+	invoiceView.Order_details = bill.Order_items
+
+	invoiceView.Payments = invoice.Payments
+	if invoiceView.Payments == nil {
+		invoiceView.Payments = []models.Payment{}
+	}
+	invoiceView.Amount_paid, invoiceView.Tip_total, err = sumPayments(invoice.Payments, "")
+	if err != nil {
+		return nil, err
+	}
+	if invoiceView.Balance_due, err = bill.Total.Sub(invoiceView.Amount_paid); err != nil {
+		return nil, err
+	}
+
+	invoiceView.Splits = []SplitView{}
+	for _, split := range invoice.Splits {
+		paid, _, err := sumPayments(invoice.Payments, split.Split_id)
+		if err != nil {
+			return nil, err
+		}
+		balance, err := split.Amount.Sub(paid)
+		if err != nil {
+			return nil, err
+		}
+		invoiceView.Splits = append(invoiceView.Splits, SplitView{
+			InvoiceSplit: split,
+			Amount_paid:  paid,
+			Balance_due:  balance,
+		})
+	}
+	return &invoiceView, nil
+}
+
+// sumPayments adds up the amounts and tips of the payments, only those taken
+// against splitId unless it is empty.
+func sumPayments(payments []models.Payment, splitId string) (amount, tip models.Money, err error) {
+	amount = models.NewMoney(0, models.DefaultCurrency)
+	tip = models.NewMoney(0, models.DefaultCurrency)
+	for _, payment := range payments {
+		if splitId != "" && payment.Split_id != splitId {
+			continue
+		}
+		if payment.Amount != nil {
+			if amount, err = amount.Add(*payment.Amount); err != nil {
+				return amount, tip, err
+			}
+		}
+		if payment.Tip != nil {
+			if tip, err = tip.Add(*payment.Tip); err != nil {
+				return amount, tip, err
+			}
+		}
+	}
+	return amount, tip, nil
+}
+
 // CreateInvoice takes a invoice JSON and store in DB.
 // CreateInvoice             godoc
 //  @Summary      Store invoice by ID
-//  @Description  Takes a invoice JSON and store in DB. Return saved JSON.
+//  @Description  Takes a invoice JSON and store in DB. Return saved JSON. An order has at most one invoice. An invoice with nothing due is created PAID.
 //  @Tags         invoices
 //  @Produce      json
 //  @Success      200  {object}  models.Invoice
+//  @Failure      409  {object}  map[string]interface{}
 //  @Router       /invoices [post]
-func CreateInvoice() gin.HandlerFunc {
+func (ctrl *Controller) CreateInvoice() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 		var invoice models.Invoice
-		if err := c.BindJSON(&invoice); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&invoice); err != nil {
+			c.Error(apperrors.Decoding(err))
 			return
 		}
 
-		var order models.Order
-
-		err := orderCollection.FindOne(ctx, bson.M{"order_id": invoice.Order_id}).Decode(&order)
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "order was not found"})
+		order, err := ctrl.repos.Orders.Get(ctx, invoice.Order_id)
+		if err == nil && order.Deleted_at != nil {
+			err = repository.ErrNotFound
+		}
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("order was not found"))
 			return
 		} else if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Error(apperrors.Internal("error occurred when fetching the order", err))
+			return
+		}
+		invoiced, err := ctrl.orderInvoiced(ctx, invoice.Order_id)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing invoices", err))
+			return
+		}
+		if invoiced {
+			c.Error(apperrors.Conflict("order already has an invoice"))
 			return
 		}
 
-		status := "PENDING"
-		if invoice.Payment_status == nil {
-			invoice.Payment_status = &status
+		bill, err := ctrl.BillForOrder(ctx, invoice.Order_id)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred when computing the invoice", err))
+			return
 		}
+		// a new invoice has no payments yet, it is only settled when nothing
+		// is due, e.g. for a comped order
+		status := paymentStatus(models.NewMoney(0, bill.Total.Currency), bill.Total)
+		invoice.Payment_status = &status
+		invoice.Payments = []models.Payment{}
+		invoice.Splits = []models.InvoiceSplit{}
 
 		invoice.Payment_due_date, _ = time.Parse(time.RFC3339, time.Now().AddDate(0, 0, 1).Format(time.RFC3339))
 		invoice.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		invoice.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		invoice.ID = primitive.NewObjectID()
+		invoice.Deleted_at, invoice.Deleted_by = nil, nil
+		invoice.Version = 1
 		invoice.Invoice_id = invoice.ID.Hex()
 
 		validationErr := validate.Struct(invoice)
 		if validationErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			c.Error(apperrors.Validation(validationErr))
 			return
 		}
 
-		result, insertErr := invoiceCollection.InsertOne(ctx, invoice)
+		insertErr := ctrl.repos.Invoices.Create(ctx, invoice)
 		if insertErr != nil {
 			msg := fmt.Sprintf("Failed to create invoice")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(apperrors.Internal(msg, insertErr))
 			return
 		}
-		c.JSON(http.StatusOK, result)
+		ctrl.audit(c, models.AuditCreate, "invoice", invoice.Invoice_id, nil, invoice)
+		tableId := ""
+		if order.Table_id != nil {
+			tableId = *order.Table_id
+		}
+		if status == models.PaymentPaid && tableId != "" {
+			ctrl.setTableStatus(ctx, tableId, models.TableNeedsCleaning)
+		}
+		ctrl.events.Publish(events.InvoiceCreated, tableId, invoice.Order_id, invoice)
+		c.JSON(http.StatusOK, invoice)
 	}
 }
 
@@ -167,62 +306,147 @@ func CreateInvoice() gin.HandlerFunc {
 //  @Description  Takes an invoice JSON and update invoice stored in DB. Return saved JSON.
 //  @Tags         invoices
 //  @Produce      json
+//  @Param        If-Match  header  string  false  "ETag of the invoice as read, the update is refused with 409 when it changed since"
 //  @Success      200  {object}  models.Invoice
+//  @Header       200  {string}  ETag  "version of the invoice"
+//  @Failure      404  {object}  map[string]interface{}
+//  @Failure      409  {object}  map[string]interface{}
 //  @Router       /invoices/{invoice_id} [patch]
-func UpdateInvoice() gin.HandlerFunc {
+func (ctrl *Controller) UpdateInvoice() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 		var invoice models.Invoice
 		invoiceId := c.Param("invoice_id")
 
-		if err := c.BindJSON(&invoice); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&invoice); err != nil {
+			c.Error(apperrors.Decoding(err))
 			return
 		}
 
-		var updateObj primitive.D
-
-		if invoice.Payment_method != nil {
-			updateObj = append(
-				updateObj, bson.E{Key: "payment_method", Value: invoice.Payment_method},
-			)
+		version, matchErr := ifMatch(c)
+		if matchErr != nil {
+			c.Error(matchErr)
+			return
 		}
 
+		// the status follows the recorded payments
 		if invoice.Payment_status != nil {
-			updateObj = append(
-				updateObj, bson.E{Key: "payment_status", Value: invoice.Payment_status},
-			)
+			c.Error(apperrors.Invalid("payment_status", "cannot be set directly, record a payment instead"))
+			return
+		}
+
+		before, err := ctrl.repos.Invoices.Get(ctx, invoiceId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("invoice was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the invoice", err))
+			return
 		}
 
 		invoice.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: invoice.Updated_at})
 
-		upsert := true
-		filter := bson.M{"invoice_id": invoiceId}
+		updated, err := ctrl.repos.Invoices.Update(ctx, invoiceId, version, invoice)
+		if err != nil {
+			c.Error(updateError("invoice", err))
+			return
+		}
+		ctrl.audit(c, models.AuditUpdate, "invoice", invoiceId, before, updated)
 
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		ctrl.events.Publish(
+			events.InvoiceUpdated, ctrl.tableOfOrder(ctx, updated.Order_id), updated.Order_id, updated,
+		)
+		setETag(c, updated.Version)
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// DeleteInvoice soft deletes the invoice with provided ID.
+// DeleteInvoice             godoc
+//  @Summary      Delete an invoice
+//  @Description  Marks the invoice deleted, it is hidden from the list of invoices until restored. An invoice with recorded payments cannot be deleted.
+//  @Tags         invoices
+//  @Produce      json
+//  @Success      200  {object}  models.Invoice
+//  @Router       /invoices/{invoice_id} [delete]
+func (ctrl *Controller) DeleteInvoice() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		invoiceId := c.Param("invoice_id")
+
+		invoice, err := ctrl.repos.Invoices.Get(ctx, invoiceId)
+		if err != nil {
+			c.Error(deletionError("invoice", err, false))
+			return
+		}
+		if len(invoice.Payments) > 0 {
+			c.Error(apperrors.Conflict("invoice has recorded payments"))
+			return
 		}
 
-		status := "PENDING"
-		if invoice.Payment_status == nil {
-			invoice.Payment_status = &status
+		deleted_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		deleted, err := ctrl.repos.Invoices.Delete(ctx, invoiceId, c.GetString("uid"), deleted_at)
+		if err != nil {
+			c.Error(deletionError("invoice", err, false))
+			return
 		}
+		ctrl.audit(c, models.AuditDelete, "invoice", invoiceId, invoice, deleted)
+		c.JSON(http.StatusOK, deleted)
+	}
+}
 
-		result, err := invoiceCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{Key: "$set", Value: updateObj},
-			},
-			&opt,
-		)
+// RestoreInvoice clears the deletion of the invoice with provided ID.
+// RestoreInvoice             godoc
+//  @Summary      Restore a deleted invoice
+//  @Description  Clears the deletion of the invoice. Its order must not be deleted.
+//  @Tags         invoices
+//  @Produce      json
+//  @Success      200  {object}  models.Invoice
+//  @Router       /invoices/{invoice_id}/restore [post]
+func (ctrl *Controller) RestoreInvoice() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		invoiceId := c.Param("invoice_id")
+
+		invoice, err := ctrl.repos.Invoices.Get(ctx, invoiceId)
+		if err != nil {
+			c.Error(deletionError("invoice", err, true))
+			return
+		}
+		order, err := ctrl.repos.Orders.Get(ctx, invoice.Order_id)
+		if err != nil && err != repository.ErrNotFound {
+			c.Error(apperrors.Internal("error occurred when fetching the order", err))
+			return
+		}
+		if err == nil && order.Deleted_at != nil {
+			c.Error(apperrors.Conflict("order of the invoice is deleted, restore it first"))
+			return
+		}
+		invoiced, err := ctrl.orderInvoiced(ctx, invoice.Order_id)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing invoices", err))
+			return
+		}
+		if invoiced && invoice.Deleted_at != nil {
+			c.Error(apperrors.Conflict("order of the invoice has another invoice"))
+			return
+		}
+
+		restored, err := ctrl.repos.Invoices.Restore(ctx, invoiceId)
 		if err != nil {
-			msg := "Failed to update the invoice"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(deletionError("invoice", err, true))
 			return
 		}
-		c.JSON(http.StatusOK, result)
+		ctrl.audit(c, models.AuditRestore, "invoice", invoiceId, invoice, restored)
+		c.JSON(http.StatusOK, restored)
 	}
 }
+
+// orderInvoiced tells whether the order has an invoice that is not deleted.
+func (ctrl *Controller) orderInvoiced(ctx context.Context, orderId string) (bool, error) {
+	return referenced(ctx, ctrl.repos.Invoices.List, repository.Query{}.
+		Where("order_id", repository.OpEq, orderId))
+}
@@ -0,0 +1,334 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/events"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+// defaultLateAfter is how long a ticket may wait before it is flagged late.
+const defaultLateAfter = 15 * time.Minute
+
+type KitchenTicketItem struct {
+	Order_item_id     string
+	Food_id           string
+	Food_name         string
+	Quantity          int
+	Status            string
+	Created_at        time.Time
+	Status_updated_at time.Time
+	Age_seconds       int64
+	Is_late           bool
+	// Modifiers are the picked options, e.g. "Size: Large".
+	Modifiers []string
+	Notes     []string
+}
+
+type KitchenTicket struct {
+	Order_id     string
+	Table_id     string
+	Table_number int
+	Created_at   time.Time
+	Age_seconds  int64
+	Is_late      bool
+	Items        []KitchenTicketItem
+}
+
+// itemTransitions lists, for every preparation status, the statuses a cook
+// or waiter may move an item to. READY can go back to COOKING to re-fire it.
+var itemTransitions = map[string][]string{
+	models.ItemQueued:  {models.ItemCooking, models.ItemVoided},
+	models.ItemCooking: {models.ItemReady, models.ItemVoided},
+	models.ItemReady:   {models.ItemServed, models.ItemCooking},
+	models.ItemServed:  {},
+	models.ItemVoided:  {},
+}
+
+// itemBumps is the next status of an item when a cook bumps it.
+var itemBumps = map[string]string{
+	models.ItemQueued:  models.ItemCooking,
+	models.ItemCooking: models.ItemReady,
+	models.ItemReady:   models.ItemServed,
+}
+
+func canTransitionItem(from, to string) bool {
+	for _, next := range itemTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// GetKitchenTickets responds with the kitchen queue as JSON.
+// GetKitchenTickets             godoc
+//  @Summary      Get the kitchen queue
+//  @Description  Responds with the ordered items that are QUEUED, COOKING or READY grouped into one ticket per order, oldest ticket first. Tickets and items older than late_after minutes (default 15) are flagged late.
+//  @Tags         kitchen
+//  @Produce      json
+//  @Param        late_after  query  int  false  "minutes after which a ticket is late"
+//  @Success      200  {array}  KitchenTicket
+//  @Router       /kitchen/tickets [get]
+func (ctrl *Controller) GetKitchenTickets() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		lateAfter := defaultLateAfter
+		if minutes, err := strconv.Atoi(c.Query("late_after")); err == nil && minutes > 0 {
+			lateAfter = time.Duration(minutes) * time.Minute
+		}
+
+		tickets, err := ctrl.kitchenTickets(ctx)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing kitchen tickets", err))
+			return
+		}
+
+		now := time.Now()
+		for i := range tickets {
+			ticket := &tickets[i]
+			ticket.Age_seconds = int64(now.Sub(ticket.Created_at).Seconds())
+			ticket.Is_late = now.Sub(ticket.Created_at) > lateAfter
+			for j := range ticket.Items {
+				item := &ticket.Items[j]
+				item.Age_seconds = int64(now.Sub(item.Created_at).Seconds())
+				// an item waiting at the pass is not the kitchen's delay
+				item.Is_late = item.Status != models.ItemReady && now.Sub(item.Created_at) > lateAfter
+			}
+		}
+		c.JSON(http.StatusOK, tickets)
+	}
+}
+
+// kitchenTickets groups the items waiting in the kitchen by order, with the
+// food name, notes and table joined in. As the items come oldest first, so do the
+// tickets.
+func (ctrl *Controller) kitchenTickets(ctx context.Context) ([]KitchenTicket, error) {
+	orderItems, err := ctrl.repos.OrderItems.ListByStatus(
+		ctx, models.ItemQueued, models.ItemCooking, models.ItemReady,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	foodIds, orderIds, orderItemIds := []string{}, []string{}, []string{}
+	for _, orderItem := range orderItems {
+		if orderItem.Food_id != nil {
+			foodIds = append(foodIds, *orderItem.Food_id)
+		}
+		orderIds = append(orderIds, orderItem.Order_id)
+		orderItemIds = append(orderItemIds, orderItem.Order_item_id)
+	}
+	foods, err := ctrl.repos.Foods.GetMany(ctx, foodIds)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := ctrl.repos.Orders.GetMany(ctx, orderIds)
+	if err != nil {
+		return nil, err
+	}
+	tableIds := []string{}
+	for _, order := range orders {
+		if order.Table_id != nil {
+			tableIds = append(tableIds, *order.Table_id)
+		}
+	}
+	tables, err := ctrl.repos.Tables.GetMany(ctx, tableIds)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := ctrl.itemNotes(ctx, orderItemIds)
+	if err != nil {
+		return nil, err
+	}
+
+	tickets := []KitchenTicket{}
+	ticketOf := map[string]int{}
+	for _, orderItem := range orderItems {
+		i, ok := ticketOf[orderItem.Order_id]
+		if !ok {
+			ticket := KitchenTicket{Order_id: orderItem.Order_id, Created_at: orderItem.Created_at}
+			if order, ok := orders[orderItem.Order_id]; ok && order.Table_id != nil {
+				ticket.Table_id = *order.Table_id
+				if table, ok := tables[*order.Table_id]; ok && table.Table_number != nil {
+					ticket.Table_number = *table.Table_number
+				}
+			}
+			i = len(tickets)
+			ticketOf[orderItem.Order_id] = i
+			tickets = append(tickets, ticket)
+		}
+
+		item := KitchenTicketItem{
+			Order_item_id:     orderItem.Order_item_id,
+			Quantity:          1,
+			Status:            *orderItem.Status,
+			Created_at:        orderItem.Created_at,
+			Status_updated_at: orderItem.Status_updated_at,
+			Notes:             notes[orderItem.Order_item_id],
+		}
+		if orderItem.Quantity != nil {
+			item.Quantity = *orderItem.Quantity
+		}
+		for _, modifier := range orderItem.Modifiers {
+			item.Modifiers = append(item.Modifiers, modifier.String())
+		}
+		if orderItem.Food_id != nil {
+			item.Food_id = *orderItem.Food_id
+			if food, ok := foods[*orderItem.Food_id]; ok && food.Name != nil {
+				item.Food_name = *food.Name
+			}
+		}
+		tickets[i].Items = append(tickets[i].Items, item)
+	}
+	return tickets, nil
+}
+
+type itemStatusRequest struct {
+	Status *string `json:"status" validate:"required,eq=QUEUED|eq=COOKING|eq=READY|eq=SERVED|eq=VOIDED"`
+}
+
+// BumpOrderItem moves an ordered item to the next preparation status.
+// BumpOrderItem             godoc
+//  @Summary      Bump an ordered item
+//  @Description  Moves the ordered item to its next preparation status (QUEUED -> COOKING -> READY -> SERVED). Firing a QUEUED item takes the ingredients of its food from the stock, it is refused with 409 when a required one is short.
+//  @Tags         kitchen
+//  @Produce      json
+//  @Success      200  {object}  models.OrderItem
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /kitchen/items/{order_item_id}/bump [post]
+func (ctrl *Controller) BumpOrderItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		orderItem, ok := ctrl.findKitchenItem(ctx, c)
+		if !ok {
+			return
+		}
+		next, ok := itemBumps[*orderItem.Status]
+		if !ok {
+			msg := fmt.Sprintf("ordered item is %s and cannot be bumped", *orderItem.Status)
+			c.Error(apperrors.Conflict(msg))
+			return
+		}
+		ctrl.setItemStatus(ctx, c, orderItem, next)
+	}
+}
+
+// UpdateOrderItemStatus sets the preparation status of an ordered item.
+// UpdateOrderItemStatus             godoc
+//  @Summary      Set the preparation status of an ordered item
+//  @Description  Takes a status JSON and moves the ordered item to it, used to void, re-fire or serve items. Items of an invoiced order cannot be voided. Moving a QUEUED item to COOKING takes the ingredients of its food from the stock, it is refused with 409 when a required one is short.
+//  @Tags         kitchen
+//  @Produce      json
+//  @Success      200  {object}  models.OrderItem
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /kitchen/items/{order_item_id}/status [post]
+func (ctrl *Controller) UpdateOrderItemStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var req itemStatusRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+		if validationErr := validate.Struct(req); validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+
+		orderItem, ok := ctrl.findKitchenItem(ctx, c)
+		if !ok {
+			return
+		}
+		if !canTransitionItem(*orderItem.Status, *req.Status) {
+			msg := fmt.Sprintf("ordered item cannot move from %s to %s", *orderItem.Status, *req.Status)
+			c.Error(apperrors.Conflict(msg))
+			return
+		}
+		if *req.Status == models.ItemVoided {
+			// voided items drop off the bill, which is settled against once
+			// the order is invoiced
+			invoiced, err := ctrl.orderInvoiced(ctx, orderItem.Order_id)
+			if err != nil {
+				c.Error(apperrors.Internal("error occurred while listing invoices", err))
+				return
+			}
+			if invoiced {
+				c.Error(apperrors.Conflict("order of the ordered item has an invoice"))
+				return
+			}
+		}
+		ctrl.setItemStatus(ctx, c, orderItem, *req.Status)
+	}
+}
+
+// findKitchenItem loads the ordered item named in the URL and writes the
+// error response itself when it cannot.
+func (ctrl *Controller) findKitchenItem(ctx context.Context, c *gin.Context) (models.OrderItem, bool) {
+	orderItem, err := ctrl.repos.OrderItems.Get(ctx, c.Param("order_item_id"))
+	if err == repository.ErrNotFound {
+		c.Error(apperrors.NotFound("ordered item was not found"))
+		return orderItem, false
+	} else if err != nil {
+		c.Error(apperrors.Internal("error occurred when fetching the ordered item", err))
+		return orderItem, false
+	}
+	if orderItem.Status == nil {
+		// items created before the kitchen display existed never reached it
+		status := models.ItemServed
+		orderItem.Status = &status
+	}
+	return orderItem, true
+}
+
+// setItemStatus moves the ordered item to status, provided nobody changed
+// it since it was read, and responds with the updated item. Firing a queued
+// item takes the ingredients of its food from the stock.
+func (ctrl *Controller) setItemStatus(ctx context.Context, c *gin.Context, orderItem models.OrderItem, status string) {
+	updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	var movements []models.StockMovement
+	if *orderItem.Status == models.ItemQueued && status == models.ItemCooking {
+		var stockErr *apperrors.Error
+		movements, stockErr = ctrl.takeStock(ctx, c, orderItem, updated_at)
+		if stockErr != nil {
+			c.Error(stockErr)
+			return
+		}
+	}
+	err := ctrl.repos.OrderItems.SetStatus(ctx, orderItem.Order_item_id, orderItem.Status, status, updated_at)
+	if err != nil {
+		ctrl.undoStock(ctx, movements, updated_at)
+	}
+	if err == repository.ErrConflict {
+		c.Error(apperrors.Conflict("ordered item was changed by someone else, please retry"))
+		return
+	} else if err != nil {
+		c.Error(apperrors.Internal("Failed to update the ordered item", err))
+		return
+	}
+	ctrl.recordStock(ctx, movements, updated_at)
+
+	before := orderItem
+	orderItem.Status = &status
+	orderItem.Status_updated_at = updated_at
+	orderItem.Updated_at = updated_at
+	ctrl.audit(c, models.AuditUpdate, "order_item", orderItem.Order_item_id, before, orderItem)
+	ctrl.events.Publish(
+		events.OrderItemStatusChanged, ctrl.tableOfOrder(ctx, orderItem.Order_id), orderItem.Order_id, orderItem,
+	)
+	c.JSON(http.StatusOK, orderItem)
+}
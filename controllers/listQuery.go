@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// Kinds of filter parameters, they tell how the value is parsed.
+const (
+	paramString = iota
+	paramInt
+	paramTime
+	paramMoney
+	paramBool
+	// paramList is a comma separated list of strings, any of them matches.
+	paramList
+)
+
+type filterParam struct {
+	field string
+	op    string
+	kind  int
+}
+
+// listParams describes the query parameters of a list route. Besides its
+// filters every list route takes limit (20 by default, at most 100), offset
+// or page, and sort: a comma separated list of sort names, a leading "-"
+// sorts descending.
+type listParams struct {
+	filters map[string]filterParam
+	// sorts maps the names accepted by sort to document fields.
+	sorts       map[string]string
+	defaultSort string
+	// softDeleted routes hide deleted records unless an admin passes
+	// include_deleted=true.
+	softDeleted bool
+}
+
+// ListResponse is the envelope of every list route.
+type ListResponse struct {
+	Items       interface{} `json:"items"`
+	Total_count int64       `json:"total_count"`
+	Offset      int         `json:"offset"`
+	Limit       int         `json:"limit"`
+	Has_more    bool        `json:"has_more"`
+}
+
+func newListResponse(items interface{}, total int64, query repository.Query) ListResponse {
+	return ListResponse{
+		Items:       items,
+		Total_count: total,
+		Offset:      query.Offset,
+		Limit:       query.Limit,
+		Has_more:    int64(query.Offset+query.Limit) < total,
+	}
+}
+
+// parse turns the query parameters of the request into a repository query.
+func (p listParams) parse(c *gin.Context) (repository.Query, *apperrors.Error) {
+	query := repository.Query{Limit: defaultListLimit}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return query, apperrors.Invalid("limit", fmt.Sprintf("must be a number between 1 and %d", maxListLimit))
+		}
+		query.Limit = limit
+	}
+	if value := c.Query("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return query, apperrors.Invalid("offset", "must be a number of at least 0")
+		}
+		query.Offset = offset
+	} else if value := c.Query("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return query, apperrors.Invalid("page", "must be a number of at least 1")
+		}
+		query.Offset = (page - 1) * query.Limit
+	}
+
+	sortParam := c.Query("sort")
+	if sortParam == "" {
+		sortParam = p.defaultSort
+	}
+	for _, name := range strings.Split(sortParam, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		desc := strings.HasPrefix(name, "-")
+		field, ok := p.sorts[strings.TrimPrefix(name, "-")]
+		if !ok {
+			return query, apperrors.Invalid("sort", "must be one of "+strings.Join(p.sortNames(), ", "))
+		}
+		query.Sort = append(query.Sort, repository.Sort{Field: field, Desc: desc})
+	}
+
+	for name, filter := range p.filters {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseParam(value, filter.kind)
+		if err != nil {
+			return query, apperrors.Invalid(name, err.Error())
+		}
+		op := filter.op
+		if filter.kind == paramList {
+			op = repository.OpIn
+		}
+		query = query.Where(filter.field, op, parsed)
+	}
+
+	if p.softDeleted {
+		includeDeleted, err := strconv.ParseBool(c.DefaultQuery("include_deleted", "false"))
+		if err != nil {
+			return query, apperrors.Invalid("include_deleted", "must be true or false")
+		}
+		if includeDeleted && c.GetString("role") != models.RoleAdmin {
+			return query, apperrors.Forbidden("only admins can list deleted records")
+		}
+		if !includeDeleted {
+			query = query.NotDeleted()
+		}
+	}
+	return query, nil
+}
+
+func (p listParams) sortNames() []string {
+	names := make([]string, 0, len(p.sorts))
+	for name := range p.sorts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parseParam(value string, kind int) (interface{}, error) {
+	switch kind {
+	case paramInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("must be a whole number")
+		}
+		return n, nil
+	case paramTime:
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("must be an RFC 3339 time")
+		}
+		return t, nil
+	case paramMoney:
+		m, err := models.ParseMoney(value, models.DefaultCurrency)
+		if err != nil {
+			return nil, fmt.Errorf("must be an amount in %s", models.DefaultCurrency)
+		}
+		return m.Amount, nil
+	case paramBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return b, nil
+	case paramList:
+		var values []string
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		return values, nil
+	}
+	return value, nil
+}
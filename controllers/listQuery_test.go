@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+var testListParams = listParams{
+	filters: map[string]filterParam{
+		"table_id":  {"table_id", repository.OpEq, paramString},
+		"guests":    {"number_of_guests", repository.OpGte, paramInt},
+		"from":      {"created_at", repository.OpGte, paramTime},
+		"max_price": {"price.amount", repository.OpLte, paramMoney},
+		"available": {"available", repository.OpEq, paramBool},
+		"status":    {"status", repository.OpEq, paramList},
+	},
+	sorts:       map[string]string{"name": "name", "price": "price.amount", "created_at": "created_at"},
+	defaultSort: "-created_at",
+	softDeleted: true,
+}
+
+// parseList parses the query string as the list route would for a user
+// with role.
+func parseList(rawQuery, role string) (repository.Query, *apperrors.Error) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/items?"+rawQuery, nil)
+	c.Set("role", role)
+	return testListParams.parse(c)
+}
+
+func TestListParamsPaging(t *testing.T) {
+	tests := []struct {
+		query         string
+		offset, limit int
+	}{
+		{"", 0, defaultListLimit},
+		{"limit=5", 0, 5},
+		{"limit=100&offset=250", 250, 100},
+		{"limit=10&page=3", 20, 10},
+		{"page=2", defaultListLimit, defaultListLimit},
+		// offset wins over page
+		{"offset=7&page=3", 7, defaultListLimit},
+	}
+	for _, tt := range tests {
+		query, err := parseList(tt.query, models.RoleWaiter)
+		if err != nil {
+			t.Errorf("%q: %v", tt.query, err)
+			continue
+		}
+		if query.Offset != tt.offset || query.Limit != tt.limit {
+			t.Errorf("%q: offset %d and limit %d, want %d and %d", tt.query, query.Offset, query.Limit, tt.offset, tt.limit)
+		}
+	}
+}
+
+func TestListParamsSort(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []repository.Sort
+	}{
+		{"", []repository.Sort{{Field: "created_at", Desc: true}}},
+		{"sort=name", []repository.Sort{{Field: "name"}}},
+		{"sort=-price,%20name", []repository.Sort{{Field: "price.amount", Desc: true}, {Field: "name"}}},
+		{"sort=name,,", []repository.Sort{{Field: "name"}}},
+	}
+	for _, tt := range tests {
+		query, err := parseList(tt.query, models.RoleWaiter)
+		if err != nil {
+			t.Errorf("%q: %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(query.Sort, tt.want) {
+			t.Errorf("%q: sorts by %+v, want %+v", tt.query, query.Sort, tt.want)
+		}
+	}
+}
+
+func TestListParamsFilters(t *testing.T) {
+	query, err := parseList(
+		"table_id=t1&guests=4&from=2024-05-10T18:00:00Z&max_price=12.5&available=true&status=OPEN,+SERVED,",
+		models.RoleWaiter,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]repository.Filter{
+		"table_id":         {Field: "table_id", Op: repository.OpEq, Value: "t1"},
+		"number_of_guests": {Field: "number_of_guests", Op: repository.OpGte, Value: 4},
+		"created_at":       {Field: "created_at", Op: repository.OpGte, Value: time.Date(2024, 5, 10, 18, 0, 0, 0, time.UTC)},
+		"price.amount":     {Field: "price.amount", Op: repository.OpLte, Value: int64(1250)},
+		"available":        {Field: "available", Op: repository.OpEq, Value: true},
+		"status":           {Field: "status", Op: repository.OpIn, Value: []string{"OPEN", "SERVED"}},
+		"deleted_at":       {Field: "deleted_at", Op: repository.OpEq, Value: nil},
+	}
+	got := map[string]repository.Filter{}
+	for _, filter := range query.Filters {
+		got[filter.Field] = filter
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("filters are %+v, want %+v", got, want)
+	}
+}
+
+func TestListParamsDeleted(t *testing.T) {
+	query, err := parseList("include_deleted=true", models.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(query.Filters) != 0 {
+		t.Errorf("admin listing deleted records filters %+v, want nothing", query.Filters)
+	}
+	if _, err := parseList("include_deleted=true", models.RoleManager); err == nil || err.Status() != http.StatusForbidden {
+		t.Errorf("manager listing deleted records: got %v, want 403", err)
+	}
+}
+
+func TestListParamsInvalid(t *testing.T) {
+	for _, query := range []string{
+		"limit=0", "limit=101", "limit=ten", "offset=-1", "page=0", "page=x",
+		"sort=unknown", "sort=name,-unknown", "guests=four", "guests=1.5",
+		"from=yesterday", "max_price=cheap", "max_price=1.2.3", "available=maybe",
+		"include_deleted=perhaps",
+	} {
+		if _, err := parseList(query, models.RoleAdmin); err == nil || err.Status() != http.StatusBadRequest {
+			t.Errorf("%q: got %v, want 400", query, err)
+		}
+	}
+}
+
+func TestNewListResponse(t *testing.T) {
+	tests := []struct {
+		offset, limit int
+		total         int64
+		hasMore       bool
+	}{
+		{0, 20, 45, true},
+		{40, 20, 45, false},
+		{20, 20, 40, false},
+		{0, 20, 0, false},
+	}
+	for _, tt := range tests {
+		response := newListResponse(nil, tt.total, repository.Query{Offset: tt.offset, Limit: tt.limit})
+		if response.Has_more != tt.hasMore {
+			t.Errorf("page at %d of %d out of %d has more: %v, want %v", tt.offset, tt.limit, tt.total, response.Has_more, tt.hasMore)
+		}
+	}
+}
@@ -3,48 +3,139 @@ package controllers
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 
-	"github.com/minhtran241/restaurant-management/database"
+	"github.com/minhtran241/restaurant-management/apperrors"
 	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
 )
 
-var menuCollection *mongo.Collection = database.OpenCollection(database.Client, "menu")
+var menuListParams = listParams{
+	filters: map[string]filterParam{
+		"category": {"category", repository.OpEq, paramString},
+		"name":     {"name", repository.OpEq, paramString},
+	},
+	sorts: map[string]string{
+		"name": "name", "category": "category", "start_date": "start_date", "end_date": "end_date", "created_at": "created_at",
+	},
+	defaultSort: "created_at",
+	softDeleted: true,
+}
+
+// menuSchedule validates the schedule of a menu update on its own.
+type menuSchedule struct {
+	Schedule []models.MenuWindow `json:"schedule" validate:"dive"`
+}
+
+// ActiveMenu is a menu served at the requested time with the foods that can
+// be ordered from it.
+type ActiveMenu struct {
+	models.Menu
+	Foods []models.Food `json:"foods"`
+}
+
+// ActiveMenusResponse holds the menus served at a time, by name.
+type ActiveMenusResponse struct {
+	At    time.Time    `json:"at"`
+	Menus []ActiveMenu `json:"menus"`
+}
 
-// GetMenus responds with the list of all menus as JSON.
+// GetMenus responds with a page of menu items as JSON.
 // GetMenus             godoc
 //  @Summary      Get all menus
-//  @Description  Responds with the list of all menus as JSON.
+//  @Description  Responds with a page of menus, filtered by category and name.
 //  @Tags         menus
 //  @Produce      json
-//  @Success      200  {array}  models.Menu
+//  @Param        limit   query  int     false  "page size, 20 by default, at most 100"
+//  @Param        offset  query  int     false  "number of items to skip"
+//  @Param        page    query  int     false  "1-based page, used when offset is not given"
+//  @Param        category  query  string  false  "only menus of the category"
+//  @Param        name      query  string  false  "only menus with this name"
+//  @Param        sort      query  string  false  "name, category, start_date, end_date or created_at, prefixed with - for descending"
+//  @Param        include_deleted  query  bool  false  "also list deleted records, admins only"
+//  @Success      200  {object}  controllers.ListResponse
 //  @Router       /menus [get]
-func GetMenus() gin.HandlerFunc {
+func (ctrl *Controller) GetMenus() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
-		result, err := menuCollection.Find(ctx, bson.M{})
+
+		query, queryErr := menuListParams.parse(c)
+		if queryErr != nil {
+			c.Error(queryErr)
+			return
+		}
+		menus, total, err := ctrl.repos.Menus.List(ctx, query)
 		if err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "error occurred while listing menu items"},
-			)
+			c.Error(apperrors.Internal("error occurred while listing menu items", err))
 			return
 		}
-		var allMenus []bson.M
-		if err = result.All(ctx, &allMenus); err != nil {
-			log.Fatal(err)
+		c.JSON(http.StatusOK, newListResponse(menus, total, query))
+	}
+}
+
+// GetActiveMenus responds with the menus served at a time and their foods.
+// GetActiveMenus             godoc
+//  @Summary      Get the menus served now
+//  @Description  Responds with the menus that are served at the given time, within their start and end dates and one of their schedule windows in the time zone of the restaurant, each with its available foods that are not 86'd.
+//  @Tags         menus
+//  @Produce      json
+//  @Param        at  query  string  false  "RFC 3339 time, now by default"
+//  @Success      200  {object}  controllers.ActiveMenusResponse
+//  @Router       /menus/active [get]
+func (ctrl *Controller) GetActiveMenus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		at := time.Now()
+		if value := c.Query("at"); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.Error(apperrors.Invalid("at", "must be an RFC 3339 time"))
+				return
+			}
+			at = parsed
+		}
+
+		menus, _, err := ctrl.repos.Menus.List(ctx, repository.Query{
+			Sort: []repository.Sort{{Field: "name"}},
+		}.NotDeleted())
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing menu items", err))
 			return
 		}
-		c.JSON(http.StatusOK, allMenus)
+		active := []ActiveMenu{}
+		menuIds := []string{}
+		for _, menu := range menus {
+			if menu.ActiveAt(at, ctrl.location) {
+				active = append(active, ActiveMenu{Menu: menu, Foods: []models.Food{}})
+				menuIds = append(menuIds, menu.Menu_id)
+			}
+		}
+
+		foods, _, err := ctrl.repos.Foods.List(ctx, repository.Query{
+			Sort: []repository.Sort{{Field: "name"}},
+		}.Where("menu_id", repository.OpIn, menuIds).NotDeleted())
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing food items", err))
+			return
+		}
+		for _, food := range foods {
+			if (food.Available != nil && !*food.Available) || food.Out_of_stock {
+				continue
+			}
+			for i := range active {
+				if active[i].Menu_id == *food.Menu_id {
+					active[i].Foods = append(active[i].Foods, food)
+				}
+			}
+		}
+		c.JSON(http.StatusOK, ActiveMenusResponse{At: at, Menus: active})
 	}
 }
 
@@ -55,24 +146,22 @@ func GetMenus() gin.HandlerFunc {
 //  @Tags         menus
 //  @Produce      json
 //  @Success      200  {object}  models.Menu
+//  @Header       200  {string}  ETag  "version of the menu"
 //  @Router       /menus/{menu_id} [get]
-func GetMenu() gin.HandlerFunc {
+func (ctrl *Controller) GetMenu() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 		menuId := c.Param("menu_id")
-		var menu models.Menu
-		err := foodCollection.FindOne(ctx, bson.M{"menu_id": menuId}).Decode(&menu)
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "menu was not found"})
+		menu, err := ctrl.repos.Menus.Get(ctx, menuId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("menu was not found"))
 			return
 		} else if err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "error occurred when fetching the menu"},
-			)
+			c.Error(apperrors.Internal("error occurred when fetching the menu", err))
 			return
 		}
+		setETag(c, menu.Version)
 		c.JSON(http.StatusOK, menu)
 	}
 }
@@ -85,33 +174,40 @@ func GetMenu() gin.HandlerFunc {
 //  @Produce      json
 //  @Success      200  {object}  models.Menu
 //  @Router       /menus [post]
-func CreateMenu() gin.HandlerFunc {
+func (ctrl *Controller) CreateMenu() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 		var menu models.Menu
-		if err := c.BindJSON(&menu); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&menu); err != nil {
+			c.Error(apperrors.Decoding(err))
 			return
 		}
 		validationErr := validate.Struct(menu)
 		if validationErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+		if datesErr := checkMenuDates(menu.Start_Date, menu.End_Date); datesErr != nil {
+			c.Error(datesErr)
 			return
 		}
 
 		menu.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		menu.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		menu.ID = primitive.NewObjectID()
+		menu.Deleted_at, menu.Deleted_by = nil, nil
+		menu.Version = 1
 		menu.Menu_id = menu.ID.Hex()
 
-		result, insertErr := menuCollection.InsertOne(ctx, menu)
+		insertErr := ctrl.repos.Menus.Create(ctx, menu)
 		if insertErr != nil {
 			msg := fmt.Sprintf("Failed to created menu item")
-			c.JSON(http.StatusInternalServerError, msg)
+			c.Error(apperrors.Internal(msg, insertErr))
 			return
 		}
-		c.JSON(http.StatusOK, result)
+		ctrl.audit(c, models.AuditCreate, "menu", menu.Menu_id, nil, menu)
+		c.JSON(http.StatusOK, menu)
 	}
 }
 
@@ -121,60 +217,143 @@ func CreateMenu() gin.HandlerFunc {
 //  @Description  Takes a menu JSON and update menu stored in DB. Return saved JSON.
 //  @Tags         menus
 //  @Produce      json
+//  @Param        If-Match  header  string  false  "ETag of the menu as read, the update is refused with 409 when it changed since"
 //  @Success      200  {object}  models.Menu
+//  @Header       200  {string}  ETag  "version of the menu"
+//  @Failure      404  {object}  map[string]interface{}
+//  @Failure      409  {object}  map[string]interface{}
 //  @Router       /menus/{menu_id} [patch]
-func UpdateMenu() gin.HandlerFunc {
+func (ctrl *Controller) UpdateMenu() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 		var menu models.Menu
-		if err := c.BindJSON(&menu); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&menu); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+
+		version, matchErr := ifMatch(c)
+		if matchErr != nil {
+			c.Error(matchErr)
 			return
 		}
 
 		menuId := c.Param("menu_id")
-		filter := bson.M{"menu_id": menuId}
 
-		var updateObj primitive.D
+		validationErr := validate.Struct(menuSchedule{menu.Schedule})
+		if validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
 
-		if menu.Start_Date != nil && menu.End_Date != nil {
-			if !inTimeSpan(*menu.Start_Date, *menu.End_Date, time.Now()) {
-				msg := "invalid time"
-				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
-				return
-			}
-			updateObj = append(updateObj, bson.E{Key: "start_date", Value: menu.Start_Date})
-			updateObj = append(updateObj, bson.E{Key: "end_date", Value: menu.End_Date})
+		before, err := ctrl.repos.Menus.Get(ctx, menuId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("menu was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the menu", err))
+			return
+		}
 
-			if menu.Name != "" {
-				updateObj = append(updateObj, bson.E{Key: "name", Value: menu.Name})
-			}
-			if menu.Category != "" {
-				updateObj = append(updateObj, bson.E{Key: "category", Value: menu.Category})
-			}
+		// the dates left out of the request keep their stored values
+		start, end := before.Start_Date, before.End_Date
+		if menu.Start_Date != nil {
+			start = menu.Start_Date
+		}
+		if menu.End_Date != nil {
+			end = menu.End_Date
+		}
+		if datesErr := checkMenuDates(start, end); datesErr != nil {
+			c.Error(datesErr)
+			return
+		}
 
-			menu.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-			updateObj = append(updateObj, bson.E{Key: "updated_at", Value: menu.Updated_at})
+		menu.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
-			upsert := true
-			opt := options.UpdateOptions{
-				Upsert: &upsert,
-			}
+		result, err := ctrl.repos.Menus.Update(ctx, menuId, version, menu)
+		if err != nil {
+			c.Error(updateError("menu", err))
+			return
+		}
+		ctrl.audit(c, models.AuditUpdate, "menu", menuId, before, result)
+		setETag(c, result.Version)
+		c.JSON(http.StatusOK, result)
+	}
+}
 
-			result, err := menuCollection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: updateObj}}, &opt)
+// DeleteMenu soft deletes the menu with provided ID.
+// DeleteMenu             godoc
+//  @Summary      Delete a menu
+//  @Description  Marks the menu deleted, it is hidden from the list of menus until restored. A menu that still has foods cannot be deleted.
+//  @Tags         menus
+//  @Produce      json
+//  @Success      200  {object}  models.Menu
+//  @Router       /menus/{menu_id} [delete]
+func (ctrl *Controller) DeleteMenu() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		menuId := c.Param("menu_id")
 
-			if err != nil {
-				msg := "Failed to update the menu"
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
-				return
-			}
-			c.JSON(http.StatusOK, result)
+		hasFoods, err := referenced(ctx, ctrl.repos.Foods.List, repository.Query{}.
+			Where("menu_id", repository.OpEq, menuId))
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing food items", err))
+			return
+		}
+		if hasFoods {
+			c.Error(apperrors.Conflict("menu still has food items"))
+			return
+		}
+
+		deleted_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		menu, err := ctrl.repos.Menus.Delete(ctx, menuId, c.GetString("uid"), deleted_at)
+		if err != nil {
+			c.Error(deletionError("menu", err, false))
+			return
 		}
+		before := menu
+		before.Deleted_at, before.Deleted_by = nil, nil
+		ctrl.audit(c, models.AuditDelete, "menu", menuId, before, menu)
+		c.JSON(http.StatusOK, menu)
+	}
+}
+
+// RestoreMenu clears the deletion of the menu with provided ID.
+// RestoreMenu             godoc
+//  @Summary      Restore a deleted menu
+//  @Description  Clears the deletion of the menu.
+//  @Tags         menus
+//  @Produce      json
+//  @Success      200  {object}  models.Menu
+//  @Router       /menus/{menu_id}/restore [post]
+func (ctrl *Controller) RestoreMenu() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		menuId := c.Param("menu_id")
 
+		menu, err := ctrl.repos.Menus.Get(ctx, menuId)
+		if err != nil {
+			c.Error(deletionError("menu", err, true))
+			return
+		}
+		restored, err := ctrl.repos.Menus.Restore(ctx, menuId)
+		if err != nil {
+			c.Error(deletionError("menu", err, true))
+			return
+		}
+		ctrl.audit(c, models.AuditRestore, "menu", menuId, menu, restored)
+		c.JSON(http.StatusOK, restored)
 	}
 }
 
-func inTimeSpan(start, end, check time.Time) bool {
-	return start.After(check) && end.After(start)
+// checkMenuDates requires the end date of a menu to come after its start
+// date when it has both.
+func checkMenuDates(start, end *time.Time) *apperrors.Error {
+	if start != nil && end != nil && !end.After(*start) {
+		return apperrors.Invalid("end_date", "must be after start_date")
+	}
+	return nil
 }
@@ -0,0 +1,357 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+var noteListParams = listParams{
+	filters: map[string]filterParam{
+		"parent_type": {"parent_type", repository.OpEq, paramString},
+		"parent_id":   {"parent_id", repository.OpEq, paramString},
+		"author_id":   {"author_id", repository.OpEq, paramString},
+	},
+	sorts: map[string]string{
+		"created_at": "created_at", "updated_at": "updated_at",
+	},
+	defaultSort: "created_at",
+	softDeleted: true,
+}
+
+// noteParents names the records notes are attached to in error messages.
+var noteParents = map[string]string{
+	models.NoteOnOrder:       "order",
+	models.NoteOnOrderItem:   "ordered item",
+	models.NoteOnTable:       "table",
+	models.NoteOnReservation: "reservation",
+}
+
+// GetNotes responds with a page of notes as JSON.
+// GetNotes             godoc
+//  @Summary      Get all notes
+//  @Description  Responds with a page of notes, oldest first, filtered by the record they are attached to and by author.
+//  @Tags         notes
+//  @Produce      json
+//  @Param        limit        query  int     false  "page size, 20 by default, at most 100"
+//  @Param        offset       query  int     false  "number of items to skip"
+//  @Param        page         query  int     false  "1-based page, used when offset is not given"
+//  @Param        parent_type  query  string  false  "ORDER, ORDER_ITEM, TABLE or RESERVATION"
+//  @Param        parent_id    query  string  false  "only notes on the record with this ID"
+//  @Param        author_id    query  string  false  "only notes written by the user"
+//  @Param        sort         query  string  false  "created_at or updated_at, prefixed with - for descending"
+//  @Param        include_deleted  query  bool  false  "also list deleted records, admins only"
+//  @Success      200  {object}  controllers.ListResponse
+//  @Router       /notes [get]
+func (ctrl *Controller) GetNotes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		query, queryErr := noteListParams.parse(c)
+		if queryErr != nil {
+			c.Error(queryErr)
+			return
+		}
+		notes, total, err := ctrl.repos.Notes.List(ctx, query)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing notes", err))
+			return
+		}
+		c.JSON(http.StatusOK, newListResponse(notes, total, query))
+	}
+}
+
+// GetNote responds with the note with provided ID as JSON.
+// GetNote             godoc
+//  @Summary      Get single note by ID
+//  @Description  Responds with the note with provided ID as JSON.
+//  @Tags         notes
+//  @Produce      json
+//  @Success      200  {object}  models.Note
+//  @Header       200  {string}  ETag  "version of the note"
+//  @Router       /notes/{note_id} [get]
+func (ctrl *Controller) GetNote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		note, err := ctrl.repos.Notes.Get(ctx, c.Param("note_id"))
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("note was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the note", err))
+			return
+		}
+		setETag(c, note.Version)
+		c.JSON(http.StatusOK, note)
+	}
+}
+
+// CreateNote takes a note JSON and store in DB.
+// CreateNote             godoc
+//  @Summary      Store a new note
+//  @Description  Takes a note JSON attached to an order, an ordered item, a table or a reservation and store in DB. The author is the user making the request. Return saved JSON.
+//  @Tags         notes
+//  @Produce      json
+//  @Success      200  {object}  models.Note
+//  @Failure      404  {object}  map[string]interface{}
+//  @Router       /notes [post]
+func (ctrl *Controller) CreateNote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var note models.Note
+
+		if err := c.ShouldBindJSON(&note); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+
+		validationErr := validate.Struct(note)
+		if validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+
+		deleted, err := ctrl.noteParentDeleted(ctx, note.Parent_type, note.Parent_id)
+		if err == repository.ErrNotFound || deleted {
+			c.Error(apperrors.NotFound(noteParents[note.Parent_type] + " was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the "+noteParents[note.Parent_type], err))
+			return
+		}
+
+		note.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		note.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		note.ID = primitive.NewObjectID()
+		note.Deleted_at, note.Deleted_by = nil, nil
+		note.Version = 1
+		note.Note_id = note.ID.Hex()
+		note.Author_id = c.GetString("uid")
+
+		if err := ctrl.repos.Notes.Create(ctx, note); err != nil {
+			c.Error(apperrors.Internal("Failed to create the note", err))
+			return
+		}
+		ctrl.audit(c, models.AuditCreate, "note", note.Note_id, nil, note)
+		c.JSON(http.StatusOK, note)
+	}
+}
+
+// UpdateNote takes a note JSON and update note stored in DB.
+// UpdateNote             godoc
+//  @Summary      Update a note
+//  @Description  Takes a note JSON and changes its title and text. Only the author, managers and admins may change a note. Return saved JSON.
+//  @Tags         notes
+//  @Produce      json
+//  @Param        If-Match  header  string  false  "ETag of the note as read, the update is refused with 409 when it changed since"
+//  @Success      200  {object}  models.Note
+//  @Header       200  {string}  ETag  "version of the note"
+//  @Failure      403  {object}  map[string]interface{}
+//  @Failure      404  {object}  map[string]interface{}
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /notes/{note_id} [patch]
+func (ctrl *Controller) UpdateNote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var note models.Note
+		noteId := c.Param("note_id")
+
+		if err := c.ShouldBindJSON(&note); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+
+		version, matchErr := ifMatch(c)
+		if matchErr != nil {
+			c.Error(matchErr)
+			return
+		}
+
+		validationErr := validate.StructPartial(note, "Title")
+		if validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+		if utf8.RuneCountInString(note.Text) > 1000 {
+			c.Error(apperrors.Invalid("text", "must be at most 1000 characters"))
+			return
+		}
+
+		before, err := ctrl.repos.Notes.Get(ctx, noteId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("note was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the note", err))
+			return
+		}
+		if !canEditNote(c, before) {
+			c.Error(apperrors.Forbidden("only the author or a manager can change the note"))
+			return
+		}
+
+		note.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		result, err := ctrl.repos.Notes.Update(ctx, noteId, version, note)
+		if err != nil {
+			c.Error(updateError("note", err))
+			return
+		}
+		ctrl.audit(c, models.AuditUpdate, "note", noteId, before, result)
+		setETag(c, result.Version)
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// DeleteNote soft deletes the note with provided ID.
+// DeleteNote             godoc
+//  @Summary      Delete a note
+//  @Description  Marks the note deleted, it is hidden from the list of notes, the bills and the kitchen until restored. Only the author, managers and admins may delete a note.
+//  @Tags         notes
+//  @Produce      json
+//  @Success      200  {object}  models.Note
+//  @Failure      403  {object}  map[string]interface{}
+//  @Router       /notes/{note_id} [delete]
+func (ctrl *Controller) DeleteNote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		noteId := c.Param("note_id")
+
+		note, err := ctrl.repos.Notes.Get(ctx, noteId)
+		if err != nil {
+			c.Error(deletionError("note", err, false))
+			return
+		}
+		if !canEditNote(c, note) {
+			c.Error(apperrors.Forbidden("only the author or a manager can delete the note"))
+			return
+		}
+
+		deleted_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		deleted, err := ctrl.repos.Notes.Delete(ctx, noteId, c.GetString("uid"), deleted_at)
+		if err != nil {
+			c.Error(deletionError("note", err, false))
+			return
+		}
+		ctrl.audit(c, models.AuditDelete, "note", noteId, note, deleted)
+		c.JSON(http.StatusOK, deleted)
+	}
+}
+
+// RestoreNote clears the deletion of the note with provided ID.
+// RestoreNote             godoc
+//  @Summary      Restore a deleted note
+//  @Description  Clears the deletion of the note. The record it is attached to must not be deleted. Only the author, managers and admins may restore a note.
+//  @Tags         notes
+//  @Produce      json
+//  @Success      200  {object}  models.Note
+//  @Failure      403  {object}  map[string]interface{}
+//  @Router       /notes/{note_id}/restore [post]
+func (ctrl *Controller) RestoreNote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		noteId := c.Param("note_id")
+
+		note, err := ctrl.repos.Notes.Get(ctx, noteId)
+		if err != nil {
+			c.Error(deletionError("note", err, true))
+			return
+		}
+		if !canEditNote(c, note) {
+			c.Error(apperrors.Forbidden("only the author or a manager can restore the note"))
+			return
+		}
+		parentDeleted, err := ctrl.noteParentDeleted(ctx, note.Parent_type, note.Parent_id)
+		if err != nil && err != repository.ErrNotFound {
+			c.Error(apperrors.Internal("error occurred when fetching the "+noteParents[note.Parent_type], err))
+			return
+		}
+		if parentDeleted {
+			c.Error(apperrors.Conflict(noteParents[note.Parent_type] + " of the note is deleted, restore it first"))
+			return
+		}
+
+		restored, err := ctrl.repos.Notes.Restore(ctx, noteId)
+		if err != nil {
+			c.Error(deletionError("note", err, true))
+			return
+		}
+		ctrl.audit(c, models.AuditRestore, "note", noteId, note, restored)
+		c.JSON(http.StatusOK, restored)
+	}
+}
+
+// canEditNote tells whether the user of the request may change the note,
+// which its author, managers and admins may.
+func canEditNote(c *gin.Context, note models.Note) bool {
+	role := c.GetString("role")
+	return note.Author_id == c.GetString("uid") || role == models.RoleManager || role == models.RoleAdmin
+}
+
+// noteParentDeleted tells whether the record of the given type and ID a
+// note is attached to is deleted. It returns repository.ErrNotFound when
+// there is no such record.
+func (ctrl *Controller) noteParentDeleted(ctx context.Context, parentType, parentId string) (bool, error) {
+	var deleted_at *time.Time
+	switch parentType {
+	case models.NoteOnOrder:
+		order, err := ctrl.repos.Orders.Get(ctx, parentId)
+		if err != nil {
+			return false, err
+		}
+		deleted_at = order.Deleted_at
+	case models.NoteOnOrderItem:
+		orderItem, err := ctrl.repos.OrderItems.Get(ctx, parentId)
+		if err != nil {
+			return false, err
+		}
+		deleted_at = orderItem.Deleted_at
+	case models.NoteOnTable:
+		table, err := ctrl.repos.Tables.Get(ctx, parentId)
+		if err != nil {
+			return false, err
+		}
+		deleted_at = table.Deleted_at
+	case models.NoteOnReservation:
+		_, err := ctrl.repos.Reservations.Get(ctx, parentId)
+		return false, err
+	default:
+		return false, repository.ErrNotFound
+	}
+	return deleted_at != nil, nil
+}
+
+// itemNotes returns the text of the notes on the ordered items, oldest
+// first, keyed by the ID of the item.
+func (ctrl *Controller) itemNotes(ctx context.Context, orderItemIds []string) (map[string][]string, error) {
+	found := map[string][]string{}
+	if len(orderItemIds) == 0 {
+		return found, nil
+	}
+	query := repository.Query{Sort: []repository.Sort{{Field: "created_at"}}}.
+		Where("parent_type", repository.OpEq, models.NoteOnOrderItem).
+		Where("parent_id", repository.OpIn, orderItemIds).
+		NotDeleted()
+	notes, _, err := ctrl.repos.Notes.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	for _, note := range notes {
+		found[note.Parent_id] = append(found[note.Parent_id], note.Text)
+	}
+	return found, nil
+}
@@ -8,43 +8,61 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 
-	"github.com/minhtran241/restaurant-management/database"
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/events"
 	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
 )
 
-var orderCollection *mongo.Collection = database.OpenCollection(database.Client, "order")
+var orderListParams = listParams{
+	filters: map[string]filterParam{
+		"table_id": {"table_id", repository.OpEq, paramString},
+		"status":   {"status", repository.OpIn, paramList},
+		"from":     {"order_date", repository.OpGte, paramTime},
+		"to":       {"order_date", repository.OpLt, paramTime},
+	},
+	sorts: map[string]string{
+		"order_date": "order_date", "created_at": "created_at", "updated_at": "updated_at",
+	},
+	defaultSort: "-order_date",
+	softDeleted: true,
+}
 
-// GetOrders responds with the list of all orders as JSON.
+// GetOrders responds with a page of orders as JSON.
 // GetOrders             godoc
 //  @Summary      Get all orders
-//  @Description  Responds with the list of all orders as JSON.
+//  @Description  Responds with a page of orders, newest first, filtered by table, status and date range.
 //  @Tags         orders
 //  @Produce      json
-//  @Success      200  {array}  models.Order
+//  @Param        limit   query  int     false  "page size, 20 by default, at most 100"
+//  @Param        offset  query  int     false  "number of items to skip"
+//  @Param        page    query  int     false  "1-based page, used when offset is not given"
+//  @Param        table_id  query  string  false  "only orders of the table"
+//  @Param        status    query  string  false  "comma separated order statuses"
+//  @Param        from      query  string  false  "orders dated at or after, RFC 3339"
+//  @Param        to        query  string  false  "orders dated before, RFC 3339"
+//  @Param        sort      query  string  false  "order_date, created_at or updated_at, prefixed with - for descending"
+//  @Param        include_deleted  query  bool  false  "also list deleted records, admins only"
+//  @Success      200  {object}  controllers.ListResponse
 //  @Router       /orders [get]
-func GetOrders() gin.HandlerFunc {
+func (ctrl *Controller) GetOrders() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
-		result, err := orderCollection.Find(ctx, bson.M{})
-		if err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "error occurred while listing orders"},
-			)
+
+		query, queryErr := orderListParams.parse(c)
+		if queryErr != nil {
+			c.Error(queryErr)
 			return
 		}
-		var allOrders []bson.M
-
-		if err = result.All(ctx, &allOrders); err != nil {
-			log.Fatal(err)
+		orders, total, err := ctrl.repos.Orders.List(ctx, query)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing orders", err))
+			return
 		}
-		c.JSON(http.StatusOK, allOrders)
+		c.JSON(http.StatusOK, newListResponse(orders, total, query))
 	}
 }
 
@@ -55,23 +73,22 @@ func GetOrders() gin.HandlerFunc {
 //  @Tags         orders
 //  @Produce      json
 //  @Success      200  {object}  models.Order
+//  @Header       200  {string}  ETag  "version of the order"
 //  @Router       /orders/{order_id} [get]
-func GetOrder() gin.HandlerFunc {
+func (ctrl *Controller) GetOrder() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 		orderId := c.Param("order_id")
-		var order models.Order
-		err := orderCollection.FindOne(ctx, bson.M{"_id": orderId}).Decode(&order)
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "order was not found"})
+		order, err := ctrl.repos.Orders.Get(ctx, orderId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("order was not found"))
+			return
 		} else if err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "error occurred when fetching the order"},
-			)
+			c.Error(apperrors.Internal("error occurred when fetching the order", err))
 			return
 		}
+		setETag(c, order.Version)
 		c.JSON(http.StatusOK, order)
 	}
 }
@@ -84,31 +101,33 @@ func GetOrder() gin.HandlerFunc {
 //  @Produce      json
 //  @Success      200  {object}  models.Order
 //  @Router       /orders [post]
-func CreateOrder() gin.HandlerFunc {
+func (ctrl *Controller) CreateOrder() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
-		var table models.Table
 		var order models.Order
 
-		if err := c.BindJSON(&order); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&order); err != nil {
+			c.Error(apperrors.Decoding(err))
 			return
 		}
 
 		validationErr := validate.Struct(order)
 		if validationErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			c.Error(apperrors.Validation(validationErr))
 			return
 		}
 
 		if order.Table_id != nil {
-			err := tableCollection.FindOne(ctx, bson.M{"table_id": order.Table_id}).Decode(&table)
-			if err == mongo.ErrNoDocuments {
-				c.JSON(http.StatusNotFound, gin.H{"error": "table was not found"})
+			table, err := ctrl.repos.Tables.Get(ctx, *order.Table_id)
+			if err == nil && table.Deleted_at != nil {
+				err = repository.ErrNotFound
+			}
+			if err == repository.ErrNotFound {
+				c.Error(apperrors.NotFound("table was not found"))
 				return
 			} else if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				c.Error(apperrors.Internal("error occurred when fetching the table", err))
 				return
 			}
 		}
@@ -116,87 +135,414 @@ func CreateOrder() gin.HandlerFunc {
 		order.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		order.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		order.ID = primitive.NewObjectID()
+		order.Deleted_at, order.Deleted_by = nil, nil
+		order.Version = 1
 		order.Order_id = order.ID.Hex()
+		openOrder(&order, c.GetString("uid"))
 
-		result, insertErr := orderCollection.InsertOne(ctx, order)
+		insertErr := ctrl.repos.Orders.Create(ctx, order)
 		if insertErr != nil {
 			msg := fmt.Sprintf("Failed to created order")
-			c.JSON(http.StatusInternalServerError, msg)
+			c.Error(apperrors.Internal(msg, insertErr))
 			return
 		}
-		c.JSON(http.StatusOK, result)
+		ctrl.audit(c, models.AuditCreate, "order", order.Order_id, nil, order)
+		ctrl.events.Publish(events.OrderCreated, *order.Table_id, order.Order_id, order)
+		ctrl.setTableStatus(ctx, *order.Table_id, models.TableOrdered)
+		c.JSON(http.StatusOK, order)
 	}
 }
 
 // UpdateOrder takes a order JSON and update order stored in DB.
 // UpdateOrder             godoc
 //  @Summary      Update a order
-//  @Description  Takes a order JSON and update order stored in DB. Return saved JSON.
+//  @Description  Takes a order JSON and update order stored in DB. Return saved JSON. Moving an open order to another table marks the new table ORDERED and makes the old one AVAILABLE once it has no open order left.
 //  @Tags         orders
 //  @Produce      json
+//  @Param        If-Match  header  string  false  "ETag of the order as read, the update is refused with 409 when it changed since"
 //  @Success      200  {object}  models.Order
+//  @Header       200  {string}  ETag  "version of the order"
+//  @Failure      404  {object}  map[string]interface{}
+//  @Failure      409  {object}  map[string]interface{}
 //  @Router       /orders/{order_id} [patch]
-func UpdateOrder() gin.HandlerFunc {
+func (ctrl *Controller) UpdateOrder() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
-		var table models.Table
 		var order models.Order
 
 		orderId := c.Param("order_id")
 
-		if err := c.BindJSON(&order); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&order); err != nil {
+			c.Error(apperrors.Decoding(err))
 			return
 		}
 
-		var updateObj primitive.D
+		version, matchErr := ifMatch(c)
+		if matchErr != nil {
+			c.Error(matchErr)
+			return
+		}
 
 		if order.Table_id != nil {
-			err := tableCollection.FindOne(ctx, bson.M{"table_id": order.Table_id}).Decode(&table)
-			if err != nil {
-				msg := fmt.Sprintf("Table was not found")
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			table, err := ctrl.repos.Tables.Get(ctx, *order.Table_id)
+			if err == nil && table.Deleted_at != nil {
+				err = repository.ErrNotFound
+			}
+			if err == repository.ErrNotFound {
+				c.Error(apperrors.NotFound("table was not found"))
+				return
+			} else if err != nil {
+				c.Error(apperrors.Internal("error occurred when fetching the table", err))
 				return
 			}
-			updateObj = append(updateObj, bson.E{Key: "table_id", Value: order.Table_id})
+		}
+
+		before, err := ctrl.repos.Orders.Get(ctx, orderId)
+		if err == nil && before.Deleted_at != nil {
+			err = repository.ErrNotFound
+		}
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("order was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the order", err))
+			return
 		}
 
 		order.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: order.Updated_at})
 
-		upsert := true
-		filter := bson.M{"order_id": orderId}
+		result, err := ctrl.repos.Orders.Update(ctx, orderId, version, order)
+		if err != nil {
+			c.Error(updateError("order", err))
+			return
+		}
+		ctrl.audit(c, models.AuditUpdate, "order", orderId, before, result)
 
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		// an open order moving to another table takes the party with it
+		moved := before.Table_id != nil && result.Table_id != nil && *before.Table_id != *result.Table_id
+		if moved && isOpenOrderStatus(orderStatus(result)) {
+			ctrl.setTableStatus(ctx, *result.Table_id, models.TableOrdered)
+			ctrl.releaseTable(ctx, *before.Table_id, models.TableAvailable)
 		}
+		setETag(c, result.Version)
+		c.JSON(http.StatusOK, result)
+	}
+}
 
-		result, err := orderCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{{Key: "$set", Value: updateObj}},
-			&opt,
-		)
+// orderTransitions lists, for every order status, the statuses it may move
+// to. SERVED may go back to SENT_TO_KITCHEN when another round is ordered.
+var orderTransitions = map[string][]string{
+	models.OrderOpen:          {models.OrderSentToKitchen, models.OrderCancelled},
+	models.OrderSentToKitchen: {models.OrderServed, models.OrderCancelled},
+	models.OrderServed:        {models.OrderSentToKitchen, models.OrderBilled},
+	models.OrderBilled:        {models.OrderClosed},
+	models.OrderClosed:        {},
+	models.OrderCancelled:     {},
+}
 
-		if err != nil {
-			msg := "Failed to update the order"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+func canTransitionOrder(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// orderStatus returns the status of the order, orders stored before the
+// lifecycle existed are treated as OPEN.
+func orderStatus(order models.Order) string {
+	if order.Status == nil || *order.Status == "" {
+		return models.OrderOpen
+	}
+	return *order.Status
+}
+
+// openOrder puts a new order in the OPEN status and records who opened it.
+func openOrder(order *models.Order, userId string) {
+	status := models.OrderOpen
+	order.Status = &status
+	order.Status_history = []models.OrderTransition{{
+		To:         models.OrderOpen,
+		User_id:    userId,
+		Changed_at: order.Created_at,
+	}}
+}
+
+type orderTransitionRequest struct {
+	Status *string `json:"status" validate:"required,eq=OPEN|eq=SENT_TO_KITCHEN|eq=SERVED|eq=BILLED|eq=CLOSED|eq=CANCELLED"`
+	Reason string  `json:"reason"`
+}
+
+// TransitionOrder moves an order to another status of its lifecycle.
+// TransitionOrder             godoc
+//  @Summary      Change the status of an order
+//  @Description  Takes a status JSON and moves the order to it if the lifecycle allows it (OPEN -> SENT_TO_KITCHEN -> SERVED -> BILLED -> CLOSED, or CANCELLED). The change is appended to the status history of the order. Cancelling voids the items the kitchen has not served yet and is refused for an invoiced order; the table goes back to SEATED once it has no open order left.
+//  @Tags         orders
+//  @Produce      json
+//  @Success      200  {object}  models.Order
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /orders/{order_id}/transitions [post]
+func (ctrl *Controller) TransitionOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var req orderTransitionRequest
+
+		orderId := c.Param("order_id")
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apperrors.Decoding(err))
 			return
 		}
-		c.JSON(http.StatusOK, result)
+		if validationErr := validate.Struct(req); validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+
+		order, err := ctrl.repos.Orders.Get(ctx, orderId)
+		if err == nil && order.Deleted_at != nil {
+			err = repository.ErrNotFound
+		}
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("order was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the order", err))
+			return
+		}
+
+		from := orderStatus(order)
+		if !canTransitionOrder(from, *req.Status) {
+			msg := fmt.Sprintf("order cannot move from %s to %s", from, *req.Status)
+			c.Error(apperrors.Conflict(msg))
+			return
+		}
+		if *req.Status == models.OrderCancelled {
+			// cancelling voids the items, which would change an issued bill
+			invoiced, err := ctrl.orderInvoiced(ctx, order.Order_id)
+			if err != nil {
+				c.Error(apperrors.Internal("error occurred while listing invoices", err))
+				return
+			}
+			if invoiced {
+				c.Error(apperrors.Conflict("order has an invoice"))
+				return
+			}
+		}
+
+		transition, err := ctrl.transitionOrder(ctx, order, *req.Status, c.GetString("uid"), req.Reason)
+		if err == repository.ErrConflict {
+			c.Error(apperrors.Conflict("order status was changed by someone else, please retry"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("Failed to update the order status", err))
+			return
+		}
+
+		before := order
+		order.Status = &transition.To
+		order.Updated_at = transition.Changed_at
+		order.Status_history = append(order.Status_history, *transition)
+		ctrl.audit(c, models.AuditUpdate, "order", order.Order_id, before, order)
+
+		tableId := ""
+		if order.Table_id != nil {
+			tableId = *order.Table_id
+		}
+		if transition.To == models.OrderCancelled {
+			ctrl.voidOpenItems(ctx, c, order.Order_id, tableId)
+			// the party is still seated, with nothing ordered
+			if tableId != "" {
+				ctrl.releaseTable(ctx, tableId, models.TableSeated)
+			}
+		}
+		ctrl.events.Publish(events.OrderStatusChanged, tableId, order.Order_id, order)
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// voidOpenItems voids the items of a cancelled order the kitchen has not
+// served yet, taking them off the kitchen display. Failures are only
+// logged, the order is cancelled already.
+func (ctrl *Controller) voidOpenItems(ctx context.Context, c *gin.Context, orderId, tableId string) {
+	orderItems, err := ctrl.repos.OrderItems.ListByOrder(ctx, orderId)
+	if err != nil {
+		log.Printf("failed to list the items of cancelled order %s: %v", orderId, err)
+		return
+	}
+	updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	for _, orderItem := range orderItems {
+		if orderItem.Status == nil || !isActiveItemStatus(*orderItem.Status) {
+			continue
+		}
+		err := ctrl.repos.OrderItems.SetStatus(ctx, orderItem.Order_item_id, orderItem.Status, models.ItemVoided, updated_at)
+		if err != nil {
+			log.Printf("failed to void item %s of cancelled order %s: %v", orderItem.Order_item_id, orderId, err)
+			continue
+		}
+		before := orderItem
+		status := models.ItemVoided
+		orderItem.Status = &status
+		orderItem.Status_updated_at = updated_at
+		orderItem.Updated_at = updated_at
+		ctrl.audit(c, models.AuditUpdate, "order_item", orderItem.Order_item_id, before, orderItem)
+		ctrl.events.Publish(events.OrderItemStatusChanged, tableId, orderId, orderItem)
+	}
+}
+
+func isOpenOrderStatus(status string) bool {
+	for _, open := range openOrderStatuses {
+		if status == open {
+			return true
+		}
+	}
+	return false
+}
+
+func isActiveItemStatus(status string) bool {
+	for _, active := range activeItemStatuses {
+		if status == active {
+			return true
+		}
 	}
+	return false
 }
 
-func OrderItemOrderCreator(order models.Order) string {
+// transitionOrder moves the order to status and appends the transition to
+// its history. The update only applies if the order is still in the status
+// it was read with; repository.ErrConflict means somebody else moved it
+// first.
+func (ctrl *Controller) transitionOrder(ctx context.Context, order models.Order, status, userId, reason string) (*models.OrderTransition, error) {
+	transition := models.OrderTransition{
+		From:    orderStatus(order),
+		To:      status,
+		Reason:  reason,
+		User_id: userId,
+	}
+	transition.Changed_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	if err := ctrl.repos.Orders.Transition(ctx, order.Order_id, order.Status, transition); err != nil {
+		return nil, err
+	}
+	return &transition, nil
+}
+
+// OrderItemOrderCreator opens a new order for ordered items and returns its
+// ID, or the error of storing it.
+func (ctrl *Controller) OrderItemOrderCreator(c *gin.Context, order models.Order) (string, error) {
 	order.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	order.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	order.ID = primitive.NewObjectID()
 	order.Order_id = order.ID.Hex()
+	order.Version = 1
+	openOrder(&order, c.GetString("uid"))
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 	defer cancel()
 
-	orderCollection.InsertOne(ctx, order)
-	return order.Order_id
+	if err := ctrl.repos.Orders.Create(ctx, order); err != nil {
+		return "", err
+	}
+	ctrl.audit(c, models.AuditCreate, "order", order.Order_id, nil, order)
+	tableId := ""
+	if order.Table_id != nil {
+		tableId = *order.Table_id
+	}
+	ctrl.events.Publish(events.OrderCreated, tableId, order.Order_id, order)
+	if tableId != "" {
+		ctrl.setTableStatus(ctx, tableId, models.TableOrdered)
+	}
+	return order.Order_id, nil
+}
+
+// DeleteOrder soft deletes the order with provided ID.
+// DeleteOrder             godoc
+//  @Summary      Delete an order
+//  @Description  Marks the order deleted, it is hidden from the list of orders until restored. An order with an invoice or with items the kitchen is still preparing cannot be deleted.
+//  @Tags         orders
+//  @Produce      json
+//  @Success      200  {object}  models.Order
+//  @Router       /orders/{order_id} [delete]
+func (ctrl *Controller) DeleteOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		orderId := c.Param("order_id")
+
+		invoiced, err := referenced(ctx, ctrl.repos.Invoices.List, repository.Query{}.
+			Where("order_id", repository.OpEq, orderId))
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing invoices", err))
+			return
+		}
+		if invoiced {
+			c.Error(apperrors.Conflict("order has an invoice"))
+			return
+		}
+		preparing, err := referenced(ctx, ctrl.repos.OrderItems.List, repository.Query{}.
+			Where("order_id", repository.OpEq, orderId).
+			Where("status", repository.OpIn, activeItemStatuses))
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing ordered items", err))
+			return
+		}
+		if preparing {
+			c.Error(apperrors.Conflict("order has items the kitchen is still preparing"))
+			return
+		}
+
+		deleted_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		order, err := ctrl.repos.Orders.Delete(ctx, orderId, c.GetString("uid"), deleted_at)
+		if err != nil {
+			c.Error(deletionError("order", err, false))
+			return
+		}
+		before := order
+		before.Deleted_at, before.Deleted_by = nil, nil
+		ctrl.audit(c, models.AuditDelete, "order", orderId, before, order)
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// RestoreOrder clears the deletion of the order with provided ID.
+// RestoreOrder             godoc
+//  @Summary      Restore a deleted order
+//  @Description  Clears the deletion of the order. The table of the order must not be deleted.
+//  @Tags         orders
+//  @Produce      json
+//  @Success      200  {object}  models.Order
+//  @Router       /orders/{order_id}/restore [post]
+func (ctrl *Controller) RestoreOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		orderId := c.Param("order_id")
+
+		order, err := ctrl.repos.Orders.Get(ctx, orderId)
+		if err != nil {
+			c.Error(deletionError("order", err, true))
+			return
+		}
+		if order.Table_id != nil {
+			table, err := ctrl.repos.Tables.Get(ctx, *order.Table_id)
+			if err != nil && err != repository.ErrNotFound {
+				c.Error(apperrors.Internal("error occurred when fetching the table", err))
+				return
+			}
+			if err == nil && table.Deleted_at != nil {
+				c.Error(apperrors.Conflict("table of the order is deleted, restore it first"))
+				return
+			}
+		}
+
+		restored, err := ctrl.repos.Orders.Restore(ctx, orderId)
+		if err != nil {
+			c.Error(deletionError("order", err, true))
+			return
+		}
+		ctrl.audit(c, models.AuditRestore, "order", orderId, order, restored)
+		c.JSON(http.StatusOK, restored)
+	}
 }
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+func TestCanTransitionOrder(t *testing.T) {
+	statuses := []string{
+		models.OrderOpen, models.OrderSentToKitchen, models.OrderServed,
+		models.OrderBilled, models.OrderClosed, models.OrderCancelled,
+	}
+	allowed := map[[2]string]bool{
+		{models.OrderOpen, models.OrderSentToKitchen}:      true,
+		{models.OrderOpen, models.OrderCancelled}:          true,
+		{models.OrderSentToKitchen, models.OrderServed}:    true,
+		{models.OrderSentToKitchen, models.OrderCancelled}: true,
+		{models.OrderServed, models.OrderSentToKitchen}:    true,
+		{models.OrderServed, models.OrderBilled}:           true,
+		{models.OrderBilled, models.OrderClosed}:           true,
+	}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			if got, want := canTransitionOrder(from, to), allowed[[2]string{from, to}]; got != want {
+				t.Errorf("canTransitionOrder(%s, %s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+	if canTransitionOrder("UNKNOWN", models.OrderOpen) || canTransitionOrder(models.OrderOpen, "UNKNOWN") {
+		t.Error("unknown statuses are allowed to transition")
+	}
+}
+
+func TestOrderStatus(t *testing.T) {
+	empty, served := "", models.OrderServed
+	tests := []struct {
+		status *string
+		want   string
+	}{
+		{nil, models.OrderOpen},
+		{&empty, models.OrderOpen},
+		{&served, models.OrderServed},
+	}
+	for _, tt := range tests {
+		if got := orderStatus(models.Order{Status: tt.status}); got != tt.want {
+			t.Errorf("orderStatus(%v) = %s, want %s", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestOpenOrder(t *testing.T) {
+	var order models.Order
+	openOrder(&order, "user-1")
+	if order.Status == nil || *order.Status != models.OrderOpen {
+		t.Fatalf("opened order has status %v, want OPEN", order.Status)
+	}
+	if len(order.Status_history) != 1 || order.Status_history[0].To != models.OrderOpen || order.Status_history[0].User_id != "user-1" {
+		t.Errorf("opened order has history %+v, want one move to OPEN by user-1", order.Status_history)
+	}
+}
@@ -2,18 +2,18 @@ package controllers
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 
-	"github.com/minhtran241/restaurant-management/database"
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/events"
+	"github.com/minhtran241/restaurant-management/helpers"
 	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
 )
 
 type OrderItemPack struct {
@@ -21,35 +21,51 @@ type OrderItemPack struct {
 	Order_items []models.OrderItem
 }
 
-var orderItemCollection *mongo.Collection = database.OpenCollection(database.Client, "orderItem")
+var orderItemListParams = listParams{
+	filters: map[string]filterParam{
+		"order_id": {"order_id", repository.OpEq, paramString},
+		"food_id":  {"food_id", repository.OpEq, paramString},
+		"status":   {"status", repository.OpIn, paramList},
+	},
+	sorts: map[string]string{
+		"created_at": "created_at", "status_updated_at": "status_updated_at", "quantity": "quantity",
+	},
+	defaultSort: "created_at",
+	softDeleted: true,
+}
 
-// GetOrderItems responds with the list of all ordered items as JSON.
-// GetFoods             godoc
+// GetOrderItems responds with a page of ordered items as JSON.
+// GetOrderItems             godoc
 //  @Summary      Get all ordered items
-//  @Description  Responds with the list of all foods as JSON.
+//  @Description  Responds with a page of ordered items, filtered by order, food and preparation status.
 //  @Tags         orderItems
 //  @Produce      json
-//  @Success      200  {array}  models.OrderItem
+//  @Param        limit   query  int     false  "page size, 20 by default, at most 100"
+//  @Param        offset  query  int     false  "number of items to skip"
+//  @Param        page    query  int     false  "1-based page, used when offset is not given"
+//  @Param        order_id  query  string  false  "only items of the order"
+//  @Param        food_id   query  string  false  "only items of the food"
+//  @Param        status    query  string  false  "comma separated preparation statuses"
+//  @Param        sort      query  string  false  "created_at, status_updated_at or quantity, prefixed with - for descending"
+//  @Param        include_deleted  query  bool  false  "also list deleted records, admins only"
+//  @Success      200  {object}  controllers.ListResponse
 //  @Router       /orderItems [get]
-func GetOrderItems() gin.HandlerFunc {
+func (ctrl *Controller) GetOrderItems() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
-		result, err := orderItemCollection.Find(ctx, bson.M{})
-		defer cancel()
-		if err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "error occurred while listing ordered items"},
-			)
+
+		query, queryErr := orderItemListParams.parse(c)
+		if queryErr != nil {
+			c.Error(queryErr)
 			return
 		}
-		var allOrderItems []bson.M
-
-		if err = result.All(ctx, &allOrderItems); err != nil {
-			log.Fatal(err)
+		orderItems, total, err := ctrl.repos.OrderItems.List(ctx, query)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing ordered items", err))
+			return
 		}
-		c.JSON(http.StatusOK, allOrderItems)
+		c.JSON(http.StatusOK, newListResponse(orderItems, total, query))
 	}
 }
 
@@ -60,27 +76,22 @@ func GetOrderItems() gin.HandlerFunc {
 //  @Tags         orderItems
 //  @Produce      json
 //  @Success      200  {object}  models.OrderItem
+//  @Header       200  {string}  ETag  "version of the ordered item"
 //  @Router       /orderItems/{order_item_id} [get]
-func GetOrderItem() gin.HandlerFunc {
+func (ctrl *Controller) GetOrderItem() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 		orderItemId := c.Param("order_item_id")
-		var orderItem models.OrderItem
-		err := orderItemCollection.FindOne(
-			ctx,
-			bson.M{"orderItem_id": orderItemId},
-		).Decode(&orderItem)
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "ordered item was not found"})
+		orderItem, err := ctrl.repos.OrderItems.Get(ctx, orderItemId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("ordered item was not found"))
 			return
 		} else if err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "error occurred when fetching the ordered item"},
-			)
+			c.Error(apperrors.Internal("error occurred when fetching the ordered item", err))
 			return
 		}
+		setETag(c, orderItem.Version)
 		c.JSON(http.StatusOK, orderItem)
 	}
 }
@@ -88,154 +99,170 @@ func GetOrderItem() gin.HandlerFunc {
 // GetOrderItemsByOrder responds with the ordered items of an order with provided order's ID.
 // GetOrderItemsByOrder             godoc
 //  @Summary      Get ordered items order's ID
-//  @Description  Responds with the ordered items of an order with provided order's ID.
+//  @Description  Responds with the ordered items of an order with provided order's ID, priced with line totals, taxes and service charge.
 //  @Tags         orderItems
 //  @Produce      json
-//  @Success      200  {array}  models.OrderItem
+//  @Success      200  {object}  OrderBill
 //  @Router       /orderItems-order/{order_id} [get]
-func GetOrderItemsByOrder() gin.HandlerFunc {
+func (ctrl *Controller) GetOrderItemsByOrder() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
 		orderId := c.Param("order_id")
 
-		allOrderItems, err := ItemsByOrder(orderId)
-		if err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "error occurred while listing ordered items by order ID"},
-			)
+		bill, err := ctrl.BillForOrder(ctx, orderId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("order was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing ordered items by order ID", err))
 			return
 		}
-		c.JSON(http.StatusOK, allOrderItems)
+		c.JSON(http.StatusOK, bill)
 	}
 }
 
-func ItemsByOrder(id string) (OrderItems []primitive.M, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	matchStage := bson.D{{Key: "$match", Value: bson.D{{Key: "order_id", Value: id}}}}
-	lookupStage := bson.D{{Key: "$lookup", Value: bson.D{
-		{Key: "from", Value: "food"},
-		{Key: "localField", Value: "food_id"},
-		{Key: "foreignField", Value: "food_id"},
-		{Key: "as", Value: "food"},
-	}}}
-	unwindStage := bson.D{{Key: "$unwind", Value: bson.D{
-		{Key: "path", Value: "$food"},
-		{Key: "preserveNullAndEmptyArrays", Value: true},
-	}}}
-
-	lookupOrderStage := bson.D{{Key: "$lookup", Value: bson.D{
-		{Key: "from", Value: "order"},
-		{Key: "localField", Value: "order_id"},
-		{Key: "foreignField", Value: "order_id"},
-		{Key: "as", Value: "order"},
-	}}}
-	unwindOrderStage := bson.D{{Key: "$unwind", Value: bson.D{
-		{Key: "path", Value: "$order"},
-		{Key: "preserveNullAndEmptyArrays", Value: true},
-	}}}
-
-	lookupTableStage := bson.D{{Key: "$lookup", Value: bson.D{
-		{Key: "from", Value: "table"},
-		{Key: "localField", Value: "order.table_id"},
-		{Key: "foreignField", Value: "table_id"},
-		{Key: "as", Value: "table"},
-	}}}
-	unwindTableStage := bson.D{{Key: "$unwind", Value: bson.D{
-		{Key: "path", Value: "$table"},
-		{Key: "preserveNullAndEmptyArrays", Value: true},
-	}}}
-
-	projectStage := bson.D{{Key: "$project", Value: bson.D{
-		{Key: "id", Value: 0},
-		{Key: "amount", Value: "$food.price"},
-		{Key: "total_count", Value: 1},
-		{Key: "food_name", Value: "$food.name"},
-		{Key: "food_image", Value: "$food.food.image"},
-		{Key: "table_number", Value: "$table.table_number"},
-		{Key: "table_id", Value: "$table.table_id"},
-		{Key: "order_id", Value: "$order.order_id"},
-		{Key: "price", Value: "$food.price"},
-		{Key: "quantity", Value: 1},
-	}}}
-
-	groupStage := bson.D{{Key: "$group", Value: bson.D{
-		{
-			Key: "_id", Value: bson.D{
-				{Key: "order_id", Value: "$order_id"},
-				{Key: "table_id", Value: "$table_id"},
-				{Key: "table_number", Value: "$table_number"},
-			},
-		},
-		{
-			Key: "payment_due", Value: bson.D{
-				{Key: "sum", Value: "$amount"},
-			},
-		},
-		{
-			Key: "total_count", Value: bson.D{
-				{Key: "$sum", Value: 1},
-			},
-		},
-		{
-			Key: "order_items", Value: bson.D{
-				{Key: "$push", Value: "$$ROOT"},
-			},
-		},
-	},
-	}}
-
-	projectStage2 := bson.D{{Key: "$project", Value: bson.D{
-		{Key: "id", Value: 0},
-		{Key: "payment_due", Value: 1},
-		{Key: "total_count", Value: 1},
-		{Key: "table_number", Value: "$_id.table_number"},
-		{Key: "order_items", Value: 1},
-	}}}
-
-	result, err := orderItemCollection.Aggregate(ctx, mongo.Pipeline{
-		matchStage,
-		lookupStage,
-		unwindStage,
-		lookupOrderStage,
-		unwindOrderStage,
-		lookupTableStage,
-		unwindTableStage,
-		projectStage,
-		groupStage,
-		projectStage2,
-	})
+// OrderBill is an order together with its priced items and totals.
+type OrderBill struct {
+	Order_id     string
+	Table_id     string
+	Table_number int
+	Order_items  []helpers.BillLine
+	helpers.BillTotals
+}
+
+// BillForOrder prices every item of the order that has not been voided. It
+// returns repository.ErrNotFound when the order does not exist.
+func (ctrl *Controller) BillForOrder(ctx context.Context, orderId string) (*OrderBill, error) {
+	order, err := ctrl.repos.Orders.Get(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	bill := OrderBill{Order_id: order.Order_id}
+	if order.Table_id != nil {
+		bill.Table_id = *order.Table_id
+		table, err := ctrl.repos.Tables.Get(ctx, *order.Table_id)
+		if err == nil && table.Table_number != nil {
+			bill.Table_number = *table.Table_number
+		} else if err != nil && err != repository.ErrNotFound {
+			return nil, err
+		}
+	}
 
+	lines, err := ctrl.ItemsByOrder(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+	bill.BillTotals, err = helpers.ComputeBill(lines, ctrl.billing)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	bill.Order_items = lines
+	return &bill, nil
+}
 
-	if err = result.All(ctx, &OrderItems); err != nil {
-		panic(err)
+// ItemsByOrder lists the billable items of an order with the food name,
+// menu category and notes joined in. Items keep the unit price, modifiers
+// included, captured when they were ordered; only items stored before
+// prices were captured fall back to the current food price.
+func (ctrl *Controller) ItemsByOrder(ctx context.Context, id string) ([]helpers.BillLine, error) {
+	orderItems, err := ctrl.repos.OrderItems.ListByOrder(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
-	return OrderItems, err
+	foodIds := []string{}
+	for _, orderItem := range orderItems {
+		if orderItem.Food_id != nil {
+			foodIds = append(foodIds, *orderItem.Food_id)
+		}
+	}
+	foods, err := ctrl.repos.Foods.GetMany(ctx, foodIds)
+	if err != nil {
+		return nil, err
+	}
+	menuIds := []string{}
+	for _, food := range foods {
+		if food.Menu_id != nil {
+			menuIds = append(menuIds, *food.Menu_id)
+		}
+	}
+	menus, err := ctrl.repos.Menus.GetMany(ctx, menuIds)
+	if err != nil {
+		return nil, err
+	}
+	orderItemIds := make([]string, 0, len(orderItems))
+	for _, orderItem := range orderItems {
+		orderItemIds = append(orderItemIds, orderItem.Order_item_id)
+	}
+	notes, err := ctrl.itemNotes(ctx, orderItemIds)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []helpers.BillLine{}
+	for _, orderItem := range orderItems {
+		if orderItem.Status != nil && *orderItem.Status == models.ItemVoided {
+			continue
+		}
+		line := helpers.BillLine{
+			Order_item_id: orderItem.Order_item_id,
+			Quantity:      1,
+			Seat:          orderItem.Seat,
+			Modifiers:     orderItem.Modifiers,
+			Notes:         notes[orderItem.Order_item_id],
+		}
+		if orderItem.Quantity != nil {
+			line.Quantity = *orderItem.Quantity
+		}
+		if orderItem.Status != nil {
+			line.Status = *orderItem.Status
+		}
+		if orderItem.Unit_price != nil && orderItem.Unit_price.Currency != "" {
+			line.Unit_price = *orderItem.Unit_price
+		}
+		if orderItem.Food_id != nil {
+			line.Food_id = *orderItem.Food_id
+			if food, ok := foods[*orderItem.Food_id]; ok {
+				if food.Name != nil {
+					line.Food_name = *food.Name
+				}
+				if food.Food_image != nil {
+					line.Food_image = *food.Food_image
+				}
+				if line.Unit_price.Currency == "" && food.Price != nil {
+					line.Unit_price = *food.Price
+				}
+				if food.Menu_id != nil {
+					line.Category = menus[*food.Menu_id].Category
+				}
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
 }
 
 // CreateOrderItem takes a ordered item JSON and store in DB.
 // CreateOrderItem             godoc
 //  @Summary      Store a new ordered item
-//  @Description  Takes a ordered item JSON and store in DB. Return saved JSON.
+//  @Description  Takes a ordered item JSON and store in DB. Return saved JSON. Foods that are 86'd or whose menu is not served at the time of the order are refused with 409.
 //  @Tags         orderItems
 //  @Produce      json
 //  @Success      200  {object}  models.OrderItem
+//  @Failure      409  {object}  map[string]interface{}
 //  @Router       /orderItems [post]
-func CreateOrderItem() gin.HandlerFunc {
+func (ctrl *Controller) CreateOrderItem() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 
 		var orderItemPack OrderItemPack
 		var order models.Order
 
-		if err := c.BindJSON(&orderItemPack); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&orderItemPack); err != nil {
+			c.Error(apperrors.Decoding(err))
 			return
 		}
 
@@ -243,84 +270,386 @@ func CreateOrderItem() gin.HandlerFunc {
 			time.RFC3339, time.Now().Format(time.RFC3339),
 		)
 
-		orderItemsToBeInserted := []interface{}{}
+		orderItems := []models.OrderItem{}
 		order.Table_id = orderItemPack.Table_id
-		order_id := OrderItemOrderCreator(order)
 
+		// validate and price every item before the order is created so a bad
+		// item does not leave an empty order behind
 		for _, orderItem := range orderItemPack.Order_items {
-			orderItem.Order_id = order_id
+			// the order ID is assigned below, satisfy the validator meanwhile
+			orderItem.Order_id = "pending"
 			validationErr := validate.Struct(orderItem)
 			if validationErr != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+				c.Error(apperrors.Validation(validationErr))
 				return
 			}
+
+			food, err := ctrl.repos.Foods.Get(ctx, *orderItem.Food_id)
+			if err == nil && food.Deleted_at != nil {
+				err = repository.ErrNotFound
+			}
+			if err == repository.ErrNotFound {
+				c.Error(apperrors.NotFound("food item was not found"))
+				return
+			} else if err != nil {
+				c.Error(apperrors.Internal("error occurred when fetching the food item", err))
+				return
+			}
+			if servedErr := ctrl.checkServed(ctx, food, order.Order_Date); servedErr != nil {
+				c.Error(servedErr)
+				return
+			}
+			// the price is captured now, later changes to the food do not
+			// affect what this order is billed
+			modifiers, price, modifiersErr := pickModifiers(food, food.Price, orderItem.Modifiers)
+			if modifiersErr != nil {
+				c.Error(modifiersErr)
+				return
+			}
+			orderItem.Modifiers = modifiers
+			orderItem.Unit_price = price
+
 			orderItem.ID = primitive.NewObjectID()
+			orderItem.Deleted_at, orderItem.Deleted_by = nil, nil
+			orderItem.Version = 1
 			orderItem.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 			orderItem.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 			orderItem.Order_item_id = orderItem.ID.Hex()
-			var num = toFixed(*orderItem.Unit_price, 2)
-			orderItem.Unit_price = &num
-			orderItemsToBeInserted = append(orderItemsToBeInserted, orderItem)
+			status := models.ItemQueued
+			orderItem.Status = &status
+			orderItem.Status_updated_at = orderItem.Created_at
+			orderItems = append(orderItems, orderItem)
 		}
 
-		insertOrderItems, err := orderItemCollection.InsertMany(ctx, orderItemsToBeInserted)
+		order_id, err := ctrl.OrderItemOrderCreator(c, order)
 		if err != nil {
-			log.Fatal(err)
+			c.Error(apperrors.Internal("Failed to create the order", err))
+			return
+		}
+		for i := range orderItems {
+			orderItems[i].Order_id = order_id
+		}
+
+		if err := ctrl.repos.OrderItems.CreateMany(ctx, orderItems); err != nil {
+			c.Error(apperrors.Internal("Failed to create the ordered items", err))
+			return
+		}
+		for _, orderItem := range orderItems {
+			ctrl.audit(c, models.AuditCreate, "order_item", orderItem.Order_item_id, nil, orderItem)
+		}
+
+		tableId := ""
+		if orderItemPack.Table_id != nil {
+			tableId = *orderItemPack.Table_id
+		}
+		for _, orderItem := range orderItems {
+			ctrl.events.Publish(events.OrderItemCreated, tableId, order_id, orderItem)
+		}
+		c.JSON(http.StatusOK, orderItems)
+	}
+}
+
+// checkServed refuses to order a food that is 86'd or whose menu is not
+// served at the given time.
+func (ctrl *Controller) checkServed(ctx context.Context, food models.Food, at time.Time) *apperrors.Error {
+	if food.Out_of_stock {
+		return apperrors.Conflict(fmt.Sprintf("%s is 86'd, an ingredient ran out", foodName(food)))
+	}
+	if food.Menu_id == nil {
+		return nil
+	}
+	menu, err := ctrl.repos.Menus.Get(ctx, *food.Menu_id)
+	if err != nil && err != repository.ErrNotFound {
+		return apperrors.Internal("error occurred when fetching the menu", err)
+	}
+	if err == repository.ErrNotFound || !menu.ActiveAt(at, ctrl.location) {
+		msg := fmt.Sprintf("%s is not served at this time, its menu is not active", foodName(food))
+		return apperrors.Conflict(msg)
+	}
+	return nil
+}
+
+func foodName(food models.Food) string {
+	if food.Name == nil {
+		return "food item"
+	}
+	return *food.Name
+}
+
+// pickModifiers checks the options picked for an ordered item against the
+// modifier groups of its food and returns them with their current price
+// deltas, in the order the food lists them, and the unit price of the item:
+// base with the deltas added. The price is nil when base is.
+func pickModifiers(food models.Food, base *models.Money, picks []models.OrderItemModifier) ([]models.OrderItemModifier, *models.Money, *apperrors.Error) {
+	offered := map[string]map[string]bool{}
+	for _, group := range food.Modifier_groups {
+		offered[group.Name] = map[string]bool{}
+		for _, option := range group.Options {
+			offered[group.Name][option.Name] = true
+		}
+	}
+	picked := map[string]map[string]bool{}
+	for _, pick := range picks {
+		if !offered[pick.Group][pick.Option] {
+			msg := fmt.Sprintf("%s has no option %s in %s", foodName(food), pick.Option, pick.Group)
+			return nil, nil, apperrors.Invalid("modifiers", msg)
+		}
+		if picked[pick.Group] == nil {
+			picked[pick.Group] = map[string]bool{}
 		}
-		c.JSON(http.StatusOK, insertOrderItems)
+		if picked[pick.Group][pick.Option] {
+			msg := fmt.Sprintf("%s is picked twice from %s", pick.Option, pick.Group)
+			return nil, nil, apperrors.Invalid("modifiers", msg)
+		}
+		picked[pick.Group][pick.Option] = true
+	}
+
+	modifiers := []models.OrderItemModifier{}
+	var price *models.Money
+	if base != nil {
+		total := *base
+		price = &total
 	}
+	for _, group := range food.Modifier_groups {
+		count := 0
+		for _, option := range group.Options {
+			if !picked[group.Name][option.Name] {
+				continue
+			}
+			count++
+			delta := models.NewMoney(0, "")
+			if option.Price_delta != nil {
+				delta = *option.Price_delta
+			}
+			modifiers = append(modifiers, models.OrderItemModifier{
+				Group: group.Name, Option: option.Name, Price_delta: &delta,
+			})
+			if price != nil {
+				total, err := price.Add(delta)
+				if err != nil {
+					return nil, nil, apperrors.Internal("error occurred while pricing the modifiers", err)
+				}
+				*price = total
+			}
+		}
+		if count < group.Min {
+			msg := fmt.Sprintf("pick at least %d of %s", group.Min, group.Name)
+			return nil, nil, apperrors.Invalid("modifiers", msg)
+		}
+		if group.Max > 0 && count > group.Max {
+			msg := fmt.Sprintf("pick at most %d of %s", group.Max, group.Name)
+			return nil, nil, apperrors.Invalid("modifiers", msg)
+		}
+	}
+	return modifiers, price, nil
+}
+
+// basePrice returns the food price an item was ordered at, its unit price
+// without the price deltas of its modifiers.
+func basePrice(orderItem models.OrderItem) (*models.Money, error) {
+	price := *orderItem.Unit_price
+	for _, modifier := range orderItem.Modifiers {
+		if modifier.Price_delta != nil {
+			var err error
+			if price, err = price.Sub(*modifier.Price_delta); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &price, nil
 }
 
 // UpdateOrderItem takes a ordered item JSON and update ordered item stored in DB.
 // UpdateOrderItem             godoc
 //  @Summary      Update a ordered item
-//  @Description  Takes a ordered item JSON and update ordered item stored in DB. Return saved JSON.
+//  @Description  Takes a ordered item JSON and update ordered item stored in DB. Return saved JSON. The unit price always follows the food and its modifiers. An item of an invoiced order cannot be changed.
 //  @Tags         orderItems
 //  @Produce      json
+//  @Param        If-Match  header  string  false  "ETag of the ordered item as read, the update is refused with 409 when it changed since"
 //  @Success      200  {object}  models.OrderItem
+//  @Header       200  {string}  ETag  "version of the ordered item"
+//  @Failure      404  {object}  map[string]interface{}
+//  @Failure      409  {object}  map[string]interface{}
 //  @Router       /orderItems/{order_item_id} [patch]
-func UpdateOrderItem() gin.HandlerFunc {
+func (ctrl *Controller) UpdateOrderItem() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 		var orderItem models.OrderItem
 		orderItemId := c.Param("order_item_id")
-		var updateObj primitive.D
 
-		if orderItem.Unit_price != nil {
-			updateObj = append(updateObj, bson.E{Key: "unit_price", Value: orderItem.Unit_price})
+		if err := c.ShouldBindJSON(&orderItem); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
 		}
 
-		if orderItem.Quantity != nil {
-			updateObj = append(updateObj, bson.E{Key: "quantity", Value: orderItem.Quantity})
+		version, matchErr := ifMatch(c)
+		if matchErr != nil {
+			c.Error(matchErr)
+			return
 		}
 
-		if orderItem.Food_id != nil {
-			updateObj = append(updateObj, bson.E{Key: "food_id", Value: orderItem.Food_id})
+		if orderItem.Quantity != nil && *orderItem.Quantity < 1 {
+			c.Error(apperrors.Invalid("quantity", "must be at least 1"))
+			return
 		}
 
-		orderItem.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: orderItem.Updated_at})
+		before, err := ctrl.repos.OrderItems.Get(ctx, orderItemId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("ordered item was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the ordered item", err))
+			return
+		}
+		// the bill of an invoiced order is settled against, changing its
+		// items would leave the payments off the total
+		invoiced, err := ctrl.orderInvoiced(ctx, before.Order_id)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing invoices", err))
+			return
+		}
+		if invoiced {
+			c.Error(apperrors.Conflict("order of the ordered item has an invoice"))
+			return
+		}
+
+		// the price is never taken from the request
+		orderItem.Unit_price = nil
+		swapped := orderItem.Food_id != nil && (before.Food_id == nil || *orderItem.Food_id != *before.Food_id)
+		if swapped || orderItem.Modifiers != nil {
+			foodId := orderItem.Food_id
+			if foodId == nil {
+				foodId = before.Food_id
+			}
+			if foodId == nil {
+				c.Error(apperrors.Invalid("food_id", "is required to pick modifiers"))
+				return
+			}
+			food, err := ctrl.repos.Foods.Get(ctx, *foodId)
+			if err == nil && food.Deleted_at != nil {
+				err = repository.ErrNotFound
+			}
+			if err != nil {
+				c.Error(apperrors.NotFound("food item was not found"))
+				return
+			}
+			if swapped {
+				if servedErr := ctrl.checkServed(ctx, food, time.Now()); servedErr != nil {
+					c.Error(servedErr)
+					return
+				}
+			}
+			// swapping the food captures its current price, picking other
+			// modifiers keeps the food price the item was ordered at
+			base := food.Price
+			if !swapped && before.Unit_price != nil {
+				if base, err = basePrice(before); err != nil {
+					c.Error(apperrors.Internal("error occurred while pricing the ordered item", err))
+					return
+				}
+			}
+			modifiers, price, modifiersErr := pickModifiers(food, base, orderItem.Modifiers)
+			if modifiersErr != nil {
+				c.Error(modifiersErr)
+				return
+			}
+			orderItem.Modifiers = modifiers
+			orderItem.Unit_price = price
+		}
 
-		upsert := true
-		filter := bson.M{"order_item_id": orderItemId}
+		orderItem.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		updated, err := ctrl.repos.OrderItems.Update(ctx, orderItemId, version, orderItem)
+		if err != nil {
+			c.Error(updateError("ordered item", err))
+			return
 		}
+		ctrl.audit(c, models.AuditUpdate, "order_item", orderItemId, before, updated)
 
-		result, err := orderItemCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{{Key: "$set", Value: updateObj}},
-			&opt,
+		ctrl.events.Publish(
+			events.OrderItemUpdated, ctrl.tableOfOrder(ctx, updated.Order_id), updated.Order_id, updated,
 		)
+		setETag(c, updated.Version)
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// DeleteOrderItem soft deletes the ordered item with provided ID.
+// DeleteOrderItem             godoc
+//  @Summary      Delete an ordered item
+//  @Description  Marks the ordered item deleted, it is hidden from the lists of ordered items, the kitchen and the bill until restored. An item of an invoiced order cannot be deleted.
+//  @Tags         orderItems
+//  @Produce      json
+//  @Success      200  {object}  models.OrderItem
+//  @Router       /orderItems/{order_item_id} [delete]
+func (ctrl *Controller) DeleteOrderItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		orderItemId := c.Param("order_item_id")
+
+		orderItem, err := ctrl.repos.OrderItems.Get(ctx, orderItemId)
+		if err != nil {
+			c.Error(deletionError("ordered item", err, false))
+			return
+		}
+		invoiced, err := ctrl.orderInvoiced(ctx, orderItem.Order_id)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing invoices", err))
+			return
+		}
+		if invoiced {
+			c.Error(apperrors.Conflict("order of the ordered item has an invoice"))
+			return
+		}
+
+		deleted_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		deleted, err := ctrl.repos.OrderItems.Delete(ctx, orderItemId, c.GetString("uid"), deleted_at)
+		if err != nil {
+			c.Error(deletionError("ordered item", err, false))
+			return
+		}
+		ctrl.audit(c, models.AuditDelete, "order_item", orderItemId, orderItem, deleted)
+		c.JSON(http.StatusOK, deleted)
+	}
+}
+
+// RestoreOrderItem clears the deletion of the ordered item with provided ID.
+// RestoreOrderItem             godoc
+//  @Summary      Restore a deleted ordered item
+//  @Description  Clears the deletion of the ordered item. Its order must not be deleted.
+//  @Tags         orderItems
+//  @Produce      json
+//  @Success      200  {object}  models.OrderItem
+//  @Router       /orderItems/{order_item_id}/restore [post]
+func (ctrl *Controller) RestoreOrderItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		orderItemId := c.Param("order_item_id")
+
+		orderItem, err := ctrl.repos.OrderItems.Get(ctx, orderItemId)
+		if err != nil {
+			c.Error(deletionError("ordered item", err, true))
+			return
+		}
+		order, err := ctrl.repos.Orders.Get(ctx, orderItem.Order_id)
+		if err != nil && err != repository.ErrNotFound {
+			c.Error(apperrors.Internal("error occurred when fetching the order", err))
+			return
+		}
+		if err == nil && order.Deleted_at != nil {
+			c.Error(apperrors.Conflict("order of the ordered item is deleted, restore it first"))
+			return
+		}
 
+		restored, err := ctrl.repos.OrderItems.Restore(ctx, orderItemId)
 		if err != nil {
-			msg := "Failed to update the ordered item"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(deletionError("ordered item", err, true))
 			return
 		}
-		c.JSON(http.StatusOK, result)
+		ctrl.audit(c, models.AuditRestore, "order_item", orderItemId, orderItem, restored)
+		c.JSON(http.StatusOK, restored)
 	}
 }
@@ -0,0 +1,384 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/events"
+	"github.com/minhtran241/restaurant-management/helpers"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+type splitGroup struct {
+	Label          string   `json:"label"`
+	Order_item_ids []string `json:"order_item_ids" validate:"required,min=1"`
+}
+
+type splitRequest struct {
+	Mode   *string      `json:"mode" validate:"required,eq=EVEN|eq=SEAT|eq=ITEM"`
+	Parts  int          `json:"parts" validate:"omitempty,min=2,max=50"`
+	Groups []splitGroup `json:"groups" validate:"dive"`
+}
+
+// RecordPayment takes a payment JSON and records it against an invoice.
+// RecordPayment             godoc
+//  @Summary      Record a payment
+//  @Description  Takes a payment JSON (method, amount, tip, reference and optionally the split it pays) and adds it to the invoice. The payment may not exceed the balance due. The invoice becomes PARTIALLY_PAID, and PAID once the balance reaches zero.
+//  @Tags         invoices
+//  @Produce      json
+//  @Success      200  {object}  InvoiceViewFormat
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /invoices/{invoice_id}/payments [post]
+func (ctrl *Controller) RecordPayment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var payment models.Payment
+
+		if err := c.ShouldBindJSON(&payment); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+		if validationErr := validate.Struct(payment); validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+
+		invoice, bill, ok := ctrl.findInvoiceBill(ctx, c)
+		if !ok {
+			return
+		}
+		if invoice.Payment_status != nil && *invoice.Payment_status == models.PaymentPaid {
+			c.Error(apperrors.Conflict("invoice is already paid"))
+			return
+		}
+
+		amount := models.NewMoney(payment.Amount.Amount, payment.Amount.Currency)
+		tip := models.NewMoney(0, amount.Currency)
+		if payment.Tip != nil {
+			tip = models.NewMoney(payment.Tip.Amount, payment.Tip.Currency)
+		}
+		if amount.Currency != bill.Total.Currency || tip.Currency != bill.Total.Currency {
+			msg := fmt.Sprintf("payment must be in %s", bill.Total.Currency)
+			c.Error(apperrors.BadRequest(msg))
+			return
+		}
+		if amount.Amount <= 0 || tip.Amount < 0 {
+			c.Error(apperrors.Invalid("amount", "must be positive"))
+			return
+		}
+
+		paid, _, err := sumPayments(invoice.Payments, "")
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while adding up the payments", err))
+			return
+		}
+		balance, err := bill.Total.Sub(paid)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while computing the balance due", err))
+			return
+		}
+		if amount.Amount > balance.Amount {
+			msg := fmt.Sprintf("payment exceeds the balance due of %s", balance)
+			c.Error(apperrors.BadRequest(msg))
+			return
+		}
+
+		if payment.Split_id != "" {
+			split, found := findSplit(invoice, payment.Split_id)
+			if !found {
+				c.Error(apperrors.NotFound("split was not found"))
+				return
+			}
+			splitPaid, _, err := sumPayments(invoice.Payments, split.Split_id)
+			if err != nil {
+				c.Error(apperrors.Internal("error occurred while adding up the payments", err))
+				return
+			}
+			remaining, err := split.Amount.Sub(splitPaid)
+			if err != nil {
+				c.Error(apperrors.Internal("error occurred while computing the balance due", err))
+				return
+			}
+			if amount.Amount > remaining.Amount {
+				msg := fmt.Sprintf("payment exceeds the balance due of the split, %s", remaining)
+				c.Error(apperrors.BadRequest(msg))
+				return
+			}
+		}
+
+		payment.Payment_id = primitive.NewObjectID().Hex()
+		payment.Amount = &amount
+		payment.Tip = &tip
+		payment.Taken_by = c.GetString("uid")
+		payment.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		// the currency of the payment was checked against the bill above
+		paidNow, _ := paid.Add(amount)
+		status := paymentStatus(paidNow, bill.Total)
+		paymentsSeen := len(invoice.Payments)
+		before := invoice
+		invoice.Payments = append(invoice.Payments, payment)
+		invoice.Payment_status = &status
+		invoice.Payment_method = payment.Method
+		invoice.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		// only apply the payment if no other payment was recorded since the
+		// invoice was read, otherwise the balance check above is stale
+		err = ctrl.repos.Invoices.SavePayments(ctx, invoice, paymentsSeen)
+		if err == repository.ErrConflict {
+			c.Error(apperrors.Conflict("another payment was recorded meanwhile, please retry"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("Failed to record the payment", err))
+			return
+		}
+		ctrl.audit(c, models.AuditUpdate, "invoice", invoice.Invoice_id, before, invoice)
+
+		// a settled table is cleared for the next party
+		if status == models.PaymentPaid {
+			if tableId := ctrl.tableOfOrder(ctx, invoice.Order_id); tableId != "" {
+				ctrl.setTableStatus(ctx, tableId, models.TableNeedsCleaning)
+			}
+		}
+
+		ctrl.respondInvoice(ctx, c, invoice)
+	}
+}
+
+// SplitInvoice divides an invoice into shares paid separately.
+// SplitInvoice             godoc
+//  @Summary      Split an invoice
+//  @Description  Divides the amount due of the invoice into splits. EVEN takes the number of parts, SEAT splits by the seat of the ordered items (items without a seat are shared evenly) and ITEM takes groups of ordered item IDs covering every item. Taxes and service charge are shared in proportion. Replaces earlier splits as long as no payment was taken against them.
+//  @Tags         invoices
+//  @Produce      json
+//  @Success      200  {object}  InvoiceViewFormat
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /invoices/{invoice_id}/splits [post]
+func (ctrl *Controller) SplitInvoice() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var req splitRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+		if validationErr := validate.Struct(req); validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+
+		invoice, bill, ok := ctrl.findInvoiceBill(ctx, c)
+		if !ok {
+			return
+		}
+		if invoice.Payment_status != nil && *invoice.Payment_status == models.PaymentPaid {
+			c.Error(apperrors.Conflict("invoice is already paid"))
+			return
+		}
+		for _, payment := range invoice.Payments {
+			if payment.Split_id != "" {
+				c.Error(apperrors.Conflict("payments were already taken against the current splits"))
+				return
+			}
+		}
+
+		// what is left to pay is split, payments taken before the split
+		// count for the whole table
+		paid, _, err := sumPayments(invoice.Payments, "")
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while adding up the payments", err))
+			return
+		}
+		due, err := bill.Total.Sub(paid)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while computing the balance due", err))
+			return
+		}
+		splits, msg := buildSplits(req, bill, due)
+		if msg != "" {
+			c.Error(apperrors.BadRequest(msg))
+			return
+		}
+
+		before := invoice
+		invoice.Splits = splits
+		invoice.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		err = ctrl.repos.Invoices.SavePayments(ctx, invoice, len(invoice.Payments))
+		if err == repository.ErrConflict {
+			c.Error(apperrors.Conflict("a payment was recorded meanwhile, please retry"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("Failed to split the invoice", err))
+			return
+		}
+		ctrl.audit(c, models.AuditUpdate, "invoice", invoice.Invoice_id, before, invoice)
+
+		ctrl.respondInvoice(ctx, c, invoice)
+	}
+}
+
+// buildSplits divides amount according to the request. The lines' totals
+// are the weights, so each share carries its part of taxes and service.
+func buildSplits(req splitRequest, bill *OrderBill, amount models.Money) ([]models.InvoiceSplit, string) {
+	var splits []models.InvoiceSplit
+	var weights []int64
+
+	switch *req.Mode {
+	case models.SplitEven:
+		if req.Parts < 2 {
+			return nil, "an even split needs at least 2 parts"
+		}
+		for i := 1; i <= req.Parts; i++ {
+			splits = append(splits, models.InvoiceSplit{
+				Label:          fmt.Sprintf("Part %d of %d", i, req.Parts),
+				Order_item_ids: []string{},
+			})
+			weights = append(weights, 1)
+		}
+
+	case models.SplitSeat:
+		seats := map[int]int{}
+		var shared int64
+		for _, line := range bill.Order_items {
+			if line.Seat == nil {
+				shared += line.Line_total.Amount
+				continue
+			}
+			index, ok := seats[*line.Seat]
+			if !ok {
+				seat := *line.Seat
+				index = len(splits)
+				seats[seat] = index
+				splits = append(splits, models.InvoiceSplit{
+					Label:          "Seat " + strconv.Itoa(seat),
+					Seat:           &seat,
+					Order_item_ids: []string{},
+				})
+				weights = append(weights, 0)
+			}
+			splits[index].Order_item_ids = append(splits[index].Order_item_ids, line.Order_item_id)
+			weights[index] += line.Line_total.Amount
+		}
+		if len(splits) == 0 {
+			return nil, "no ordered item has a seat"
+		}
+		// shared items are spread evenly over the seats; weights are scaled
+		// by the number of seats to keep that exact in integers
+		for i := range weights {
+			weights[i] = weights[i]*int64(len(splits)) + shared
+		}
+
+	case models.SplitItem:
+		lineTotals := map[string]int64{}
+		for _, line := range bill.Order_items {
+			lineTotals[line.Order_item_id] = line.Line_total.Amount
+		}
+		assigned := map[string]bool{}
+		for i, group := range req.Groups {
+			var weight int64
+			for _, id := range group.Order_item_ids {
+				total, ok := lineTotals[id]
+				if !ok {
+					return nil, fmt.Sprintf("ordered item %s is not on this invoice", id)
+				}
+				if assigned[id] {
+					return nil, fmt.Sprintf("ordered item %s is in more than one group", id)
+				}
+				assigned[id] = true
+				weight += total
+			}
+			label := group.Label
+			if label == "" {
+				label = fmt.Sprintf("Group %d", i+1)
+			}
+			splits = append(splits, models.InvoiceSplit{Label: label, Order_item_ids: group.Order_item_ids})
+			weights = append(weights, weight)
+		}
+		if len(assigned) != len(lineTotals) {
+			return nil, "every ordered item of the invoice must be in a group"
+		}
+		if len(splits) < 2 {
+			return nil, "an item split needs at least 2 groups"
+		}
+	}
+
+	for i, share := range helpers.Allocate(amount, weights) {
+		splits[i].Split_id = primitive.NewObjectID().Hex()
+		splits[i].Amount = share
+	}
+	return splits, ""
+}
+
+// paymentStatus derives the status of an invoice from what has been paid.
+// Nothing is due on a zero total, it is paid as it is.
+func paymentStatus(paid, total models.Money) string {
+	switch {
+	case paid.Amount >= total.Amount:
+		return models.PaymentPaid
+	case paid.Amount > 0:
+		return models.PaymentPartiallyPaid
+	default:
+		return models.PaymentPending
+	}
+}
+
+func findSplit(invoice models.Invoice, splitId string) (models.InvoiceSplit, bool) {
+	for _, split := range invoice.Splits {
+		if split.Split_id == splitId {
+			return split, true
+		}
+	}
+	return models.InvoiceSplit{}, false
+}
+
+// findInvoiceBill loads the invoice named in the URL and prices its order,
+// writing the error response itself when it cannot. A deleted invoice counts
+// as missing.
+func (ctrl *Controller) findInvoiceBill(ctx context.Context, c *gin.Context) (models.Invoice, *OrderBill, bool) {
+	invoice, err := ctrl.repos.Invoices.Get(ctx, c.Param("invoice_id"))
+	if err == nil && invoice.Deleted_at != nil {
+		err = repository.ErrNotFound
+	}
+	if err == repository.ErrNotFound {
+		c.Error(apperrors.NotFound("invoice was not found"))
+		return invoice, nil, false
+	} else if err != nil {
+		c.Error(apperrors.Internal("error occurred when fetching the invoice", err))
+		return invoice, nil, false
+	}
+
+	bill, err := ctrl.BillForOrder(ctx, invoice.Order_id)
+	if err == repository.ErrNotFound {
+		c.Error(apperrors.NotFound("order of the invoice was not found"))
+		return invoice, nil, false
+	} else if err != nil {
+		c.Error(apperrors.Internal("error occurred when computing the invoice", err))
+		return invoice, nil, false
+	}
+	return invoice, bill, true
+}
+
+// respondInvoice publishes the change of the invoice and responds with its
+// view.
+func (ctrl *Controller) respondInvoice(ctx context.Context, c *gin.Context, invoice models.Invoice) {
+	invoiceView, err := ctrl.buildInvoiceView(ctx, invoice)
+	if err != nil {
+		c.Error(apperrors.Internal("error occurred when computing the invoice", err))
+		return
+	}
+	ctrl.events.Publish(
+		events.InvoiceUpdated, ctrl.tableOfOrder(ctx, invoice.Order_id), invoice.Order_id, invoice,
+	)
+	c.JSON(http.StatusOK, invoiceView)
+}
@@ -0,0 +1,673 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+// defaultUsageDays is how many days of ordered items the reorder report
+// averages the usage over by default.
+const defaultUsageDays = 14
+
+var purchaseOrderListParams = listParams{
+	filters: map[string]filterParam{
+		"supplier_id":   {"supplier_id", repository.OpEq, paramString},
+		"status":        {"status", repository.OpIn, paramList},
+		"expected_from": {"expected_at", repository.OpGte, paramTime},
+		"expected_to":   {"expected_at", repository.OpLt, paramTime},
+	},
+	sorts: map[string]string{
+		"created_at": "created_at", "expected_at": "expected_at",
+	},
+	defaultSort: "-created_at",
+	softDeleted: true,
+}
+
+// purchaseOrderLines validates the lines of a purchase order update on
+// their own.
+type purchaseOrderLines struct {
+	Lines []models.PurchaseOrderLine `json:"lines" validate:"min=1,dive"`
+}
+
+// ReceiptLine is a quantity of an ingredient of a purchase order delivered.
+type ReceiptLine struct {
+	Ingredient_id string  `json:"ingredient_id" validate:"required"`
+	Quantity      float64 `json:"quantity" validate:"gt=0"`
+}
+
+// PurchaseReceipt is a delivery of a purchase order. Without lines all that
+// is still to be received has been delivered.
+type PurchaseReceipt struct {
+	Lines []ReceiptLine `json:"lines" validate:"omitempty,dive"`
+}
+
+type PurchaseReceiptResponse struct {
+	Purchase_order models.PurchaseOrder   `json:"purchase_order"`
+	Movements      []models.StockMovement `json:"movements"`
+}
+
+// ReorderLine is an ingredient to restock: enough to cover its par level
+// and the usage expected until a new delivery arrives, less the stock on
+// hand and on order.
+type ReorderLine struct {
+	Ingredient_id       string  `json:"ingredient_id"`
+	Name                string  `json:"name"`
+	Unit                string  `json:"unit"`
+	Supplier_id         *string `json:"supplier_id"`
+	On_hand             float64 `json:"on_hand"`
+	On_order            float64 `json:"on_order"`
+	Par_level           float64 `json:"par_level"`
+	Average_daily_usage float64 `json:"average_daily_usage"`
+	Lead_time_days      int     `json:"lead_time_days"`
+	Suggested_quantity  float64 `json:"suggested_quantity"`
+}
+
+type ReorderReport struct {
+	From  time.Time     `json:"from"`
+	To    time.Time     `json:"to"`
+	Days  int           `json:"days"`
+	Lines []ReorderLine `json:"lines"`
+}
+
+// GetPurchaseOrders responds with a page of purchase orders as JSON.
+// GetPurchaseOrders             godoc
+//  @Summary      Get all purchase orders
+//  @Description  Responds with a page of purchase orders, newest first, filtered by supplier, status and expected delivery.
+//  @Tags         purchaseOrders
+//  @Produce      json
+//  @Param        limit          query  int     false  "page size, 20 by default, at most 100"
+//  @Param        offset         query  int     false  "number of items to skip"
+//  @Param        page           query  int     false  "1-based page, used when offset is not given"
+//  @Param        supplier_id    query  string  false  "only purchase orders placed with the supplier"
+//  @Param        status         query  string  false  "comma separated statuses: ORDERED, PARTIAL, RECEIVED, CANCELLED"
+//  @Param        expected_from  query  string  false  "expected at or after, RFC 3339"
+//  @Param        expected_to    query  string  false  "expected before, RFC 3339"
+//  @Param        sort           query  string  false  "created_at or expected_at, prefixed with - for descending"
+//  @Param        include_deleted  query  bool  false  "also list deleted records, admins only"
+//  @Success      200  {object}  controllers.ListResponse
+//  @Router       /purchaseOrders [get]
+func (ctrl *Controller) GetPurchaseOrders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		query, queryErr := purchaseOrderListParams.parse(c)
+		if queryErr != nil {
+			c.Error(queryErr)
+			return
+		}
+		purchaseOrders, total, err := ctrl.repos.PurchaseOrders.List(ctx, query)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing purchase orders", err))
+			return
+		}
+		c.JSON(http.StatusOK, newListResponse(purchaseOrders, total, query))
+	}
+}
+
+// GetPurchaseOrder responds with the purchase order with provided ID as JSON.
+// GetPurchaseOrder             godoc
+//  @Summary      Get single purchase order by ID
+//  @Description  Responds with the purchase order with provided ID as JSON.
+//  @Tags         purchaseOrders
+//  @Produce      json
+//  @Success      200  {object}  models.PurchaseOrder
+//  @Header       200  {string}  ETag  "version of the purchase order"
+//  @Router       /purchaseOrders/{purchase_order_id} [get]
+func (ctrl *Controller) GetPurchaseOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		purchaseOrder, err := ctrl.repos.PurchaseOrders.Get(ctx, c.Param("purchase_order_id"))
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("purchase order was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the purchase order", err))
+			return
+		}
+		setETag(c, purchaseOrder.Version)
+		c.JSON(http.StatusOK, purchaseOrder)
+	}
+}
+
+// CreatePurchaseOrder takes a purchase order JSON and store in DB.
+// CreatePurchaseOrder             godoc
+//  @Summary      Place a purchase order
+//  @Description  Takes a purchase order JSON with a supplier and lines of ingredients and store in DB as ORDERED. Without an expected delivery it is expected after the lead time of the supplier. Return saved JSON.
+//  @Tags         purchaseOrders
+//  @Produce      json
+//  @Success      200  {object}  models.PurchaseOrder
+//  @Failure      404  {object}  map[string]interface{}
+//  @Router       /purchaseOrders [post]
+func (ctrl *Controller) CreatePurchaseOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var purchaseOrder models.PurchaseOrder
+
+		if err := c.ShouldBindJSON(&purchaseOrder); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+
+		validationErr := validate.Struct(purchaseOrder)
+		if validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+
+		supplier, supplierErr := ctrl.activeSupplier(ctx, purchaseOrder.Supplier_id)
+		if supplierErr != nil {
+			c.Error(supplierErr)
+			return
+		}
+		if linesErr := ctrl.checkPurchaseLines(ctx, purchaseOrder.Lines); linesErr != nil {
+			c.Error(linesErr)
+			return
+		}
+
+		purchaseOrder.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		purchaseOrder.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		purchaseOrder.ID = primitive.NewObjectID()
+		purchaseOrder.Deleted_at, purchaseOrder.Deleted_by = nil, nil
+		purchaseOrder.Version = 1
+		purchaseOrder.Purchase_order_id = purchaseOrder.ID.Hex()
+		purchaseOrder.Status = models.PurchaseOrdered
+		purchaseOrder.Ordered_by = c.GetString("uid")
+		if purchaseOrder.Expected_at == nil && supplier.Lead_time_days != nil {
+			expected_at := purchaseOrder.Created_at.AddDate(0, 0, *supplier.Lead_time_days)
+			purchaseOrder.Expected_at = &expected_at
+		}
+
+		if err := ctrl.repos.PurchaseOrders.Create(ctx, purchaseOrder); err != nil {
+			c.Error(apperrors.Internal("Failed to create the purchase order", err))
+			return
+		}
+		ctrl.audit(c, models.AuditCreate, "purchase_order", purchaseOrder.Purchase_order_id, nil, purchaseOrder)
+		c.JSON(http.StatusOK, purchaseOrder)
+	}
+}
+
+// UpdatePurchaseOrder takes a purchase order JSON and update purchase order stored in DB.
+// UpdatePurchaseOrder             godoc
+//  @Summary      Update a purchase order
+//  @Description  Takes a purchase order JSON and changes its expected delivery, its lines while nothing is received, or cancels it with status CANCELLED. Received and cancelled orders cannot be changed. Return saved JSON.
+//  @Tags         purchaseOrders
+//  @Produce      json
+//  @Param        If-Match  header  string  false  "ETag of the purchase order as read, the update is refused with 409 when it changed since"
+//  @Success      200  {object}  models.PurchaseOrder
+//  @Header       200  {string}  ETag  "version of the purchase order"
+//  @Failure      404  {object}  map[string]interface{}
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /purchaseOrders/{purchase_order_id} [patch]
+func (ctrl *Controller) UpdatePurchaseOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var purchaseOrder models.PurchaseOrder
+		purchaseOrderId := c.Param("purchase_order_id")
+
+		if err := c.ShouldBindJSON(&purchaseOrder); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+
+		version, matchErr := ifMatch(c)
+		if matchErr != nil {
+			c.Error(matchErr)
+			return
+		}
+
+		if purchaseOrder.Status != "" && purchaseOrder.Status != models.PurchaseCancelled {
+			c.Error(apperrors.Invalid("status", "can only be set to CANCELLED, the others follow the deliveries"))
+			return
+		}
+		if purchaseOrder.Lines != nil {
+			if validationErr := validate.Struct(purchaseOrderLines{purchaseOrder.Lines}); validationErr != nil {
+				c.Error(apperrors.Validation(validationErr))
+				return
+			}
+			if linesErr := ctrl.checkPurchaseLines(ctx, purchaseOrder.Lines); linesErr != nil {
+				c.Error(linesErr)
+				return
+			}
+		}
+
+		before, err := ctrl.repos.PurchaseOrders.Get(ctx, purchaseOrderId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("purchase order was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the purchase order", err))
+			return
+		}
+		if before.Status == models.PurchaseReceived || before.Status == models.PurchaseCancelled {
+			c.Error(apperrors.Conflict(fmt.Sprintf("purchase order is %s and cannot be changed", before.Status)))
+			return
+		}
+		if purchaseOrder.Lines != nil && before.Status != models.PurchaseOrdered {
+			c.Error(apperrors.Conflict("purchase order is partly received, its lines cannot be changed"))
+			return
+		}
+		// the checks above hold for the order as read
+		if version == nil {
+			version = &before.Version
+		}
+
+		purchaseOrder.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		result, err := ctrl.repos.PurchaseOrders.Update(ctx, purchaseOrderId, version, purchaseOrder)
+		if err != nil {
+			c.Error(updateError("purchase order", err))
+			return
+		}
+		ctrl.audit(c, models.AuditUpdate, "purchase_order", purchaseOrderId, before, result)
+		setETag(c, result.Version)
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// ReceivePurchaseOrder records a delivery of a purchase order.
+// ReceivePurchaseOrder             godoc
+//  @Summary      Receive a purchase order
+//  @Description  Takes a receipt JSON with the quantities of the ingredients delivered, or no lines when all that is still to be received arrived. The quantities are added to the stock as deliveries and the order becomes PARTIAL or RECEIVED. Receiving more than is still to be received is refused.
+//  @Tags         purchaseOrders
+//  @Produce      json
+//  @Success      200  {object}  controllers.PurchaseReceiptResponse
+//  @Header       200  {string}  ETag  "version of the purchase order"
+//  @Failure      404  {object}  map[string]interface{}
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /purchaseOrders/{purchase_order_id}/receipts [post]
+func (ctrl *Controller) ReceivePurchaseOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var receipt PurchaseReceipt
+		purchaseOrderId := c.Param("purchase_order_id")
+
+		if err := c.ShouldBindJSON(&receipt); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+		if validationErr := validate.Struct(receipt); validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+
+		before, err := ctrl.repos.PurchaseOrders.Get(ctx, purchaseOrderId)
+		if err == nil && before.Deleted_at != nil {
+			err = repository.ErrNotFound
+		}
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("purchase order was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the purchase order", err))
+			return
+		}
+		if before.Status != models.PurchaseOrdered && before.Status != models.PurchasePartial {
+			c.Error(apperrors.Conflict(fmt.Sprintf("purchase order is %s and cannot be received", before.Status)))
+			return
+		}
+
+		lines, received, receiptErr := receiveLines(before.Lines, receipt.Lines)
+		if receiptErr != nil {
+			c.Error(receiptErr)
+			return
+		}
+		status := models.PurchaseReceived
+		for _, line := range lines {
+			if line.Outstanding() > 0 {
+				status = models.PurchasePartial
+			}
+		}
+
+		updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		// claim the delivery on the order as read before adding the stock,
+		// so it is not received twice
+		result, err := ctrl.repos.PurchaseOrders.Update(ctx, purchaseOrderId, &before.Version, models.PurchaseOrder{
+			Lines: lines, Status: status, Updated_at: updated_at,
+		})
+		if err != nil {
+			c.Error(updateError("purchase order", err))
+			return
+		}
+
+		movements := []models.StockMovement{}
+		for _, line := range received {
+			ingredient, err := ctrl.repos.Ingredients.Adjust(ctx, line.Ingredient_id, line.Quantity, updated_at)
+			if err != nil {
+				log.Printf("failed to add %v of ingredient %s received with purchase order %s: %v",
+					line.Quantity, line.Ingredient_id, purchaseOrderId, err)
+				continue
+			}
+			movement := newStockMovement(c, ingredient, models.StockDelivery, line.Quantity, "purchase order")
+			movement.Purchase_order_id = &result.Purchase_order_id
+			movements = append(movements, movement)
+		}
+		ctrl.recordStock(ctx, movements, updated_at)
+		ctrl.audit(c, models.AuditUpdate, "purchase_order", purchaseOrderId, before, result)
+		setETag(c, result.Version)
+		c.JSON(http.StatusOK, PurchaseReceiptResponse{Purchase_order: result, Movements: movements})
+	}
+}
+
+// DeletePurchaseOrder soft deletes the purchase order with provided ID.
+// DeletePurchaseOrder             godoc
+//  @Summary      Delete a purchase order
+//  @Description  Marks the purchase order deleted, it is hidden from the list of purchase orders until restored. A purchase order some of which was received cannot be deleted.
+//  @Tags         purchaseOrders
+//  @Produce      json
+//  @Success      200  {object}  models.PurchaseOrder
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /purchaseOrders/{purchase_order_id} [delete]
+func (ctrl *Controller) DeletePurchaseOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		purchaseOrderId := c.Param("purchase_order_id")
+
+		purchaseOrder, err := ctrl.repos.PurchaseOrders.Get(ctx, purchaseOrderId)
+		if err != nil {
+			c.Error(deletionError("purchase order", err, false))
+			return
+		}
+		for _, line := range purchaseOrder.Lines {
+			if line.Received > 0 {
+				c.Error(apperrors.Conflict("purchase order was received, its deliveries are in the stock"))
+				return
+			}
+		}
+
+		deleted_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		deleted, err := ctrl.repos.PurchaseOrders.Delete(ctx, purchaseOrderId, c.GetString("uid"), deleted_at)
+		if err != nil {
+			c.Error(deletionError("purchase order", err, false))
+			return
+		}
+		ctrl.audit(c, models.AuditDelete, "purchase_order", purchaseOrderId, purchaseOrder, deleted)
+		c.JSON(http.StatusOK, deleted)
+	}
+}
+
+// RestorePurchaseOrder clears the deletion of the purchase order with provided ID.
+// RestorePurchaseOrder             godoc
+//  @Summary      Restore a deleted purchase order
+//  @Description  Clears the deletion of the purchase order. Its supplier must not be deleted.
+//  @Tags         purchaseOrders
+//  @Produce      json
+//  @Success      200  {object}  models.PurchaseOrder
+//  @Router       /purchaseOrders/{purchase_order_id}/restore [post]
+func (ctrl *Controller) RestorePurchaseOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		purchaseOrderId := c.Param("purchase_order_id")
+
+		purchaseOrder, err := ctrl.repos.PurchaseOrders.Get(ctx, purchaseOrderId)
+		if err != nil {
+			c.Error(deletionError("purchase order", err, true))
+			return
+		}
+		supplier, err := ctrl.repos.Suppliers.Get(ctx, purchaseOrder.Supplier_id)
+		if err != nil && err != repository.ErrNotFound {
+			c.Error(apperrors.Internal("error occurred when fetching the supplier", err))
+			return
+		}
+		if err == nil && supplier.Deleted_at != nil {
+			c.Error(apperrors.Conflict("supplier of the purchase order is deleted, restore it first"))
+			return
+		}
+
+		restored, err := ctrl.repos.PurchaseOrders.Restore(ctx, purchaseOrderId)
+		if err != nil {
+			c.Error(deletionError("purchase order", err, true))
+			return
+		}
+		ctrl.audit(c, models.AuditRestore, "purchase_order", purchaseOrderId, purchaseOrder, restored)
+		c.JSON(http.StatusOK, restored)
+	}
+}
+
+// GetReorderReport responds with the ingredients to reorder as JSON.
+// GetReorderReport             godoc
+//  @Summary      Get the suggested reorders
+//  @Description  Responds with the ingredients with a par level that should be reordered. The usage of every ingredient is averaged over the ordered items of the last days, and the suggested quantity covers the par level and the usage expected during the lead time of the supplier, less the stock on hand and still to be delivered.
+//  @Tags         purchaseOrders
+//  @Produce      json
+//  @Param        days         query  int     false  "days of ordered items to average the usage over, 14 by default, at most 365"
+//  @Param        supplier_id  query  string  false  "only ingredients bought from the supplier"
+//  @Success      200  {object}  controllers.ReorderReport
+//  @Router       /ingredients/reorder [get]
+func (ctrl *Controller) GetReorderReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		days := defaultUsageDays
+		if value := c.Query("days"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 || parsed > 365 {
+				c.Error(apperrors.Invalid("days", "must be a number between 1 and 365"))
+				return
+			}
+			days = parsed
+		}
+		report := ReorderReport{Days: days, Lines: []ReorderLine{}}
+		report.To, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		report.From = report.To.AddDate(0, 0, -days)
+
+		usage, err := ctrl.ingredientUsage(ctx, report.From)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while adding up the ingredient usage", err))
+			return
+		}
+		onOrder, err := ctrl.ingredientsOnOrder(ctx)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing purchase orders", err))
+			return
+		}
+		suppliers, _, err := ctrl.repos.Suppliers.List(ctx, repository.Query{}.NotDeleted())
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing suppliers", err))
+			return
+		}
+		leadTimes := map[string]int{}
+		for _, supplier := range suppliers {
+			if supplier.Lead_time_days != nil {
+				leadTimes[supplier.Supplier_id] = *supplier.Lead_time_days
+			}
+		}
+		ingredients, _, err := ctrl.repos.Ingredients.List(ctx, repository.Query{
+			Sort: []repository.Sort{{Field: "name"}},
+		}.NotDeleted())
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing ingredients", err))
+			return
+		}
+
+		supplierId := c.Query("supplier_id")
+		for _, ingredient := range ingredients {
+			if ingredient.Par_level == nil {
+				continue
+			}
+			if supplierId != "" && (ingredient.Supplier_id == nil || *ingredient.Supplier_id != supplierId) {
+				continue
+			}
+			dailyUsage := usage[ingredient.Ingredient_id] / float64(days)
+			line := ReorderLine{
+				Ingredient_id:       ingredient.Ingredient_id,
+				Name:                ingredient.Name,
+				Unit:                ingredient.Unit,
+				Supplier_id:         ingredient.Supplier_id,
+				On_hand:             ingredient.On_hand,
+				On_order:            onOrder[ingredient.Ingredient_id],
+				Par_level:           *ingredient.Par_level,
+				Average_daily_usage: roundUp(dailyUsage),
+			}
+			if ingredient.Supplier_id != nil {
+				line.Lead_time_days = leadTimes[*ingredient.Supplier_id]
+			}
+			needed := line.Par_level + dailyUsage*float64(line.Lead_time_days)
+			line.Suggested_quantity = roundUp(needed - line.On_hand - line.On_order)
+			if line.Suggested_quantity > 0 {
+				report.Lines = append(report.Lines, line)
+			}
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// checkPurchaseLines checks that the ingredients of purchase order lines are
+// listed once and exist, and that their unit costs are in the default
+// currency and not negative. Received quantities are reset.
+func (ctrl *Controller) checkPurchaseLines(ctx context.Context, lines []models.PurchaseOrderLine) *apperrors.Error {
+	ingredientIds := []string{}
+	listed := map[string]bool{}
+	for i, line := range lines {
+		field := fmt.Sprintf("lines[%d]", i)
+		if listed[line.Ingredient_id] {
+			return apperrors.Invalid(field+".ingredient_id", "must be unique within the purchase order")
+		}
+		listed[line.Ingredient_id] = true
+		ingredientIds = append(ingredientIds, line.Ingredient_id)
+		lines[i].Received = 0
+		if line.Unit_cost != nil {
+			cost := models.NewMoney(line.Unit_cost.Amount, line.Unit_cost.Currency)
+			if cost.Currency != models.DefaultCurrency {
+				msg := fmt.Sprintf("must be in %s", models.DefaultCurrency)
+				return apperrors.Invalid(field+".unit_cost", msg)
+			}
+			if cost.Amount < 0 {
+				return apperrors.Invalid(field+".unit_cost", "cannot be negative")
+			}
+			lines[i].Unit_cost = &cost
+		}
+	}
+	ingredients, err := ctrl.repos.Ingredients.GetMany(ctx, ingredientIds)
+	if err != nil {
+		return apperrors.Internal("error occurred when fetching the ingredients", err)
+	}
+	for _, line := range lines {
+		if ingredient, ok := ingredients[line.Ingredient_id]; !ok || ingredient.Deleted_at != nil {
+			return apperrors.NotFound(fmt.Sprintf("ingredient %s was not found", line.Ingredient_id))
+		}
+	}
+	return nil
+}
+
+// receiveLines returns the lines of a purchase order with the receipt
+// added and what was received of each ingredient. No receipt lines receive
+// all that is still to be received.
+func receiveLines(lines []models.PurchaseOrderLine, receipt []ReceiptLine) ([]models.PurchaseOrderLine, []ReceiptLine, *apperrors.Error) {
+	updated := make([]models.PurchaseOrderLine, len(lines))
+	copy(updated, lines)
+	if len(receipt) == 0 {
+		for _, line := range lines {
+			if line.Outstanding() > 0 {
+				receipt = append(receipt, ReceiptLine{Ingredient_id: line.Ingredient_id, Quantity: line.Outstanding()})
+			}
+		}
+	}
+
+	received := map[string]bool{}
+	for i, delivery := range receipt {
+		field := fmt.Sprintf("lines[%d]", i)
+		if received[delivery.Ingredient_id] {
+			return nil, nil, apperrors.Invalid(field+".ingredient_id", "must be unique within the receipt")
+		}
+		received[delivery.Ingredient_id] = true
+		found := false
+		for j := range updated {
+			if updated[j].Ingredient_id != delivery.Ingredient_id {
+				continue
+			}
+			found = true
+			if delivery.Quantity > updated[j].Outstanding() {
+				msg := fmt.Sprintf("cannot exceed the %v still to be received", updated[j].Outstanding())
+				return nil, nil, apperrors.Invalid(field+".quantity", msg)
+			}
+			updated[j].Received += delivery.Quantity
+		}
+		if !found {
+			return nil, nil, apperrors.Invalid(field+".ingredient_id", "is not on the purchase order")
+		}
+	}
+	if len(receipt) == 0 {
+		return nil, nil, apperrors.Conflict("purchase order has nothing left to receive")
+	}
+	return updated, receipt, nil
+}
+
+// ingredientUsage adds up the ingredients the recipes of the items ordered
+// since from took, keyed by ingredient ID. Voided items are left out.
+func (ctrl *Controller) ingredientUsage(ctx context.Context, from time.Time) (map[string]float64, error) {
+	orderItems, _, err := ctrl.repos.OrderItems.List(ctx, repository.Query{}.
+		Where("created_at", repository.OpGte, from).
+		NotDeleted())
+	if err != nil {
+		return nil, err
+	}
+	foodIds := []string{}
+	for _, orderItem := range orderItems {
+		if orderItem.Food_id != nil {
+			foodIds = append(foodIds, *orderItem.Food_id)
+		}
+	}
+	foods, err := ctrl.repos.Foods.GetMany(ctx, foodIds)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := map[string]float64{}
+	for _, orderItem := range orderItems {
+		if orderItem.Food_id == nil || (orderItem.Status != nil && *orderItem.Status == models.ItemVoided) {
+			continue
+		}
+		portions := 1
+		if orderItem.Quantity != nil {
+			portions = *orderItem.Quantity
+		}
+		for _, line := range foods[*orderItem.Food_id].Recipe {
+			usage[line.Ingredient_id] += line.Quantity * float64(portions)
+		}
+	}
+	return usage, nil
+}
+
+// ingredientsOnOrder adds up what is still to be delivered of every
+// ingredient on open purchase orders, keyed by ingredient ID.
+func (ctrl *Controller) ingredientsOnOrder(ctx context.Context) (map[string]float64, error) {
+	purchaseOrders, _, err := ctrl.repos.PurchaseOrders.List(ctx, repository.Query{}.
+		Where("status", repository.OpIn, openPurchaseStatuses).
+		NotDeleted())
+	if err != nil {
+		return nil, err
+	}
+	onOrder := map[string]float64{}
+	for _, purchaseOrder := range purchaseOrders {
+		for _, line := range purchaseOrder.Lines {
+			onOrder[line.Ingredient_id] += line.Outstanding()
+		}
+	}
+	return onOrder, nil
+}
+
+// roundUp rounds a quantity up to two decimals, ignoring the float error
+// of sums such as 0.1 + 0.2.
+func roundUp(quantity float64) float64 {
+	return math.Ceil(quantity*100-1e-9) / 100
+}
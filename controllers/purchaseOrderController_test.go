@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+func TestReceiveLines(t *testing.T) {
+	lines := []models.PurchaseOrderLine{
+		{Ingredient_id: "flour", Quantity: 25, Received: 10},
+		{Ingredient_id: "eggs", Quantity: 60},
+		{Ingredient_id: "milk", Quantity: 12, Received: 12},
+	}
+	tests := []struct {
+		name         string
+		receipt      []ReceiptLine
+		wantReceived []float64
+		wantReceipt  []ReceiptLine
+	}{
+		{
+			"part of a line",
+			[]ReceiptLine{{Ingredient_id: "eggs", Quantity: 30}},
+			[]float64{10, 30, 12},
+			[]ReceiptLine{{Ingredient_id: "eggs", Quantity: 30}},
+		},
+		{
+			"the rest of lines",
+			[]ReceiptLine{{Ingredient_id: "flour", Quantity: 15}, {Ingredient_id: "eggs", Quantity: 60}},
+			[]float64{25, 60, 12},
+			[]ReceiptLine{{Ingredient_id: "flour", Quantity: 15}, {Ingredient_id: "eggs", Quantity: 60}},
+		},
+		{
+			"everything outstanding",
+			nil,
+			[]float64{25, 60, 12},
+			[]ReceiptLine{{Ingredient_id: "flour", Quantity: 15}, {Ingredient_id: "eggs", Quantity: 60}},
+		},
+	}
+	for _, tt := range tests {
+		updated, received, appErr := receiveLines(lines, tt.receipt)
+		if appErr != nil {
+			t.Errorf("%s: %v", tt.name, appErr)
+			continue
+		}
+		got := []float64{}
+		for _, line := range updated {
+			got = append(got, line.Received)
+		}
+		if !reflect.DeepEqual(got, tt.wantReceived) || !reflect.DeepEqual(received, tt.wantReceipt) {
+			t.Errorf("%s: received %v by %+v, want %v by %+v", tt.name, got, received, tt.wantReceived, tt.wantReceipt)
+		}
+	}
+	if lines[0].Received != 10 || lines[1].Received != 0 {
+		t.Errorf("the lines of the purchase order were changed to %+v", lines)
+	}
+}
+
+func TestReceiveLinesInvalid(t *testing.T) {
+	lines := []models.PurchaseOrderLine{
+		{Ingredient_id: "flour", Quantity: 25, Received: 10},
+		{Ingredient_id: "eggs", Quantity: 60},
+	}
+	tests := []struct {
+		name      string
+		receipt   []ReceiptLine
+		wantField string
+	}{
+		{"more than outstanding", []ReceiptLine{{Ingredient_id: "flour", Quantity: 15.5}}, "lines[0].quantity"},
+		{"listed twice", []ReceiptLine{{Ingredient_id: "eggs", Quantity: 10}, {Ingredient_id: "eggs", Quantity: 10}}, "lines[1].ingredient_id"},
+		{"not ordered", []ReceiptLine{{Ingredient_id: "eggs", Quantity: 10}, {Ingredient_id: "milk", Quantity: 1}}, "lines[1].ingredient_id"},
+	}
+	for _, tt := range tests {
+		updated, received, appErr := receiveLines(lines, tt.receipt)
+		if appErr == nil || appErr.Status() != http.StatusBadRequest || len(appErr.Fields) != 1 || appErr.Fields[0].Field != tt.wantField {
+			t.Errorf("%s: got %v, want an invalid %s", tt.name, appErr, tt.wantField)
+		}
+		if updated != nil || received != nil {
+			t.Errorf("%s: received %+v by %+v, want nothing", tt.name, updated, received)
+		}
+	}
+	if lines[1].Received != 0 {
+		t.Errorf("a refused receipt changed the lines to %+v", lines)
+	}
+
+	done := []models.PurchaseOrderLine{{Ingredient_id: "flour", Quantity: 25, Received: 25}}
+	if _, _, appErr := receiveLines(done, nil); appErr == nil || appErr.Status() != http.StatusConflict {
+		t.Errorf("receiving a fully received order = %v, want a 409", appErr)
+	}
+}
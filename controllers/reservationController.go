@@ -0,0 +1,519 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+const (
+	defaultReservationDuration = 90 * time.Minute
+	// defaultNoShowGrace is how late a party may be before it is a no-show.
+	defaultNoShowGrace = 15 * time.Minute
+)
+
+// reservationTransitions lists, for every reservation status, the statuses
+// it may move to.
+var reservationTransitions = map[string][]string{
+	models.ReservationBooked: {models.ReservationSeated, models.ReservationCancelled, models.ReservationNoShow},
+	models.ReservationSeated: {models.ReservationCompleted},
+}
+
+var reservationListParams = listParams{
+	filters: map[string]filterParam{
+		"from":     {"start_time", repository.OpGte, paramTime},
+		"to":       {"start_time", repository.OpLt, paramTime},
+		"status":   {"status", repository.OpIn, paramList},
+		"table_id": {"table_id", repository.OpEq, paramString},
+	},
+	sorts: map[string]string{
+		"start_time": "start_time", "party_size": "party_size", "created_at": "created_at",
+	},
+	defaultSort: "start_time",
+}
+
+// GetReservations responds with a page of reservations as JSON.
+// GetReservations             godoc
+//  @Summary      Get reservations
+//  @Description  Responds with the reservations starting between from and to (RFC 3339, without either: the next 24 hours), optionally only those with the given statuses or table, ordered by start time.
+//  @Tags         reservations
+//  @Produce      json
+//  @Param        limit     query  int     false  "page size, 20 by default, at most 100"
+//  @Param        offset    query  int     false  "number of items to skip"
+//  @Param        page      query  int     false  "1-based page, used when offset is not given"
+//  @Param        from      query  string  false  "start of the window"
+//  @Param        to        query  string  false  "end of the window"
+//  @Param        status    query  string  false  "comma separated reservation statuses"
+//  @Param        table_id  query  string  false  "only reservations of the table"
+//  @Param        sort      query  string  false  "start_time, party_size or created_at, prefixed with - for descending"
+//  @Success      200  {object}  controllers.ListResponse
+//  @Router       /reservations [get]
+func (ctrl *Controller) GetReservations() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		query, queryErr := reservationListParams.parse(c)
+		if queryErr != nil {
+			c.Error(queryErr)
+			return
+		}
+		if c.Query("from") == "" && c.Query("to") == "" {
+			query = query.
+				Where("start_time", repository.OpGte, time.Now()).
+				Where("start_time", repository.OpLt, time.Now().Add(24*time.Hour))
+		}
+
+		reservations, total, err := ctrl.repos.Reservations.List(ctx, query)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing reservations", err))
+			return
+		}
+		c.JSON(http.StatusOK, newListResponse(reservations, total, query))
+	}
+}
+
+// GetReservation responds with the reservation with provided ID as JSON.
+// GetReservation             godoc
+//  @Summary      Get single reservation by ID
+//  @Description  Responds with the reservation with provided ID as JSON.
+//  @Tags         reservations
+//  @Produce      json
+//  @Success      200  {object}  models.Reservation
+//  @Header       200  {string}  ETag  "version of the reservation"
+//  @Router       /reservations/{reservation_id} [get]
+func (ctrl *Controller) GetReservation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		reservation, ok := ctrl.findReservation(ctx, c)
+		if !ok {
+			return
+		}
+		setETag(c, reservation.Version)
+		c.JSON(http.StatusOK, reservation)
+	}
+}
+
+// GetAvailableTables responds with the tables free for a booking.
+// GetAvailableTables             godoc
+//  @Summary      Search available tables
+//  @Description  Responds with the tables fitting party_size guests (number_of_guests or max_capacity at least party_size, min_capacity at most) that have no reservation overlapping start (RFC 3339) to start + duration minutes (default 90), smallest table first.
+//  @Tags         reservations
+//  @Produce      json
+//  @Param        start       query  string  true   "start of the booking"
+//  @Param        duration    query  int     false  "length of the booking in minutes"
+//  @Param        party_size  query  int     true   "number of guests"
+//  @Success      200  {array}  models.Table
+//  @Router       /reservations/availability [get]
+func (ctrl *Controller) GetAvailableTables() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		start, err := time.Parse(time.RFC3339, c.Query("start"))
+		if err != nil {
+			c.Error(apperrors.Invalid("start", "must be an RFC 3339 time"))
+			return
+		}
+		partySize, err := strconv.Atoi(c.Query("party_size"))
+		if err != nil || partySize < 1 {
+			c.Error(apperrors.Invalid("party_size", "must be a positive number"))
+			return
+		}
+		duration := defaultReservationDuration
+		if minutes, err := strconv.Atoi(c.Query("duration")); err == nil && minutes > 0 {
+			duration = time.Duration(minutes) * time.Minute
+		}
+
+		tables, err := ctrl.repos.Tables.Available(ctx, partySize, start, start.Add(duration))
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while searching available tables", err))
+			return
+		}
+		c.JSON(http.StatusOK, tables)
+	}
+}
+
+// CreateReservation takes a reservation JSON and books a table for it.
+// CreateReservation             godoc
+//  @Summary      Book a table
+//  @Description  Takes a reservation JSON and books the requested table, or the smallest free table large enough when no table_id is given. A table already booked in an overlapping window is refused with 409.
+//  @Tags         reservations
+//  @Produce      json
+//  @Success      200  {object}  models.Reservation
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /reservations [post]
+func (ctrl *Controller) CreateReservation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var reservation models.Reservation
+
+		if err := c.ShouldBindJSON(&reservation); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+		if validationErr := validate.Struct(reservation); validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+		if reservation.Start_time.Before(time.Now()) {
+			c.Error(apperrors.Invalid("start_time", "cannot be in the past"))
+			return
+		}
+
+		if reservation.Duration_minutes == nil {
+			minutes := int(defaultReservationDuration / time.Minute)
+			reservation.Duration_minutes = &minutes
+		}
+		reservation.End_time = reservation.Start_time.Add(time.Duration(*reservation.Duration_minutes) * time.Minute)
+		reservation.ID = primitive.NewObjectID()
+		reservation.Reservation_id = reservation.ID.Hex()
+		reservation.Version = 1
+		status := models.ReservationBooked
+		reservation.Status = &status
+		reservation.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		reservation.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		tableId, bookErr := ctrl.bookTable(ctx, reservation, reservation.Table_id)
+		if bookErr != nil {
+			c.Error(bookErr)
+			return
+		}
+		reservation.Table_id = &tableId
+
+		if err := ctrl.repos.Reservations.Create(ctx, reservation); err != nil {
+			ctrl.releaseSlot(ctx, tableId, reservation.Reservation_id)
+			c.Error(apperrors.Internal("Failed to create reservation", err))
+			return
+		}
+		ctrl.audit(c, models.AuditCreate, "reservation", reservation.Reservation_id, nil, reservation)
+		c.JSON(http.StatusOK, reservation)
+	}
+}
+
+// UpdateReservation takes a reservation JSON and changes the booking.
+// UpdateReservation             godoc
+//  @Summary      Modify a reservation
+//  @Description  Takes a reservation JSON and updates the guest, party size, time, duration or table of a BOOKED reservation. A new time or table is booked before the old one is released, a conflict is refused with 409 and leaves the reservation unchanged.
+//  @Tags         reservations
+//  @Produce      json
+//  @Param        If-Match  header  string  false  "ETag of the reservation as read, the update is refused with 409 when it changed since"
+//  @Success      200  {object}  models.Reservation
+//  @Header       200  {string}  ETag  "version of the reservation"
+//  @Failure      404  {object}  map[string]interface{}
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /reservations/{reservation_id} [patch]
+func (ctrl *Controller) UpdateReservation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var changes models.Reservation
+
+		if err := c.ShouldBindJSON(&changes); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+
+		version, matchErr := ifMatch(c)
+		if matchErr != nil {
+			c.Error(matchErr)
+			return
+		}
+
+		reservation, ok := ctrl.findReservation(ctx, c)
+		if !ok {
+			return
+		}
+		if version != nil && *version != reservation.Version {
+			c.Error(updateError("reservation", repository.ErrConflict))
+			return
+		}
+		if *reservation.Status != models.ReservationBooked {
+			msg := fmt.Sprintf("a %s reservation cannot be modified", *reservation.Status)
+			c.Error(apperrors.Conflict(msg))
+			return
+		}
+
+		updated := reservation
+		if changes.Guest_name != nil {
+			updated.Guest_name = changes.Guest_name
+		}
+		if changes.Phone != nil {
+			updated.Phone = changes.Phone
+		}
+		if changes.Party_size != nil {
+			updated.Party_size = changes.Party_size
+		}
+		if changes.Start_time != nil {
+			updated.Start_time = changes.Start_time
+		}
+		if changes.Duration_minutes != nil {
+			updated.Duration_minutes = changes.Duration_minutes
+		}
+		if validationErr := validate.Struct(updated); validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+		updated.End_time = updated.Start_time.Add(time.Duration(*updated.Duration_minutes) * time.Minute)
+
+		rebook := changes.Start_time != nil || changes.Duration_minutes != nil ||
+			changes.Party_size != nil || changes.Table_id != nil
+		if rebook {
+			if changes.Start_time != nil && changes.Start_time.Before(time.Now()) {
+				c.Error(apperrors.Invalid("start_time", "cannot be in the past"))
+				return
+			}
+			requested := reservation.Table_id
+			if changes.Table_id != nil {
+				requested = changes.Table_id
+			}
+			tableId, bookErr := ctrl.bookTable(ctx, updated, requested)
+			if bookErr != nil {
+				c.Error(bookErr)
+				return
+			}
+			updated.Table_id = &tableId
+		}
+
+		updated.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		stored, err := ctrl.repos.Reservations.Update(ctx, version, updated)
+		if err != nil {
+			// the old table is only given back once the change is stored; on
+			// the same table the slot was moved and is moved back
+			if *updated.Table_id != *reservation.Table_id {
+				ctrl.releaseSlot(ctx, *updated.Table_id, reservation.Reservation_id)
+			} else if rebook {
+				ctrl.restoreSlot(ctx, reservation)
+			}
+			c.Error(updateError("reservation", err))
+			return
+		}
+		if *stored.Table_id != *reservation.Table_id {
+			ctrl.releaseSlot(ctx, *reservation.Table_id, reservation.Reservation_id)
+		}
+		ctrl.audit(c, models.AuditUpdate, "reservation", stored.Reservation_id, reservation, stored)
+		setETag(c, stored.Version)
+		c.JSON(http.StatusOK, stored)
+	}
+}
+
+// ChangeReservationStatus returns a handler moving a reservation to status,
+// used for seating, completing, cancelling and no-shows. Every status but
+// SEATED gives the table back.
+// ChangeReservationStatus             godoc
+//  @Summary      Seat, complete, cancel or mark a reservation as no-show
+//  @Description  Moves the reservation to SEATED, COMPLETED, CANCELLED or NO_SHOW. Cancelled, completed and no-show reservations release their table.
+//  @Tags         reservations
+//  @Produce      json
+//  @Success      200  {object}  models.Reservation
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /reservations/{reservation_id}/seat [post]
+//  @Router       /reservations/{reservation_id}/complete [post]
+//  @Router       /reservations/{reservation_id}/cancel [post]
+//  @Router       /reservations/{reservation_id}/no-show [post]
+func (ctrl *Controller) ChangeReservationStatus(status string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		reservation, ok := ctrl.findReservation(ctx, c)
+		if !ok {
+			return
+		}
+		if !canTransitionReservation(*reservation.Status, status) {
+			msg := fmt.Sprintf("reservation cannot move from %s to %s", *reservation.Status, status)
+			c.Error(apperrors.Conflict(msg))
+			return
+		}
+
+		updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		err := ctrl.repos.Reservations.SetStatus(ctx, reservation.Reservation_id, *reservation.Status, status, updated_at)
+		if err == repository.ErrConflict {
+			c.Error(apperrors.Conflict("reservation was changed by someone else, please retry"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("Failed to update the reservation", err))
+			return
+		}
+		if status == models.ReservationSeated && reservation.Table_id != nil {
+			ctrl.setTableStatus(ctx, *reservation.Table_id, models.TableSeated)
+		} else if reservation.Table_id != nil {
+			ctrl.releaseSlot(ctx, *reservation.Table_id, reservation.Reservation_id)
+		}
+
+		before := reservation
+		reservation.Status = &status
+		reservation.Updated_at = updated_at
+		ctrl.audit(c, models.AuditUpdate, "reservation", reservation.Reservation_id, before, reservation)
+		c.JSON(http.StatusOK, reservation)
+	}
+}
+
+// MarkNoShows flags every booked party that is later than the grace period.
+// MarkNoShows             godoc
+//  @Summary      Mark late reservations as no-shows
+//  @Description  Moves every BOOKED reservation that started more than grace minutes ago (default 15) to NO_SHOW and releases its table. Responds with the reservations marked.
+//  @Tags         reservations
+//  @Produce      json
+//  @Param        grace  query  int  false  "minutes a party may be late"
+//  @Success      200  {array}  models.Reservation
+//  @Router       /reservations/no-shows [post]
+func (ctrl *Controller) MarkNoShows() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		grace := defaultNoShowGrace
+		if minutes, err := strconv.Atoi(c.Query("grace")); err == nil && minutes >= 0 {
+			grace = time.Duration(minutes) * time.Minute
+		}
+
+		late, _, err := ctrl.repos.Reservations.List(ctx, repository.Query{}.
+			Where("start_time", repository.OpLt, time.Now().Add(-grace)).
+			Where("status", repository.OpEq, models.ReservationBooked))
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing reservations", err))
+			return
+		}
+
+		updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		marked := []models.Reservation{}
+		for _, reservation := range late {
+			err := ctrl.repos.Reservations.SetStatus(
+				ctx, reservation.Reservation_id, models.ReservationBooked, models.ReservationNoShow, updated_at,
+			)
+			if err != nil {
+				continue
+			}
+			if reservation.Table_id != nil {
+				ctrl.releaseSlot(ctx, *reservation.Table_id, reservation.Reservation_id)
+			}
+			before := reservation
+			status := models.ReservationNoShow
+			reservation.Status = &status
+			reservation.Updated_at = updated_at
+			ctrl.audit(c, models.AuditUpdate, "reservation", reservation.Reservation_id, before, reservation)
+			marked = append(marked, reservation)
+		}
+		c.JSON(http.StatusOK, marked)
+	}
+}
+
+func canTransitionReservation(from, to string) bool {
+	for _, next := range reservationTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// findReservation loads the reservation named in the URL and writes the
+// error response itself when it cannot.
+func (ctrl *Controller) findReservation(ctx context.Context, c *gin.Context) (models.Reservation, bool) {
+	reservation, err := ctrl.repos.Reservations.Get(ctx, c.Param("reservation_id"))
+	if err == repository.ErrNotFound {
+		c.Error(apperrors.NotFound("reservation was not found"))
+		return reservation, false
+	} else if err != nil {
+		c.Error(apperrors.Internal("error occurred when fetching the reservation", err))
+		return reservation, false
+	}
+	return reservation, true
+}
+
+// seats tells whether the table fits a party of partySize.
+func seats(table models.Table, partySize int) bool {
+	capacity := 0
+	if table.Number_of_guests != nil {
+		capacity = *table.Number_of_guests
+	}
+	if table.Max_capacity != nil && *table.Max_capacity > capacity {
+		capacity = *table.Max_capacity
+	}
+	if table.Min_capacity != nil && partySize < *table.Min_capacity {
+		return false
+	}
+	return partySize <= capacity
+}
+
+// bookTable holds a table for the reservation's window. With a table ID
+// only that table is tried, otherwise the smallest free table that seats the
+// party. It returns the booked table, or why none could be booked.
+func (ctrl *Controller) bookTable(ctx context.Context, reservation models.Reservation, tableId *string) (string, *apperrors.Error) {
+	start, end := *reservation.Start_time, reservation.End_time
+	slot := models.TableSlot{Reservation_id: reservation.Reservation_id, Start_time: start, End_time: end}
+
+	if tableId != nil {
+		table, err := ctrl.repos.Tables.Get(ctx, *tableId)
+		if err == nil && table.Deleted_at != nil {
+			err = repository.ErrNotFound
+		}
+		if err == repository.ErrNotFound {
+			return "", apperrors.NotFound("table was not found")
+		} else if err != nil {
+			return "", apperrors.Internal("error occurred when fetching the table", err)
+		}
+		if !seats(table, *reservation.Party_size) {
+			return "", apperrors.Invalid("party_size", "does not fit the table")
+		}
+		claimed, err := ctrl.repos.Tables.ClaimSlot(ctx, *tableId, slot)
+		if err != nil {
+			return "", apperrors.Internal("Failed to book the table", err)
+		}
+		if !claimed {
+			return "", apperrors.Conflict("table is already booked at that time")
+		}
+		return *tableId, nil
+	}
+
+	tables, err := ctrl.repos.Tables.Available(ctx, *reservation.Party_size, start, end)
+	if err != nil {
+		return "", apperrors.Internal("error occurred while searching available tables", err)
+	}
+	// another booking may take a candidate between the search and the claim,
+	// in which case the next one is tried
+	for _, table := range tables {
+		claimed, err := ctrl.repos.Tables.ClaimSlot(ctx, table.Table_id, slot)
+		if err != nil {
+			return "", apperrors.Internal("Failed to book the table", err)
+		}
+		if claimed {
+			return table.Table_id, nil
+		}
+	}
+	return "", apperrors.Conflict("no table is available at that time")
+}
+
+// restoreSlot moves the slot of the reservation on its table back to the
+// reservation's window, after a change of its time could not be stored.
+func (ctrl *Controller) restoreSlot(ctx context.Context, reservation models.Reservation) {
+	slot := models.TableSlot{
+		Reservation_id: reservation.Reservation_id,
+		Start_time:     *reservation.Start_time,
+		End_time:       reservation.End_time,
+	}
+	claimed, err := ctrl.repos.Tables.ClaimSlot(ctx, *reservation.Table_id, slot)
+	if err != nil || !claimed {
+		log.Printf("failed to move the slot of reservation %s back on table %s: claimed %v, %v",
+			reservation.Reservation_id, *reservation.Table_id, claimed, err)
+	}
+}
+
+// releaseSlot gives the table back for the reservation's window.
+func (ctrl *Controller) releaseSlot(ctx context.Context, tableId, reservationId string) {
+	ctrl.repos.Tables.ReleaseSlot(ctx, tableId, reservationId)
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+// maxSearchLength bounds the searched text, longer input is not typed in a
+// search box.
+const maxSearchLength = 100
+
+type FoodResult struct {
+	models.Food
+	Score float64 `json:"score"`
+}
+
+type MenuResult struct {
+	models.Menu
+	Score float64 `json:"score"`
+}
+
+// SearchResponse holds the foods and the menus matching a search, the most
+// relevant first.
+type SearchResponse struct {
+	Query string       `json:"query"`
+	Foods []FoodResult `json:"foods"`
+	Menus []MenuResult `json:"menus"`
+}
+
+// Search responds with the foods and menus matching the searched text.
+// Search             godoc
+//  @Summary      Search the catalog
+//  @Description  Responds with the foods and menus matching every word of q, ranked by relevance. Foods are matched by name, description and the name and category of their menu, menus by name and category. Words being typed match by their start, and words of 4 letters or more tolerate a typo after their first two letters, two from 8 letters.
+//  @Tags         search
+//  @Produce      json
+//  @Param        q          query  string  true   "searched text"
+//  @Param        menu_id    query  string  false  "only that menu and its foods"
+//  @Param        available  query  bool    false  "only foods that are, or are not, available"
+//  @Param        limit      query  int     false  "foods and menus returned, each, 20 by default, at most 100"
+//  @Success      200  {object}  controllers.SearchResponse
+//  @Router       /search [get]
+func (ctrl *Controller) Search() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		query := repository.SearchQuery{
+			Text:    strings.TrimSpace(c.Query("q")),
+			Menu_id: c.Query("menu_id"),
+			Limit:   defaultListLimit,
+		}
+		if query.Text == "" {
+			c.Error(apperrors.Required("q"))
+			return
+		}
+		if len([]rune(query.Text)) > maxSearchLength {
+			c.Error(apperrors.Invalid("q", fmt.Sprintf("must be at most %d characters long", maxSearchLength)))
+			return
+		}
+		if value := c.Query("available"); value != "" {
+			available, err := strconv.ParseBool(value)
+			if err != nil {
+				c.Error(apperrors.Invalid("available", "must be true or false"))
+				return
+			}
+			query.Available = &available
+		}
+		if value := c.Query("limit"); value != "" {
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxListLimit {
+				c.Error(apperrors.Invalid("limit", fmt.Sprintf("must be a number between 1 and %d", maxListLimit)))
+				return
+			}
+			query.Limit = limit
+		}
+
+		result, err := ctrl.repos.Search.Search(ctx, query)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while searching", err))
+			return
+		}
+		response := SearchResponse{
+			Query: query.Text,
+			Foods: make([]FoodResult, 0, len(result.Foods)),
+			Menus: make([]MenuResult, 0, len(result.Menus)),
+		}
+		for _, hit := range result.Foods {
+			response.Foods = append(response.Foods, FoodResult{hit.Food, hit.Score})
+		}
+		for _, hit := range result.Menus {
+			response.Menus = append(response.Menus, MenuResult{hit.Menu, hit.Score})
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+// takeStock takes the ingredients of the recipe of the item's food from the
+// stock when the kitchen fires it, and returns the usage movements to
+// record. When a required ingredient is short the stock taken so far is put
+// back and a 409 is returned; a short optional ingredient is left out.
+// Ingredients that are missing or deleted are not tracked. Every ingredient
+// is taken by a conditional decrement that fails rather than going below
+// zero, the stock read beforehand is never relied on.
+func (ctrl *Controller) takeStock(ctx context.Context, c *gin.Context, orderItem models.OrderItem, at time.Time) ([]models.StockMovement, *apperrors.Error) {
+	if orderItem.Food_id == nil {
+		return nil, nil
+	}
+	food, err := ctrl.repos.Foods.Get(ctx, *orderItem.Food_id)
+	if err == repository.ErrNotFound || len(food.Recipe) == 0 {
+		return nil, nil
+	} else if err != nil {
+		return nil, apperrors.Internal("error occurred when fetching the food item", err)
+	}
+	ingredients, err := ctrl.repos.Ingredients.GetMany(ctx, recipeIngredientIds(food.Recipe))
+	if err != nil {
+		return nil, apperrors.Internal("error occurred when fetching the ingredients", err)
+	}
+	portions := 1
+	if orderItem.Quantity != nil {
+		portions = *orderItem.Quantity
+	}
+
+	movements := []models.StockMovement{}
+	for _, line := range food.Recipe {
+		ingredient, ok := ingredients[line.Ingredient_id]
+		if !ok || ingredient.Deleted_at != nil {
+			continue
+		}
+		needed := line.Quantity * float64(portions)
+		updated, err := ctrl.repos.Ingredients.Adjust(ctx, line.Ingredient_id, -needed, at)
+		if err == repository.ErrNotFound || (err == repository.ErrConflict && line.Optional) {
+			continue
+		} else if err == repository.ErrConflict {
+			ctrl.undoStock(ctx, movements, at)
+			msg := fmt.Sprintf("not enough %s on hand to fire %s", ingredient.Name, foodName(food))
+			return nil, apperrors.Conflict(msg)
+		} else if err != nil {
+			ctrl.undoStock(ctx, movements, at)
+			return nil, apperrors.Internal("error occurred while taking the stock", err)
+		}
+		movement := newStockMovement(c, updated, models.StockUsage, -needed, "")
+		movement.Order_item_id = &orderItem.Order_item_id
+		movements = append(movements, movement)
+	}
+	return movements, nil
+}
+
+// newStockMovement returns a movement of the stock of the ingredient made
+// by the user of the request, quantity being the change of the stock.
+func newStockMovement(c *gin.Context, ingredient models.Ingredient, kind string, quantity float64, reason string) models.StockMovement {
+	movement := models.StockMovement{
+		ID:            primitive.NewObjectID(),
+		Ingredient_id: ingredient.Ingredient_id,
+		Kind:          kind,
+		Quantity:      quantity,
+		On_hand:       ingredient.On_hand,
+		Reason:        reason,
+		Created_by:    c.GetString("uid"),
+		Created_at:    ingredient.Updated_at,
+	}
+	movement.Movement_id = movement.ID.Hex()
+	return movement
+}
+
+// undoStock puts back the stock taken by movements that are not going to
+// be recorded. Failures are only logged.
+func (ctrl *Controller) undoStock(ctx context.Context, movements []models.StockMovement, at time.Time) {
+	for _, movement := range movements {
+		if _, err := ctrl.repos.Ingredients.Adjust(ctx, movement.Ingredient_id, -movement.Quantity, at); err != nil {
+			log.Printf("failed to put back %v of ingredient %s: %v", -movement.Quantity, movement.Ingredient_id, err)
+		}
+	}
+}
+
+// recordStock appends movements to the stock log and updates the 86'd
+// flags of the foods using their ingredients. Failures are only logged, the
+// stock has already changed.
+func (ctrl *Controller) recordStock(ctx context.Context, movements []models.StockMovement, at time.Time) {
+	if len(movements) == 0 {
+		return
+	}
+	if err := ctrl.repos.StockMovements.Append(ctx, movements...); err != nil {
+		log.Printf("failed to record %d stock movements: %v", len(movements), err)
+	}
+	ingredientIds := []string{}
+	for _, movement := range movements {
+		ingredientIds = append(ingredientIds, movement.Ingredient_id)
+	}
+	ctrl.refreshOutOfStock(ctx, ingredientIds, at)
+}
+
+// refreshOutOfStock updates the 86'd flags of the foods that use any of the
+// ingredients after their stock changed. Failures are only logged.
+func (ctrl *Controller) refreshOutOfStock(ctx context.Context, ingredientIds []string, at time.Time) {
+	foods := map[string]models.Food{}
+	for _, ingredientId := range ingredientIds {
+		using, err := ctrl.repos.Foods.ListByIngredient(ctx, ingredientId)
+		if err != nil {
+			log.Printf("failed to list the foods using ingredient %s: %v", ingredientId, err)
+			return
+		}
+		for _, food := range using {
+			foods[food.Food_id] = food
+		}
+	}
+	if len(foods) == 0 {
+		return
+	}
+
+	recipeIds := []string{}
+	for _, food := range foods {
+		recipeIds = append(recipeIds, recipeIngredientIds(food.Recipe)...)
+	}
+	ingredients, err := ctrl.repos.Ingredients.GetMany(ctx, recipeIds)
+	if err != nil {
+		log.Printf("failed to fetch the ingredients of %d foods: %v", len(foods), err)
+		return
+	}
+	for _, food := range foods {
+		outOfStock := recipeOutOfStock(food.Recipe, ingredients)
+		if outOfStock == food.Out_of_stock {
+			continue
+		}
+		if err := ctrl.repos.Foods.SetOutOfStock(ctx, food.Food_id, outOfStock, at); err != nil {
+			log.Printf("failed to set the stock of food %s: %v", food.Food_id, err)
+		}
+	}
+}
+
+// checkRecipe checks that the ingredients of a recipe are listed once and
+// exist, and reports whether the food is out of stock with it.
+func (ctrl *Controller) checkRecipe(ctx context.Context, recipe []models.RecipeIngredient) (bool, *apperrors.Error) {
+	listed := map[string]bool{}
+	for i, line := range recipe {
+		if listed[line.Ingredient_id] {
+			field := fmt.Sprintf("recipe[%d].ingredient_id", i)
+			return false, apperrors.Invalid(field, "must be unique within the recipe")
+		}
+		listed[line.Ingredient_id] = true
+	}
+	if len(recipe) == 0 {
+		return false, nil
+	}
+	ingredients, err := ctrl.repos.Ingredients.GetMany(ctx, recipeIngredientIds(recipe))
+	if err != nil {
+		return false, apperrors.Internal("error occurred when fetching the ingredients", err)
+	}
+	for _, line := range recipe {
+		if ingredient, ok := ingredients[line.Ingredient_id]; !ok || ingredient.Deleted_at != nil {
+			return false, apperrors.NotFound(fmt.Sprintf("ingredient %s was not found", line.Ingredient_id))
+		}
+	}
+	return recipeOutOfStock(recipe, ingredients), nil
+}
+
+// recipeOutOfStock reports whether a required ingredient of the recipe is
+// short of one portion. Missing and deleted ingredients are not tracked.
+func recipeOutOfStock(recipe []models.RecipeIngredient, ingredients map[string]models.Ingredient) bool {
+	for _, line := range recipe {
+		ingredient, ok := ingredients[line.Ingredient_id]
+		if !ok || ingredient.Deleted_at != nil || line.Optional {
+			continue
+		}
+		if ingredient.On_hand < line.Quantity {
+			return true
+		}
+	}
+	return false
+}
+
+func recipeIngredientIds(recipe []models.RecipeIngredient) []string {
+	ids := []string{}
+	for _, line := range recipe {
+		ids = append(ids, line.Ingredient_id)
+	}
+	return ids
+}
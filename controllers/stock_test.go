@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+// newStockKitchen returns a controller on an in-memory store holding a
+// burger whose recipe takes a bun and a patty, optionally a slice of
+// cheese, and a deleted and a missing ingredient that are not tracked.
+func newStockKitchen(t *testing.T) (*Controller, *gin.Context) {
+	t.Helper()
+	ctx := context.Background()
+	repos := repository.NewMemory()
+	deleted := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, ingredient := range []models.Ingredient{
+		{Ingredient_id: "bun", Name: "Bun", Unit: models.UnitPiece, On_hand: 10},
+		{Ingredient_id: "patty", Name: "Patty", Unit: models.UnitPiece, On_hand: 5},
+		{Ingredient_id: "cheese", Name: "Cheese", Unit: models.UnitPiece, On_hand: 1},
+		{Ingredient_id: "sauce", Name: "Sauce", Unit: models.UnitMilliliter, Deleted_at: &deleted},
+	} {
+		if err := repos.Ingredients.Create(ctx, ingredient); err != nil {
+			t.Fatal(err)
+		}
+	}
+	name := "Burger"
+	burger := models.Food{Food_id: "burger", Name: &name, Recipe: []models.RecipeIngredient{
+		{Ingredient_id: "bun", Quantity: 1},
+		{Ingredient_id: "patty", Quantity: 1},
+		{Ingredient_id: "cheese", Quantity: 1, Optional: true},
+		{Ingredient_id: "sauce", Quantity: 20},
+		{Ingredient_id: "gone", Quantity: 1},
+	}}
+	if err := repos.Foods.Create(ctx, burger); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Foods.Create(ctx, models.Food{Food_id: "water"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("uid", "chef")
+	return &Controller{repos: repos}, c
+}
+
+func orderedItem(foodId string, quantity int) models.OrderItem {
+	return models.OrderItem{Order_item_id: "item-" + foodId, Food_id: &foodId, Quantity: &quantity}
+}
+
+func onHand(t *testing.T, ctrl *Controller, ingredientId string) float64 {
+	t.Helper()
+	ingredient, err := ctrl.repos.Ingredients.Get(context.Background(), ingredientId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ingredient.On_hand
+}
+
+func TestTakeStock(t *testing.T) {
+	ctrl, c := newStockKitchen(t)
+	at := time.Date(2024, 5, 10, 19, 0, 0, 0, time.UTC)
+
+	// two burgers: the one slice of cheese is short and left out
+	movements, appErr := ctrl.takeStock(context.Background(), c, orderedItem("burger", 2), at)
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	want := []struct {
+		ingredientId     string
+		quantity, onHand float64
+	}{{"bun", -2, 8}, {"patty", -2, 3}}
+	if len(movements) != len(want) {
+		t.Fatalf("took %d movements %+v, want %d", len(movements), movements, len(want))
+	}
+	for i, movement := range movements {
+		if movement.Ingredient_id != want[i].ingredientId || movement.Quantity != want[i].quantity || movement.On_hand != want[i].onHand {
+			t.Errorf("movement %d took %v of %s leaving %v, want %v of %s leaving %v", i,
+				movement.Quantity, movement.Ingredient_id, movement.On_hand,
+				want[i].quantity, want[i].ingredientId, want[i].onHand)
+		}
+		if movement.Kind != models.StockUsage || movement.Created_by != "chef" || !movement.Created_at.Equal(at) ||
+			movement.Order_item_id == nil || *movement.Order_item_id != "item-burger" || movement.Movement_id == "" {
+			t.Errorf("movement %d is %+v, want a usage of item-burger by chef at %v", i, movement, at)
+		}
+	}
+	if got := onHand(t, ctrl, "cheese"); got != 1 {
+		t.Errorf("cheese on hand is %v, want 1 left alone", got)
+	}
+
+	// four more burgers: the patties are short, the buns are put back
+	movements, appErr = ctrl.takeStock(context.Background(), c, orderedItem("burger", 4), at)
+	if appErr == nil || appErr.Status() != http.StatusConflict || movements != nil {
+		t.Fatalf("firing 4 burgers with 3 patties = %v, %v, want a 409", movements, appErr)
+	}
+	if appErr.Message != "not enough Patty on hand to fire Burger" {
+		t.Errorf("short patties are reported as %q", appErr.Message)
+	}
+	if bun, patty := onHand(t, ctrl, "bun"), onHand(t, ctrl, "patty"); bun != 8 || patty != 3 {
+		t.Errorf("after the refused fire %v buns and %v patties are on hand, want 8 and 3", bun, patty)
+	}
+}
+
+func TestTakeStockUntracked(t *testing.T) {
+	ctrl, c := newStockKitchen(t)
+	for name, orderItem := range map[string]models.OrderItem{
+		"no food":      {Order_item_id: "item"},
+		"no recipe":    orderedItem("water", 1),
+		"missing food": orderedItem("gone", 1),
+	} {
+		movements, appErr := ctrl.takeStock(context.Background(), c, orderItem, time.Now())
+		if movements != nil || appErr != nil {
+			t.Errorf("%s: took %+v, %v, want nothing", name, movements, appErr)
+		}
+	}
+	// without a quantity one portion is taken
+	movements, appErr := ctrl.takeStock(context.Background(), c, models.OrderItem{Food_id: orderedItem("burger", 0).Food_id}, time.Now())
+	if appErr != nil || len(movements) != 3 || movements[0].Quantity != -1 {
+		t.Errorf("an item without quantity took %+v, %v, want one portion", movements, appErr)
+	}
+}
+
+func TestUndoStock(t *testing.T) {
+	ctrl, c := newStockKitchen(t)
+	movements, appErr := ctrl.takeStock(context.Background(), c, orderedItem("burger", 3), time.Now())
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	ctrl.undoStock(context.Background(), movements, time.Now())
+	for ingredientId, want := range map[string]float64{"bun": 10, "patty": 5, "cheese": 1} {
+		if got := onHand(t, ctrl, ingredientId); got != want {
+			t.Errorf("%s on hand after the undo is %v, want %v", ingredientId, got, want)
+		}
+	}
+}
+
+func TestRecordStock(t *testing.T) {
+	ctrl, c := newStockKitchen(t)
+	ctx := context.Background()
+	movements, appErr := ctrl.takeStock(ctx, c, orderedItem("burger", 5), time.Now())
+	if appErr != nil {
+		t.Fatal(appErr)
+	}
+	ctrl.recordStock(ctx, movements, time.Now())
+
+	logged, total, err := ctrl.repos.StockMovements.List(ctx, repository.Query{})
+	if err != nil || total != int64(len(movements)) || len(logged) != len(movements) {
+		t.Errorf("the log holds %d movements, %v, want %d", total, err, len(movements))
+	}
+	burger, err := ctrl.repos.Foods.Get(ctx, "burger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !burger.Out_of_stock {
+		t.Error("the burger is not 86'd without patties")
+	}
+
+	ctrl.undoStock(ctx, movements, time.Now())
+	ctrl.refreshOutOfStock(ctx, []string{"patty"}, time.Now())
+	if burger, _ = ctrl.repos.Foods.Get(ctx, "burger"); burger.Out_of_stock {
+		t.Error("the burger is still 86'd with the patties back")
+	}
+}
+
+func TestRecipeOutOfStock(t *testing.T) {
+	deleted := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	ingredients := map[string]models.Ingredient{
+		"flour": {Ingredient_id: "flour", On_hand: 500},
+		"basil": {Ingredient_id: "basil", On_hand: 2},
+		"old":   {Ingredient_id: "old", Deleted_at: &deleted},
+	}
+	tests := []struct {
+		name   string
+		recipe []models.RecipeIngredient
+		want   bool
+	}{
+		{"enough", []models.RecipeIngredient{{Ingredient_id: "flour", Quantity: 500}}, false},
+		{"short", []models.RecipeIngredient{{Ingredient_id: "flour", Quantity: 200}, {Ingredient_id: "basil", Quantity: 5}}, true},
+		{"short optional", []models.RecipeIngredient{{Ingredient_id: "basil", Quantity: 5, Optional: true}}, false},
+		{"deleted", []models.RecipeIngredient{{Ingredient_id: "old", Quantity: 1}}, false},
+		{"missing", []models.RecipeIngredient{{Ingredient_id: "gone", Quantity: 1}}, false},
+		{"no recipe", nil, false},
+	}
+	for _, tt := range tests {
+		if got := recipeOutOfStock(tt.recipe, ingredients); got != tt.want {
+			t.Errorf("%s: recipeOutOfStock = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
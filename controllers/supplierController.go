@@ -0,0 +1,276 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+var supplierListParams = listParams{
+	filters: map[string]filterParam{
+		"name": {"name", repository.OpEq, paramString},
+	},
+	sorts: map[string]string{
+		"name": "name", "created_at": "created_at", "updated_at": "updated_at",
+	},
+	defaultSort: "name",
+	softDeleted: true,
+}
+
+// openPurchaseStatuses are the statuses of the purchase orders still
+// expected to be delivered.
+var openPurchaseStatuses = []string{models.PurchaseOrdered, models.PurchasePartial}
+
+// GetSuppliers responds with a page of suppliers as JSON.
+// GetSuppliers             godoc
+//  @Summary      Get all suppliers
+//  @Description  Responds with a page of suppliers, filtered by name.
+//  @Tags         suppliers
+//  @Produce      json
+//  @Param        limit   query  int     false  "page size, 20 by default, at most 100"
+//  @Param        offset  query  int     false  "number of items to skip"
+//  @Param        page    query  int     false  "1-based page, used when offset is not given"
+//  @Param        name    query  string  false  "only suppliers with this name"
+//  @Param        sort    query  string  false  "name, created_at or updated_at, prefixed with - for descending"
+//  @Param        include_deleted  query  bool  false  "also list deleted records, admins only"
+//  @Success      200  {object}  controllers.ListResponse
+//  @Router       /suppliers [get]
+func (ctrl *Controller) GetSuppliers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		query, queryErr := supplierListParams.parse(c)
+		if queryErr != nil {
+			c.Error(queryErr)
+			return
+		}
+		suppliers, total, err := ctrl.repos.Suppliers.List(ctx, query)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing suppliers", err))
+			return
+		}
+		c.JSON(http.StatusOK, newListResponse(suppliers, total, query))
+	}
+}
+
+// GetSupplier responds with the supplier with provided ID as JSON.
+// GetSupplier             godoc
+//  @Summary      Get single supplier by ID
+//  @Description  Responds with the supplier with provided ID as JSON.
+//  @Tags         suppliers
+//  @Produce      json
+//  @Success      200  {object}  models.Supplier
+//  @Header       200  {string}  ETag  "version of the supplier"
+//  @Router       /suppliers/{supplier_id} [get]
+func (ctrl *Controller) GetSupplier() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		supplier, err := ctrl.repos.Suppliers.Get(ctx, c.Param("supplier_id"))
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("supplier was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the supplier", err))
+			return
+		}
+		setETag(c, supplier.Version)
+		c.JSON(http.StatusOK, supplier)
+	}
+}
+
+// CreateSupplier takes a supplier JSON and store in DB.
+// CreateSupplier             godoc
+//  @Summary      Store a new supplier
+//  @Description  Takes a supplier JSON and store in DB. Return saved JSON.
+//  @Tags         suppliers
+//  @Produce      json
+//  @Success      200  {object}  models.Supplier
+//  @Router       /suppliers [post]
+func (ctrl *Controller) CreateSupplier() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var supplier models.Supplier
+
+		if err := c.ShouldBindJSON(&supplier); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+
+		validationErr := validate.Struct(supplier)
+		if validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+
+		supplier.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		supplier.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		supplier.ID = primitive.NewObjectID()
+		supplier.Deleted_at, supplier.Deleted_by = nil, nil
+		supplier.Version = 1
+		supplier.Supplier_id = supplier.ID.Hex()
+
+		if err := ctrl.repos.Suppliers.Create(ctx, supplier); err != nil {
+			c.Error(apperrors.Internal("Failed to create the supplier", err))
+			return
+		}
+		ctrl.audit(c, models.AuditCreate, "supplier", supplier.Supplier_id, nil, supplier)
+		c.JSON(http.StatusOK, supplier)
+	}
+}
+
+// UpdateSupplier takes a supplier JSON and update supplier stored in DB.
+// UpdateSupplier             godoc
+//  @Summary      Update a supplier
+//  @Description  Takes a supplier JSON and changes its name, contact details and lead time. Return saved JSON.
+//  @Tags         suppliers
+//  @Produce      json
+//  @Param        If-Match  header  string  false  "ETag of the supplier as read, the update is refused with 409 when it changed since"
+//  @Success      200  {object}  models.Supplier
+//  @Header       200  {string}  ETag  "version of the supplier"
+//  @Failure      404  {object}  map[string]interface{}
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /suppliers/{supplier_id} [patch]
+func (ctrl *Controller) UpdateSupplier() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var supplier models.Supplier
+		supplierId := c.Param("supplier_id")
+
+		if err := c.ShouldBindJSON(&supplier); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+
+		version, matchErr := ifMatch(c)
+		if matchErr != nil {
+			c.Error(matchErr)
+			return
+		}
+
+		fields := []string{"Contact_name", "Email", "Phone", "Lead_time_days"}
+		if supplier.Name != "" {
+			fields = append(fields, "Name")
+		}
+		validationErr := validate.StructPartial(supplier, fields...)
+		if validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+
+		before, err := ctrl.repos.Suppliers.Get(ctx, supplierId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("supplier was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the supplier", err))
+			return
+		}
+
+		supplier.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		result, err := ctrl.repos.Suppliers.Update(ctx, supplierId, version, supplier)
+		if err != nil {
+			c.Error(updateError("supplier", err))
+			return
+		}
+		ctrl.audit(c, models.AuditUpdate, "supplier", supplierId, before, result)
+		setETag(c, result.Version)
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// DeleteSupplier soft deletes the supplier with provided ID.
+// DeleteSupplier             godoc
+//  @Summary      Delete a supplier
+//  @Description  Marks the supplier deleted, it is hidden from the list of suppliers and cannot be ordered from until restored. A supplier with purchase orders still to be delivered cannot be deleted.
+//  @Tags         suppliers
+//  @Produce      json
+//  @Success      200  {object}  models.Supplier
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /suppliers/{supplier_id} [delete]
+func (ctrl *Controller) DeleteSupplier() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		supplierId := c.Param("supplier_id")
+
+		open, err := referenced(ctx, ctrl.repos.PurchaseOrders.List, repository.Query{}.
+			Where("supplier_id", repository.OpEq, supplierId).
+			Where("status", repository.OpIn, openPurchaseStatuses))
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing purchase orders", err))
+			return
+		}
+		if open {
+			c.Error(apperrors.Conflict("supplier has purchase orders still to be delivered"))
+			return
+		}
+
+		deleted_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		supplier, err := ctrl.repos.Suppliers.Delete(ctx, supplierId, c.GetString("uid"), deleted_at)
+		if err != nil {
+			c.Error(deletionError("supplier", err, false))
+			return
+		}
+		before := supplier
+		before.Deleted_at, before.Deleted_by = nil, nil
+		ctrl.audit(c, models.AuditDelete, "supplier", supplierId, before, supplier)
+		c.JSON(http.StatusOK, supplier)
+	}
+}
+
+// RestoreSupplier clears the deletion of the supplier with provided ID.
+// RestoreSupplier             godoc
+//  @Summary      Restore a deleted supplier
+//  @Description  Clears the deletion of the supplier.
+//  @Tags         suppliers
+//  @Produce      json
+//  @Success      200  {object}  models.Supplier
+//  @Router       /suppliers/{supplier_id}/restore [post]
+func (ctrl *Controller) RestoreSupplier() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		supplierId := c.Param("supplier_id")
+
+		supplier, err := ctrl.repos.Suppliers.Get(ctx, supplierId)
+		if err != nil {
+			c.Error(deletionError("supplier", err, true))
+			return
+		}
+
+		restored, err := ctrl.repos.Suppliers.Restore(ctx, supplierId)
+		if err != nil {
+			c.Error(deletionError("supplier", err, true))
+			return
+		}
+		ctrl.audit(c, models.AuditRestore, "supplier", supplierId, supplier, restored)
+		c.JSON(http.StatusOK, restored)
+	}
+}
+
+// activeSupplier returns the supplier with the given ID, a 404 when there
+// is none or it is deleted.
+func (ctrl *Controller) activeSupplier(ctx context.Context, supplierId string) (models.Supplier, *apperrors.Error) {
+	supplier, err := ctrl.repos.Suppliers.Get(ctx, supplierId)
+	if err == nil && supplier.Deleted_at != nil {
+		err = repository.ErrNotFound
+	}
+	if err == repository.ErrNotFound {
+		return supplier, apperrors.NotFound("supplier was not found")
+	} else if err != nil {
+		return supplier, apperrors.Internal("error occurred when fetching the supplier", err)
+	}
+	return supplier, nil
+}
@@ -5,46 +5,64 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 
-	"github.com/minhtran241/restaurant-management/database"
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/events"
 	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
 )
 
-var tableCollection *mongo.Collection = database.OpenCollection(database.Client, "table")
+var tableListParams = listParams{
+	filters: map[string]filterParam{
+		"status":     {"status", repository.OpIn, paramList},
+		"section":    {"section", repository.OpEq, paramString},
+		"min_guests": {"number_of_guests", repository.OpGte, paramInt},
+	},
+	sorts: map[string]string{
+		"table_number": "table_number", "number_of_guests": "number_of_guests", "section": "section", "created_at": "created_at",
+	},
+	defaultSort: "table_number",
+	softDeleted: true,
+}
 
-// GetTables responds with the list of all tables as JSON.
+// GetTables responds with a page of tables as JSON.
 // GetTables             godoc
 //  @Summary      Get all tables
-//  @Description  Responds with the list of all tables as JSON.
+//  @Description  Responds with a page of tables, filtered by status, section and size.
 //  @Tags         tables
 //  @Produce      json
-//  @Success      200  {array}  models.Table
+//  @Param        limit   query  int     false  "page size, 20 by default, at most 100"
+//  @Param        offset  query  int     false  "number of items to skip"
+//  @Param        page    query  int     false  "1-based page, used when offset is not given"
+//  @Param        status      query  string  false  "comma separated table statuses"
+//  @Param        section     query  string  false  "only tables of the section"
+//  @Param        min_guests  query  int     false  "only tables seating at least that many guests"
+//  @Param        sort        query  string  false  "table_number, number_of_guests, section or created_at, prefixed with - for descending"
+//  @Param        include_deleted  query  bool  false  "also list deleted records, admins only"
+//  @Success      200  {object}  controllers.ListResponse
 //  @Router       /tables [get]
-func GetTables() gin.HandlerFunc {
+func (ctrl *Controller) GetTables() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
-		result, err := tableCollection.Find(ctx, bson.M{})
-		if err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "error occurred while listing tables"},
-			)
+
+		query, queryErr := tableListParams.parse(c)
+		if queryErr != nil {
+			c.Error(queryErr)
 			return
 		}
-		var allTables []bson.M
-
-		if err = result.All(ctx, &allTables); err != nil {
-			log.Fatal(err)
+		tables, total, err := ctrl.repos.Tables.List(ctx, query)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing tables", err))
+			return
 		}
-		c.JSON(http.StatusOK, allTables)
+		c.JSON(http.StatusOK, newListResponse(tables, total, query))
 	}
 }
 
@@ -55,24 +73,22 @@ func GetTables() gin.HandlerFunc {
 //  @Tags         tables
 //  @Produce      json
 //  @Success      200  {object}  models.Table
+//  @Header       200  {string}  ETag  "version of the table"
 //  @Router       /tables/{table_id} [get]
-func GetTable() gin.HandlerFunc {
+func (ctrl *Controller) GetTable() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 		tableId := c.Param("table_id")
-		var table models.Table
-		err := tableCollection.FindOne(ctx, bson.M{"table_id": tableId}).Decode(&table)
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "table was not found"})
+		table, err := ctrl.repos.Tables.Get(ctx, tableId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("table was not found"))
 			return
 		} else if err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "error occurred when fetching the table"},
-			)
+			c.Error(apperrors.Internal("error occurred when fetching the table", err))
 			return
 		}
+		setETag(c, table.Version)
 		c.JSON(http.StatusOK, table)
 	}
 }
@@ -85,35 +101,47 @@ func GetTable() gin.HandlerFunc {
 //  @Produce      json
 //  @Success      200  {object}  models.Table
 //  @Router       /tables [post]
-func CreateTable() gin.HandlerFunc {
+func (ctrl *Controller) CreateTable() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 		var table models.Table
 
-		if err := c.BindJSON(&table); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&table); err != nil {
+			c.Error(apperrors.Decoding(err))
 			return
 		}
 
 		validationErr := validate.Struct(table)
 		if validationErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			c.Error(apperrors.Validation(validationErr))
 			return
 		}
 
+		if table.Min_capacity != nil && table.Max_capacity != nil && *table.Min_capacity > *table.Max_capacity {
+			c.Error(apperrors.Invalid("min_capacity", "cannot be larger than max_capacity"))
+			return
+		}
+		if table.Status == nil {
+			status := models.TableAvailable
+			table.Status = &status
+		}
+
 		table.Created_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		table.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		table.ID = primitive.NewObjectID()
+		table.Deleted_at, table.Deleted_by = nil, nil
+		table.Version = 1
 		table.Table_id = table.ID.Hex()
 
-		result, insertErr := tableCollection.InsertOne(ctx, table)
+		insertErr := ctrl.repos.Tables.Create(ctx, table)
 		if insertErr != nil {
 			msg := fmt.Sprintf("Failed to created table")
-			c.JSON(http.StatusInternalServerError, msg)
+			c.Error(apperrors.Internal(msg, insertErr))
 			return
 		}
-		c.JSON(http.StatusOK, result)
+		ctrl.audit(c, models.AuditCreate, "table", table.Table_id, nil, table)
+		c.JSON(http.StatusOK, table)
 	}
 }
 
@@ -123,53 +151,266 @@ func CreateTable() gin.HandlerFunc {
 //  @Description  Takes a table JSON and update table stored in DB. Return saved JSON.
 //  @Tags         tables
 //  @Produce      json
+//  @Param        If-Match  header  string  false  "ETag of the table as read, the update is refused with 409 when it changed since"
 //  @Success      200  {object}  models.Table
+//  @Header       200  {string}  ETag  "version of the table"
+//  @Failure      404  {object}  map[string]interface{}
+//  @Failure      409  {object}  map[string]interface{}
 //  @Router       /tables/{table_id} [patch]
-func UpdateTable() gin.HandlerFunc {
+func (ctrl *Controller) UpdateTable() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 		var table models.Table
 
 		tableId := c.Param("table_id")
 
-		if err := c.BindJSON(&table); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&table); err != nil {
+			c.Error(apperrors.Decoding(err))
 			return
 		}
 
-		var updateObj primitive.D
+		version, matchErr := ifMatch(c)
+		if matchErr != nil {
+			c.Error(matchErr)
+			return
+		}
 
-		if table.Number_of_guests != nil {
-			updateObj = append(updateObj, bson.E{Key: "number_of_guests", Value: table.Number_of_guests})
+		validationErr := validate.StructPartial(table, "Status", "Shape", "Min_capacity", "Max_capacity")
+		if validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
 		}
 
-		if table.Table_number != nil {
-			updateObj = append(updateObj, bson.E{Key: "table_number", Value: table.Table_number})
+		before, err := ctrl.repos.Tables.Get(ctx, tableId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("table was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching the table", err))
+			return
 		}
 
 		table.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: table.Updated_at})
 
-		upsert := true
-		filter := bson.M{"table_id": tableId}
+		result, err := ctrl.repos.Tables.Update(ctx, tableId, version, table)
+		if err != nil {
+			c.Error(updateError("table", err))
+			return
+		}
+		ctrl.audit(c, models.AuditUpdate, "table", tableId, before, result)
+		if table.Status != nil {
+			ctrl.events.Publish(events.TableStatusChanged, tableId, "", gin.H{"table_id": tableId, "status": table.Status})
+		}
+		setETag(c, result.Version)
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// DeleteTable soft deletes the table with provided ID.
+// DeleteTable             godoc
+//  @Summary      Delete a table
+//  @Description  Marks the table deleted, it is hidden from the list of tables and the floor plan and cannot be booked until restored. A table with an open order or an upcoming reservation cannot be deleted.
+//  @Tags         tables
+//  @Produce      json
+//  @Success      200  {object}  models.Table
+//  @Router       /tables/{table_id} [delete]
+func (ctrl *Controller) DeleteTable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		tableId := c.Param("table_id")
 
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		hasOrder, err := referenced(ctx, ctrl.repos.Orders.List, repository.Query{}.
+			Where("table_id", repository.OpEq, tableId).
+			Where("status", repository.OpIn, openOrderStatuses))
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing orders", err))
+			return
+		}
+		if hasOrder {
+			c.Error(apperrors.Conflict("table has an open order"))
+			return
+		}
+		hasReservation, err := referenced(ctx, ctrl.repos.Reservations.List, repository.Query{}.
+			Where("table_id", repository.OpEq, tableId).
+			Where("status", repository.OpIn, []string{models.ReservationBooked, models.ReservationSeated}).
+			Where("end_time", repository.OpGte, time.Now()))
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing reservations", err))
+			return
+		}
+		if hasReservation {
+			c.Error(apperrors.Conflict("table has upcoming reservations"))
+			return
 		}
 
-		result, err := tableCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{{Key: "$set", Value: updateObj}},
-			&opt,
-		)
+		deleted_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		table, err := ctrl.repos.Tables.Delete(ctx, tableId, c.GetString("uid"), deleted_at)
+		if err != nil {
+			c.Error(deletionError("table", err, false))
+			return
+		}
+		before := table
+		before.Deleted_at, before.Deleted_by = nil, nil
+		ctrl.audit(c, models.AuditDelete, "table", tableId, before, table)
+		c.JSON(http.StatusOK, table)
+	}
+}
 
+// RestoreTable clears the deletion of the table with provided ID.
+// RestoreTable             godoc
+//  @Summary      Restore a deleted table
+//  @Description  Clears the deletion of the table.
+//  @Tags         tables
+//  @Produce      json
+//  @Success      200  {object}  models.Table
+//  @Router       /tables/{table_id}/restore [post]
+func (ctrl *Controller) RestoreTable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		tableId := c.Param("table_id")
+
+		table, err := ctrl.repos.Tables.Get(ctx, tableId)
 		if err != nil {
-			msg := "Failed to update table"
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(deletionError("table", err, true))
 			return
 		}
-		c.JSON(http.StatusOK, result)
+		restored, err := ctrl.repos.Tables.Restore(ctx, tableId)
+		if err != nil {
+			c.Error(deletionError("table", err, true))
+			return
+		}
+		ctrl.audit(c, models.AuditRestore, "table", tableId, table, restored)
+		c.JSON(http.StatusOK, restored)
+	}
+}
+
+// FloorTable is a table on the floor plan with its live status and the
+// start of its next booking, if any.
+type FloorTable struct {
+	models.Table
+	Next_reservation *time.Time
+}
+
+// FloorSection groups the tables of one area of the floor.
+type FloorSection struct {
+	Section string
+	Tables  []FloorTable
+}
+
+// GetFloor responds with the floor plan and the status of every table.
+// GetFloor             godoc
+//  @Summary      Get the floor plan
+//  @Description  Responds with every table that is not deleted grouped by section with its position, shape, capacity and live status. An available table booked to start within reserved_within minutes (default 60) is shown as RESERVED.
+//  @Tags         tables
+//  @Produce      json
+//  @Param        reserved_within  query  int  false  "minutes before a booking the table shows as reserved"
+//  @Success      200  {array}  FloorSection
+//  @Router       /floor [get]
+func (ctrl *Controller) GetFloor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		reservedWithin := time.Hour
+		if minutes, err := strconv.Atoi(c.Query("reserved_within")); err == nil && minutes >= 0 {
+			reservedWithin = time.Duration(minutes) * time.Minute
+		}
+
+		tables, _, err := ctrl.repos.Tables.List(ctx, repository.Query{}.NotDeleted())
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while listing tables", err))
+			return
+		}
+		sort.SliceStable(tables, func(i, j int) bool {
+			a, b := tables[i], tables[j]
+			if section(a) != section(b) {
+				return section(a) < section(b)
+			}
+			return a.Table_number != nil && (b.Table_number == nil || *a.Table_number < *b.Table_number)
+		})
+
+		now := time.Now()
+		floor := []FloorSection{}
+		for _, table := range tables {
+			floorTable := FloorTable{Table: table}
+			status, next := floorStatus(table, now, reservedWithin)
+			floorTable.Status = &status
+			floorTable.Next_reservation = next
+
+			if len(floor) == 0 || floor[len(floor)-1].Section != section(table) {
+				floor = append(floor, FloorSection{Section: section(table), Tables: []FloorTable{}})
+			}
+			floor[len(floor)-1].Tables = append(floor[len(floor)-1].Tables, floorTable)
+		}
+		c.JSON(http.StatusOK, floor)
+	}
+}
+
+// floorStatus returns the live status of the table at now and the start of
+// its next booking that has not ended, if any. An available table whose next
+// booking starts within reservedWithin shows as RESERVED.
+func floorStatus(table models.Table, now time.Time, reservedWithin time.Duration) (string, *time.Time) {
+	var next *time.Time
+	for _, slot := range table.Booked_slots {
+		if slot.End_time.After(now) && (next == nil || slot.Start_time.Before(*next)) {
+			start := slot.Start_time
+			next = &start
+		}
+	}
+
+	status := models.TableAvailable
+	if table.Status != nil {
+		status = *table.Status
+	}
+	if status == models.TableAvailable && next != nil && next.Before(now.Add(reservedWithin)) {
+		status = models.TableReserved
+	}
+	return status, next
+}
+
+// section returns the section of the table, empty if it has none.
+func section(table models.Table) string {
+	if table.Section == nil {
+		return ""
+	}
+	return *table.Section
+}
+
+// setTableStatus records the new status of a table and tells the floor.
+// Failures are only logged, the change that caused it has already happened.
+func (ctrl *Controller) setTableStatus(ctx context.Context, tableId, status string) {
+	updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	err := ctrl.repos.Tables.SetStatus(ctx, tableId, status, updated_at)
+	if err != nil {
+		log.Printf("failed to set status of table %s: %v", tableId, err)
+		return
+	}
+	ctrl.events.Publish(events.TableStatusChanged, tableId, "", gin.H{"table_id": tableId, "status": status})
+}
+
+// releaseTable sets an ORDERED table to status once it has no open order
+// left, after an order was cancelled or moved away from it. Failures are
+// only logged.
+func (ctrl *Controller) releaseTable(ctx context.Context, tableId, status string) {
+	table, err := ctrl.repos.Tables.Get(ctx, tableId)
+	if err != nil {
+		log.Printf("failed to fetch table %s: %v", tableId, err)
+		return
+	}
+	if table.Status == nil || *table.Status != models.TableOrdered {
+		return
+	}
+	hasOrder, err := referenced(ctx, ctrl.repos.Orders.List, repository.Query{}.
+		Where("table_id", repository.OpEq, tableId).
+		Where("status", repository.OpIn, openOrderStatuses))
+	if err != nil {
+		log.Printf("failed to list the orders of table %s: %v", tableId, err)
+		return
+	}
+	if !hasOrder {
+		ctrl.setTableStatus(ctx, tableId, status)
 	}
 }
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+func TestFloorStatus(t *testing.T) {
+	now := time.Date(2024, 5, 10, 18, 0, 0, 0, time.UTC)
+	slot := func(start, end time.Duration) models.TableSlot {
+		return models.TableSlot{Start_time: now.Add(start), End_time: now.Add(end)}
+	}
+	status := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		status   *string
+		slots    []models.TableSlot
+		want     string
+		wantNext *time.Duration
+	}{
+		{"no status", nil, nil, models.TableAvailable, nil},
+		{"available", status(models.TableAvailable), nil, models.TableAvailable, nil},
+		{"booked later", status(models.TableAvailable), []models.TableSlot{slot(2*time.Hour, 3*time.Hour)}, models.TableAvailable, durationOf(2 * time.Hour)},
+		{"booked soon", nil, []models.TableSlot{slot(30*time.Minute, 2*time.Hour)}, models.TableReserved, durationOf(30 * time.Minute)},
+		{"booking under way", status(models.TableAvailable), []models.TableSlot{slot(-30*time.Minute, time.Hour)}, models.TableReserved, durationOf(-30 * time.Minute)},
+		{"booking ended", status(models.TableAvailable), []models.TableSlot{slot(-2*time.Hour, -time.Hour)}, models.TableAvailable, nil},
+		{"earliest booking", status(models.TableAvailable), []models.TableSlot{
+			slot(5*time.Hour, 6*time.Hour), slot(45*time.Minute, 2*time.Hour), slot(-3*time.Hour, -2*time.Hour),
+		}, models.TableReserved, durationOf(45 * time.Minute)},
+		{"seated and booked soon", status(models.TableSeated), []models.TableSlot{slot(30*time.Minute, 2*time.Hour)}, models.TableSeated, durationOf(30 * time.Minute)},
+		{"ordered", status(models.TableOrdered), nil, models.TableOrdered, nil},
+		{"needs cleaning", status(models.TableNeedsCleaning), nil, models.TableNeedsCleaning, nil},
+	}
+	for _, tt := range tests {
+		got, next := floorStatus(models.Table{Status: tt.status, Booked_slots: tt.slots}, now, time.Hour)
+		if got != tt.want {
+			t.Errorf("%s: status is %s, want %s", tt.name, got, tt.want)
+		}
+		switch {
+		case tt.wantNext == nil && next != nil:
+			t.Errorf("%s: next booking at %v, want none", tt.name, next)
+		case tt.wantNext != nil && (next == nil || !next.Equal(now.Add(*tt.wantNext))):
+			t.Errorf("%s: next booking at %v, want %v", tt.name, next, now.Add(*tt.wantNext))
+		}
+	}
+}
+
+func durationOf(d time.Duration) *time.Duration {
+	return &d
+}
@@ -5,93 +5,109 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 
-	"github.com/minhtran241/restaurant-management/database"
+	"github.com/minhtran241/restaurant-management/apperrors"
 	"github.com/minhtran241/restaurant-management/helpers"
 	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
 )
 
-var userCollection *mongo.Collection = database.OpenCollection(database.Client, "user")
+var userListParams = listParams{
+	filters: map[string]filterParam{
+		"role":  {"role", repository.OpIn, paramList},
+		"email": {"email", repository.OpEq, paramString},
+	},
+	sorts: map[string]string{
+		"first_name": "first_name", "last_name": "last_name", "email": "email", "created_at": "created_at",
+	},
+	defaultSort: "created_at",
+}
+
+// signupRequest is the user signing up with their password, which User
+// does not read from JSON.
+type signupRequest struct {
+	models.User
+	Password *string `json:"password"`
+}
+
+type loginRequest struct {
+	Email    *string `json:"email"`
+	Password *string `json:"password"`
+}
+
+// AuthResponse is the user together with the tokens just issued to them.
+type AuthResponse struct {
+	models.User
+	Token         string `json:"token"`
+	Refresh_token string `json:"refresh_token"`
+}
 
-// GetUsers responds with the list of all users as JSON.
+// GetUsers responds with a page of users as JSON.
 // GetUsers             godoc
 //  @Summary      Get all users
-//  @Description  Responds with the list of all users as JSON.
+//  @Description  Responds with a page of users, filtered by role and email.
 //  @Tags         users
 //  @Produce      json
-//  @Success      200  {array}  models.User
+//  @Param        limit   query  int     false  "page size, 20 by default, at most 100"
+//  @Param        offset  query  int     false  "number of items to skip"
+//  @Param        page    query  int     false  "1-based page, used when offset is not given"
+//  @Param        role   query  string  false  "comma separated roles"
+//  @Param        email  query  string  false  "only the user with this email"
+//  @Param        sort   query  string  false  "first_name, last_name, email or created_at, prefixed with - for descending"
+//  @Success      200  {object}  controllers.ListResponse
 //  @Router       /users [get]
-func GetUsers() gin.HandlerFunc {
+func (ctrl *Controller) GetUsers() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 
-		recordPerPage, err := strconv.Atoi(c.Query("recordPerPage"))
-		if err != nil || recordPerPage < 1 {
-			recordPerPage = 10
-		}
-
-		page, err1 := strconv.Atoi(c.Query("page"))
-		if err1 != nil || page < 1 {
-			page = 1
+		query, queryErr := userListParams.parse(c)
+		if queryErr != nil {
+			c.Error(queryErr)
+			return
 		}
-
-		startIndex := (page - 1) * recordPerPage
-		startIndex, err = strconv.Atoi(c.Query("startIndex"))
-
-		matchStage := bson.D{{Key: "$match", Value: bson.D{{}}}}
-		projectStage := bson.D{{Key: "$project", Value: bson.D{
-			{Key: "_id", Value: 0},
-			{Key: "total_count", Value: 1},
-			{Key: "user_items", Value: bson.D{{Key: "$slice", Value: []interface{}{"$data", startIndex, recordPerPage}}}},
-		}}}
-
-		result, err := userCollection.Aggregate(ctx, mongo.Pipeline{matchStage, projectStage})
+		users, total, err := ctrl.repos.Users.List(ctx, query)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while listing users"})
-		}
-
-		var allUsers []bson.M
-		if err = result.All(ctx, &allUsers); err != nil {
-			log.Fatal(err)
+			c.Error(apperrors.Internal("error occurred while listing users", err))
+			return
 		}
-		c.JSON(http.StatusOK, allUsers[0])
+		c.JSON(http.StatusOK, newListResponse(users, total, query))
 	}
 }
 
 // GetUser responds with the user with provided ID as JSON.
 // GetUser             godoc
 //  @Summary      Get single user by ID
-//  @Description  Responds with the user with provided ID as JSON.
+//  @Description  Responds with the user with provided ID as JSON. Users may read themselves, managers and admins anyone.
 //  @Tags         users
 //  @Produce      json
 //  @Success      200  {object}  models.User
+//  @Header       200  {string}  ETag  "version of the user"
+//  @Failure      403  {object}  map[string]interface{}
 //  @Router       /users/{user_id} [get]
-func GetUser() gin.HandlerFunc {
+func (ctrl *Controller) GetUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
 		userId := c.Param("user_id")
-		var user models.User
-		err := userCollection.FindOne(ctx, bson.M{"user_id": userId}).Decode(&user)
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "user was not found"})
+		if !canReadUser(c, userId) {
+			c.Error(apperrors.Forbidden("you are not allowed to access this resource"))
+			return
+		}
+		user, err := ctrl.repos.Users.Get(ctx, userId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("user was not found"))
 			return
 		} else if err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "error occurred when fetching user"},
-			)
+			c.Error(apperrors.Internal("error occurred when fetching user", err))
 			return
 		}
+		setETag(c, user.Version)
 		c.JSON(http.StatusOK, user)
 	}
 }
@@ -102,47 +118,51 @@ func GetUser() gin.HandlerFunc {
 //  @Description  Create a new user.
 //  @Tags         users
 //  @Produce      json
-//  @Success      200  {object}  models.User
+//  @Success      200  {object}  controllers.AuthResponse
 //  @Router       /users/signup [post]
-func SignUp() gin.HandlerFunc {
+func (ctrl *Controller) SignUp() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
-		var user models.User
+		var req signupRequest
 		// convert the JSON data coming from client to golang readable format
-		if err := c.BindJSON(&user); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apperrors.Decoding(err))
 			return
 		}
+		user := req.User
+		user.Password = req.Password
 		// validate the data based on user struct
 		validationErr := validate.Struct(user)
 		if validationErr != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			c.Error(apperrors.Validation(validationErr))
 			return
 		}
 		// check if the email has already been used by another user
-		count, err := userCollection.CountDocuments(ctx, bson.M{"email": user.Email})
+		exists, err := ctrl.repos.Users.EmailExists(ctx, *user.Email)
 		if err != nil {
-			log.Panic(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while checking for email"})
+			c.Error(apperrors.Internal("error occurred while checking for email", err))
 			return
 		}
-		if count > 0 {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "email already exists"})
+		if exists {
+			c.Error(apperrors.Conflict("email already exists"))
 			return
 		}
 		// hash password
-		password := HashPassword(*user.Password)
+		password, err := HashPassword(*user.Password, ctrl.bcryptCost)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to hash the password", err))
+			return
+		}
 		user.Password = &password
 		// check if the phone no. has already been used by another user
-		count, err = userCollection.CountDocuments(ctx, bson.M{"phone": user.Phone})
+		exists, err = ctrl.repos.Users.PhoneExists(ctx, *user.Phone)
 		if err != nil {
-			log.Panic(err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while checking for phone number"})
+			c.Error(apperrors.Internal("error occurred while checking for phone number", err))
 			return
 		}
-		if count > 0 {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "phone number already exists"})
+		if exists {
+			c.Error(apperrors.Conflict("phone number already exists"))
 			return
 		}
 		// get extra details for user object - created_at, updated_at, ID
@@ -150,21 +170,57 @@ func SignUp() gin.HandlerFunc {
 		user.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		user.ID = primitive.NewObjectID()
 		user.User_id = user.ID.Hex()
+		// the very first account bootstraps the system as ADMIN, everyone
+		// else starts as WAITER until an admin promotes them. Counting alone
+		// would let concurrent first sign ups all become ADMIN, the claim
+		// lets only one of them through
+		count, err := ctrl.repos.Users.Count(ctx)
+		if err != nil {
+			c.Error(apperrors.Internal("error occurred while counting users", err))
+			return
+		}
+		bootstrap := false
+		if count == 0 {
+			bootstrap, err = ctrl.repos.Users.ClaimBootstrap(ctx, user.User_id)
+			if err != nil {
+				c.Error(apperrors.Internal("error occurred while claiming the admin role", err))
+				return
+			}
+		}
+		role := models.RoleWaiter
+		if bootstrap {
+			role = models.RoleAdmin
+		}
+		user.Role = &role
+		user.Token_version = 0
+		user.Version = 1
 		// generate token and refresh token (helpers package)
-		token, refreshToken, _ := helpers.GenerateAllTokens(
-			*user.Email, *user.First_name, *user.Last_name, user.User_id,
+		token, refreshToken, err := helpers.GenerateAllTokens(
+			*user.Email, *user.First_name, *user.Last_name, user.User_id, *user.Role, user.Token_version,
 		)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to generate tokens", err))
+			return
+		}
 		user.Token = &token
 		user.Refresh_Token = &refreshToken
 		// insert new user into the database
-		resultInsertNumber, insertErr := userCollection.InsertOne(ctx, user)
+		insertErr := ctrl.repos.Users.Create(ctx, user)
+		if insertErr != nil && bootstrap {
+			if err := ctrl.repos.Users.ReleaseBootstrap(ctx, user.User_id); err != nil {
+				log.Printf("failed to release the admin role claimed by %s: %v", user.User_id, err)
+			}
+		}
 		if insertErr != nil {
 			msg := fmt.Sprintf("Failed to create user")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(apperrors.Internal(msg, insertErr))
 			return
 		}
+		// nobody is logged in yet, the new user signed themselves up
+		c.Set("uid", user.User_id)
+		ctrl.audit(c, models.AuditCreate, "user", user.User_id, nil, user)
 		// return status OK and result
-		c.JSON(http.StatusOK, resultInsertNumber)
+		c.JSON(http.StatusOK, AuthResponse{User: user, Token: token, Refresh_token: refreshToken})
 	}
 }
 
@@ -174,54 +230,248 @@ func SignUp() gin.HandlerFunc {
 //  @Description  Log a user in.
 //  @Tags         users
 //  @Produce      json
-//  @Success      200  {object}  models.User
+//  @Success      200  {object}  controllers.AuthResponse
 //  @Router       /users/login [post]
-func Login() gin.HandlerFunc {
+func (ctrl *Controller) Login() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
 		defer cancel()
-		var user models.User
-		var foundUser models.User
+		var user loginRequest
 		// convert the login data coming from client to golang readable format
-		if err := c.BindJSON(&user); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := c.ShouldBindJSON(&user); err != nil {
+			c.Error(apperrors.Decoding(err))
 			return
 		}
 		// find a user with that email, see if user even exists
-		err := userCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&foundUser)
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "email not found, login failed"})
+		var missing []string
+		if user.Email == nil {
+			missing = append(missing, "email")
+		}
+		if user.Password == nil {
+			missing = append(missing, "password")
+		}
+		if len(missing) > 0 {
+			c.Error(apperrors.Required(missing...))
+			return
+		}
+		foundUser, err := ctrl.repos.Users.GetByEmail(ctx, *user.Email)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.Unauthorized("email not found, login failed"))
 			return
 		} else if err != nil {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": "user not found, login failed"},
-			)
+			c.Error(apperrors.Internal("error occurred when fetching user", err))
 			return
 		}
 		// verify password
 		passwordIsValid, msg := VerifyPassword(*foundUser.Password, *user.Password)
 		if !passwordIsValid {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.Error(apperrors.Unauthorized(msg))
 			return
 		}
 		// generate tokens
-		token, refreshToken, _ := helpers.GenerateAllTokens(
+		token, refreshToken, err := helpers.GenerateAllTokens(
 			*foundUser.Email, *foundUser.First_name, *foundUser.Last_name, foundUser.User_id,
+			userRole(foundUser), foundUser.Token_version,
 		)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to generate tokens", err))
+			return
+		}
 		// update tokens - token and refresh token
-		helpers.UpdateAllTokens(token, refreshToken, foundUser.User_id)
+		updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err := ctrl.repos.Users.SetTokens(ctx, foundUser.User_id, token, refreshToken, updated_at); err != nil {
+			c.Error(apperrors.Internal("Failed to store tokens", err))
+			return
+		}
 		// return status OK and result
-		c.JSON(http.StatusOK, foundUser)
+		c.JSON(http.StatusOK, AuthResponse{User: foundUser, Token: token, Refresh_token: refreshToken})
+	}
+}
+
+type refreshRequest struct {
+	Refresh_token string `json:"refresh_token" validate:"required"`
+}
+
+// RefreshToken exchanges a refresh token for a new token pair.
+// RefreshToken             godoc
+//  @Summary      Refresh the tokens of a user
+//  @Description  Takes a refresh token and responds with a new token and refresh token. The old refresh token stops working; presenting it again revokes every token of the user.
+//  @Tags         users
+//  @Produce      json
+//  @Success      200  {object}  map[string]interface{}
+//  @Router       /users/refresh [post]
+func (ctrl *Controller) RefreshToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var req refreshRequest
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+		if validationErr := validate.Struct(req); validationErr != nil {
+			c.Error(apperrors.Validation(validationErr))
+			return
+		}
+
+		claims, msg := helpers.ValidateToken(req.Refresh_token)
+		if msg != "" {
+			c.Error(apperrors.Unauthorized(msg))
+			return
+		}
+		if claims.Token_type != helpers.RefreshToken {
+			c.Error(apperrors.Unauthorized("not a refresh token"))
+			return
+		}
+
+		foundUser, err := ctrl.repos.Users.Get(ctx, claims.Uid)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.Unauthorized("user was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching user", err))
+			return
+		}
+		if claims.Token_version != foundUser.Token_version {
+			c.Error(apperrors.Unauthorized("refresh token has been revoked"))
+			return
+		}
+
+		token, refreshToken, err := helpers.GenerateAllTokens(
+			*foundUser.Email, *foundUser.First_name, *foundUser.Last_name, foundUser.User_id,
+			userRole(foundUser), foundUser.Token_version,
+		)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to generate tokens", err))
+			return
+		}
+		updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		rotated, err := ctrl.repos.Users.RotateTokens(
+			ctx, foundUser.User_id, req.Refresh_token, token, refreshToken, updated_at,
+		)
+		if err != nil {
+			c.Error(apperrors.Internal("Failed to refresh tokens", err))
+			return
+		}
+		if !rotated {
+			// a valid but already rotated refresh token is being replayed,
+			// assume it was stolen and log the user out everywhere
+			if err := ctrl.repos.Users.RevokeTokens(ctx, foundUser.User_id, updated_at); err != nil {
+				c.Error(apperrors.Internal("Failed to revoke tokens", err))
+				return
+			}
+			c.Error(apperrors.Unauthorized("refresh token reuse detected, all sessions have been revoked"))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refreshToken})
+	}
+}
+
+// Logout revokes every token of the authenticated user.
+// Logout             godoc
+//  @Summary      Log a user out
+//  @Description  Revokes the token and refresh token of the authenticated user. Tokens issued before the logout are rejected afterwards.
+//  @Tags         users
+//  @Produce      json
+//  @Success      200  {object}  map[string]interface{}
+//  @Router       /users/logout [post]
+func (ctrl *Controller) Logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+
+		updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err := ctrl.repos.Users.RevokeTokens(ctx, c.GetString("uid"), updated_at); err != nil {
+			c.Error(apperrors.Internal("Failed to log out", err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": "logged out"})
+	}
+}
+
+// UpdateUserRole changes the role of the user with provided ID.
+// UpdateUserRole             godoc
+//  @Summary      Change the role of a user
+//  @Description  Takes a role JSON and assigns it to the user. Admin only. The tokens of the user are revoked, the new role applies from their next login.
+//  @Tags         users
+//  @Produce      json
+//  @Param        If-Match  header  string  false  "ETag of the user as read, the update is refused with 409 when it changed since"
+//  @Success      200  {object}  models.User
+//  @Header       200  {string}  ETag  "version of the user"
+//  @Failure      404  {object}  map[string]interface{}
+//  @Failure      409  {object}  map[string]interface{}
+//  @Router       /users/{user_id}/role [patch]
+func (ctrl *Controller) UpdateUserRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(context.Background(), ctrl.queryTimeout)
+		defer cancel()
+		var user models.User
+		userId := c.Param("user_id")
+
+		if err := c.ShouldBindJSON(&user); err != nil {
+			c.Error(apperrors.Decoding(err))
+			return
+		}
+
+		version, matchErr := ifMatch(c)
+		if matchErr != nil {
+			c.Error(matchErr)
+			return
+		}
+		if user.Role == nil {
+			c.Error(apperrors.Required("role"))
+			return
+		}
+		if err := validate.Var(user.Role, "eq=ADMIN|eq=MANAGER|eq=WAITER|eq=CHEF|eq=CASHIER"); err != nil {
+			c.Error(apperrors.Invalid("role", "must be one of ADMIN, MANAGER, WAITER, CHEF, CASHIER"))
+			return
+		}
+
+		before, err := ctrl.repos.Users.Get(ctx, userId)
+		if err == repository.ErrNotFound {
+			c.Error(apperrors.NotFound("user was not found"))
+			return
+		} else if err != nil {
+			c.Error(apperrors.Internal("error occurred when fetching user", err))
+			return
+		}
+
+		user.Updated_at, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		updated, err := ctrl.repos.Users.SetRole(ctx, userId, version, *user.Role, user.Updated_at)
+		if err != nil {
+			c.Error(updateError("user", err))
+			return
+		}
+		ctrl.audit(c, models.AuditUpdate, "user", userId, before, updated)
+		setETag(c, updated.Version)
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+// canReadUser tells whether the user of the request may read the user with
+// the given ID, which the user themself, managers and admins may.
+func canReadUser(c *gin.Context, userId string) bool {
+	role := c.GetString("role")
+	return userId == c.GetString("uid") || role == models.RoleManager || role == models.RoleAdmin
+}
+
+// userRole returns the role stored on the user, accounts created before
+// roles existed are treated as waiters.
+func userRole(user models.User) string {
+	if user.Role == nil || *user.Role == "" {
+		return models.RoleWaiter
 	}
+	return *user.Role
 }
 
-func HashPassword(password string) string {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+func HashPassword(password string, cost int) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), cost)
 	if err != nil {
-		log.Panic(err)
+		return "", err
 	}
-	return string(bytes)
+	return string(bytes), nil
 }
 
 func VerifyPassword(userPassword, providePassword string) (bool, string) {
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+// ifMatch returns the version the If-Match header of the request expects,
+// nil when there is none or it is "*". The ETag of a resource is its
+// version in quotes; weak tags are accepted as well.
+func ifMatch(c *gin.Context) (*int, *apperrors.Error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+	tag := strings.TrimPrefix(header, "W/")
+	version, err := strconv.Atoi(strings.Trim(tag, `"`))
+	if err != nil || version < 0 {
+		return nil, apperrors.Invalid("If-Match", "must be the ETag of the resource")
+	}
+	return &version, nil
+}
+
+// setETag tags the response with the version of the resource it carries.
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// updateError reports the error of a versioned update of the named
+// resource.
+func updateError(resource string, err error) *apperrors.Error {
+	switch err {
+	case repository.ErrNotFound:
+		return apperrors.NotFound(resource + " was not found")
+	case repository.ErrConflict:
+		return apperrors.Conflict(resource + " was changed by someone else, fetch it again and retry")
+	}
+	return apperrors.Internal("Failed to update the "+resource, err)
+}
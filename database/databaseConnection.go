@@ -3,22 +3,21 @@ package database
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/minhtran241/restaurant-management/config"
 )
 
-func DBinstance() (*mongo.Client, error) {
-	MongoDB := "mongodb://localhost:27017"
-	fmt.Println("connecting to " + MongoDB + "...")
+func DBinstance(cfg config.Database) (*mongo.Client, error) {
+	fmt.Println("connecting to database...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Connect_timeout))
 	defer cancel()
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(MongoDB))
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.Uri))
 	if err != nil {
-		log.Fatal(err)
 		return nil, err
 	}
 
@@ -26,9 +25,7 @@ func DBinstance() (*mongo.Client, error) {
 	return client, nil
 }
 
-var Client, Error = DBinstance()
-
-func OpenCollection(client *mongo.Client, collectionName string) *mongo.Collection {
-	var collection *mongo.Collection =  client.Database("restaurant").Collection(collectionName)
-	return collection
-}
\ No newline at end of file
+// Database returns the database of the service.
+func Database(client *mongo.Client, cfg config.Database) *mongo.Database {
+	return client.Database(cfg.Name)
+}
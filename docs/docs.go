@@ -48,702 +48,4751 @@ const docTemplate = `{
                 }
             }
         },
-        "/foods": {
+        "/audit": {
             "get": {
-                "description": "Responds with the list of all foods as JSON.",
+                "description": "Responds with a page of the changes made through the API, newest first, filtered by entity, user and time range. Every entry holds who made the change, the action, the entity and its state before and after.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "foods"
+                    "audit"
+                ],
+                "summary": "Get the audit log",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based page, used when offset is not given",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "food, menu, table, order, order_item, invoice, reservation, user, note, ingredient, supplier or purchase_order",
+                        "name": "entity_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only changes of the entity",
+                        "name": "entity_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only changes made by the user",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated actions: CREATE, UPDATE, DELETE, RESTORE",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "changes made at or after, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "changes made before, RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created_at, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all foods",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Food"
-                            }
+                            "$ref": "#/definitions/controllers.ListResponse"
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Takes a food JSON and store in DB. Return saved JSON.",
+            }
+        },
+        "/events/stream": {
+            "get": {
+                "description": "Streams order, kitchen and invoice events as Server-Sent Events. Filter with table_id, order_id and a comma separated list of event types. Reconnecting clients send the Last-Event-ID header (or last_event_id query) to receive the events they missed. Browsers may pass the token as a query parameter.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Stream live events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "only events of this table",
+                        "name": "table_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only events of this order",
+                        "name": "order_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated event types",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "resume after this event",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": ""
+                    }
+                }
+            }
+        },
+        "/floor": {
+            "get": {
+                "description": "Responds with every table that is not deleted grouped by section with its position, shape, capacity and live status. An available table booked to start within reserved_within minutes (default 60) is shown as RESERVED.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "foods"
+                    "tables"
+                ],
+                "summary": "Get the floor plan",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "minutes before a booking the table shows as reserved",
+                        "name": "reserved_within",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Store a new food",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Food"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controllers.FloorSection"
+                            }
                         }
                     }
                 }
             }
         },
-        "/foods/{food_id}": {
+        "/foods": {
             "get": {
-                "description": "Responds with the food with provided ID as JSON.",
+                "description": "Responds with a page of foods, filtered by menu, name and price range.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "foods"
                 ],
-                "summary": "Get single food by ID",
+                "summary": "Get all foods",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based page, used when offset is not given",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only foods of the menu",
+                        "name": "menu_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only foods with this name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "lowest price, e.g. 4.50",
+                        "name": "min_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "highest price",
+                        "name": "max_price",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "name, price, created_at or updated_at, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also list deleted records, admins only",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Food"
+                            "$ref": "#/definitions/controllers.ListResponse"
                         }
                     }
                 }
             },
-            "patch": {
-                "description": "Takes a food JSON and update food stored in DB. Return saved JSON.",
+            "post": {
+                "description": "Takes a food JSON and store in DB. The ingredients of its recipe must exist, out_of_stock is worked out from their stock. Return saved JSON.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "foods"
                 ],
-                "summary": "Update a food",
+                "summary": "Store a new food",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/models.Food"
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/invoices": {
+        "/foods/{food_id}": {
             "get": {
-                "description": "Responds with the list of all invoices as JSON.",
+                "description": "Responds with the food with provided ID as JSON.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "invoices"
+                    "foods"
                 ],
-                "summary": "Get all invoices",
+                "summary": "Get single food by ID",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Invoice"
+                            "$ref": "#/definitions/models.Food"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the food"
                             }
                         }
                     }
                 }
             },
-            "post": {
-                "description": "Takes a invoice JSON and store in DB. Return saved JSON.",
+            "delete": {
+                "description": "Marks the food deleted, it is hidden from the list of foods and cannot be ordered until restored. A food the kitchen is still preparing for an order cannot be deleted.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "invoices"
+                    "foods"
                 ],
-                "summary": "Store invoice by ID",
+                "summary": "Delete a food",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Invoice"
+                            "$ref": "#/definitions/models.Food"
                         }
                     }
                 }
-            }
-        },
-        "/invoices/{invoice_id}": {
-            "get": {
-                "description": "Responds with the invoice with provided ID as JSON",
+            },
+            "patch": {
+                "description": "Takes a food JSON and update food stored in DB. A recipe replaces the current one, its ingredients must exist and out_of_stock is worked out again. Return saved JSON.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "invoices"
+                    "foods"
+                ],
+                "summary": "Update a food",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of the food as read, the update is refused with 409 when it changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
                 ],
-                "summary": "Get single invoice by ID",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Invoice"
+                            "$ref": "#/definitions/models.Food"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the food"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Takes an invoice JSON and update invoice stored in DB. Return saved JSON.",
+            }
+        },
+        "/foods/{food_id}/restore": {
+            "post": {
+                "description": "Clears the deletion of the food. The menu of the food must not be deleted.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "invoices"
+                    "foods"
                 ],
-                "summary": "Update an invoice",
+                "summary": "Restore a deleted food",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Invoice"
+                            "$ref": "#/definitions/models.Food"
                         }
                     }
                 }
             }
         },
-        "/menus": {
+        "/healthz": {
             "get": {
-                "description": "Responds with the list of all menus as JSON.",
+                "description": "Responds with 200 as long as the process serves requests. It does not look at the dependencies.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "menus"
+                    "health"
                 ],
-                "summary": "Get all menus",
+                "summary": "Liveness probe",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Menu"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Takes a menu JSON and store in DB. Return saved JSON.",
+            }
+        },
+        "/ingredients": {
+            "get": {
+                "description": "Responds with a page of ingredients, filtered by name, unit and whether they are low on stock.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "menus"
+                    "ingredients"
+                ],
+                "summary": "Get all ingredients",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based page, used when offset is not given",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only ingredients with this name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "g, kg, ml, l or piece",
+                        "name": "unit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only ingredients at or below their low stock threshold, or only the others",
+                        "name": "low_stock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "name, on_hand, created_at or updated_at, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also list deleted records, admins only",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Store a new menu",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Menu"
+                            "$ref": "#/definitions/controllers.ListResponse"
                         }
                     }
                 }
-            }
-        },
-        "/menus/{menu_id}": {
-            "get": {
-                "description": "Responds with the menu with provided ID as JSON.",
+            },
+            "post": {
+                "description": "Takes an ingredient JSON and store in DB. A stock on hand is recorded as the first count of the ingredient, the supplier must exist. Return saved JSON.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "menus"
+                    "ingredients"
                 ],
-                "summary": "Get single menu by ID",
+                "summary": "Store a new ingredient",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Menu"
+                            "$ref": "#/definitions/models.Ingredient"
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Takes a menu JSON and update menu stored in DB. Return saved JSON.",
+            }
+        },
+        "/ingredients/reorder": {
+            "get": {
+                "description": "Responds with the ingredients with a par level that should be reordered. The usage of every ingredient is averaged over the ordered items of the last days, and the suggested quantity covers the par level and the usage expected during the lead time of the supplier, less the stock on hand and still to be delivered.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "menus"
+                    "purchaseOrders"
+                ],
+                "summary": "Get the suggested reorders",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "days of ordered items to average the usage over, 14 by default, at most 365",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only ingredients bought from the supplier",
+                        "name": "supplier_id",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Update a menu",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Menu"
+                            "$ref": "#/definitions/controllers.ReorderReport"
                         }
                     }
                 }
             }
         },
-        "/orderItems": {
+        "/ingredients/{ingredient_id}": {
             "get": {
-                "description": "Responds with the list of all foods as JSON.",
+                "description": "Responds with the ingredient with provided ID as JSON.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orderItems"
+                    "ingredients"
                 ],
-                "summary": "Get all ordered items",
+                "summary": "Get single ingredient by ID",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.OrderItem"
+                            "$ref": "#/definitions/models.Ingredient"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the ingredient"
                             }
                         }
                     }
                 }
             },
-            "post": {
-                "description": "Takes a ordered item JSON and store in DB. Return saved JSON.",
+            "delete": {
+                "description": "Marks the ingredient deleted, it is hidden from the list of ingredients and its stock is no longer tracked until restored. An ingredient in the recipe of a food cannot be deleted.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orderItems"
+                    "ingredients"
                 ],
-                "summary": "Store a new ordered item",
+                "summary": "Delete an ingredient",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.OrderItem"
+                            "$ref": "#/definitions/models.Ingredient"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
-            }
-        },
-        "/orderItems-order/{order_id}": {
-            "get": {
-                "description": "Responds with the ordered items of an order with provided order's ID.",
+            },
+            "patch": {
+                "description": "Takes an ingredient JSON and changes its name, unit, low stock threshold, par level and supplier. The stock only changes through adjustments, fired orders and received purchase orders. Return saved JSON.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orderItems"
+                    "ingredients"
+                ],
+                "summary": "Update an ingredient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of the ingredient as read, the update is refused with 409 when it changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
                 ],
-                "summary": "Get ordered items order's ID",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.OrderItem"
+                            "$ref": "#/definitions/models.Ingredient"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the ingredient"
                             }
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/orderItems/{order_item_id}": {
-            "get": {
-                "description": "Responds with the ordered item with provided ID as JSON.",
+        "/ingredients/{ingredient_id}/adjustments": {
+            "post": {
+                "description": "Takes a stock adjustment JSON: a DELIVERY adds quantity to the stock, WASTE takes it away and a COUNT sets the stock to what was counted. Every adjustment is recorded as a stock movement, and the foods using the ingredient are 86'd or back on once it runs out or is restocked. Waste of more than is on hand is refused with 409.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orderItems"
+                    "ingredients"
                 ],
-                "summary": "Get single ordered item by ID",
+                "summary": "Adjust the stock of an ingredient",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.OrderItem"
+                            "$ref": "#/definitions/controllers.StockAdjustmentResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the ingredient"
+                            }
                         }
-                    }
-                }
-            },
-            "patch": {
-                "description": "Takes a ordered item JSON and update ordered item stored in DB. Return saved JSON.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "orderItems"
-                ],
-                "summary": "Update a ordered item",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/models.OrderItem"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/orders": {
+        "/ingredients/{ingredient_id}/movements": {
             "get": {
-                "description": "Responds with the list of all orders as JSON.",
+                "description": "Responds with a page of the changes of the stock of the ingredient, newest first, filtered by kind, ordered item, purchase order and time range. Usage is recorded when the kitchen fires an ordered item, deliveries when a purchase order is received.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "ingredients"
+                ],
+                "summary": "Get the stock movements of an ingredient",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based page, used when offset is not given",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated kinds: DELIVERY, WASTE, COUNT, USAGE",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the usage of the ordered item",
+                        "name": "order_item_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the deliveries of the purchase order",
+                        "name": "purchase_order_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "movements made at or after, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "movements made before, RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created_at, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all orders",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Order"
-                            }
+                            "$ref": "#/definitions/controllers.ListResponse"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/ingredients/{ingredient_id}/restore": {
             "post": {
-                "description": "Takes a order JSON and store in DB. Return saved JSON.",
+                "description": "Clears the deletion of the ingredient.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "ingredients"
                 ],
-                "summary": "Store a new order",
+                "summary": "Restore a deleted ingredient",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Order"
+                            "$ref": "#/definitions/models.Ingredient"
                         }
                     }
                 }
             }
         },
-        "/orders/{order_id}": {
+        "/invoices": {
             "get": {
-                "description": "Responds with the order with provided ID as JSON.",
+                "description": "Responds with a page of invoices, newest first, filtered by order, payment status and method and due date.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "invoices"
+                ],
+                "summary": "Get all invoices",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based page, used when offset is not given",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the invoice of the order",
+                        "name": "order_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated payment statuses",
+                        "name": "payment_status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CARD or CASH",
+                        "name": "payment_method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "due at or after, RFC 3339",
+                        "name": "due_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "due before, RFC 3339",
+                        "name": "due_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created_at, payment_due_date or updated_at, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also list deleted records, admins only",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get single order by ID",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Order"
+                            "$ref": "#/definitions/controllers.ListResponse"
                         }
                     }
                 }
             },
-            "patch": {
-                "description": "Takes a order JSON and update order stored in DB. Return saved JSON.",
+            "post": {
+                "description": "Takes a invoice JSON and store in DB. Return saved JSON. An order has at most one invoice. An invoice with nothing due is created PAID.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "invoices"
                 ],
-                "summary": "Update a order",
+                "summary": "Store invoice by ID",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Order"
+                            "$ref": "#/definitions/models.Invoice"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/tables": {
+        "/invoices/{invoice_id}": {
             "get": {
-                "description": "Responds with the list of all tables as JSON.",
+                "description": "Responds with the invoice with provided ID as JSON",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tables"
+                    "invoices"
                 ],
-                "summary": "Get all tables",
+                "summary": "Get single invoice by ID",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Table"
+                            "$ref": "#/definitions/controllers.InvoiceViewFormat"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the invoice"
                             }
                         }
                     }
                 }
             },
-            "post": {
-                "description": "Takes a table JSON and store in DB. Return saved JSON.",
+            "delete": {
+                "description": "Marks the invoice deleted, it is hidden from the list of invoices until restored. An invoice with recorded payments cannot be deleted.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tables"
+                    "invoices"
                 ],
-                "summary": "Store a new table",
+                "summary": "Delete an invoice",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Table"
+                            "$ref": "#/definitions/models.Invoice"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Takes an invoice JSON and update invoice stored in DB. Return saved JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invoices"
+                ],
+                "summary": "Update an invoice",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of the invoice as read, the update is refused with 409 when it changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Invoice"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the invoice"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/tables/{table_id}": {
-            "get": {
-                "description": "Responds with the table with provided ID as JSON.",
+        "/invoices/{invoice_id}/payments": {
+            "post": {
+                "description": "Takes a payment JSON (method, amount, tip, reference and optionally the split it pays) and adds it to the invoice. The payment may not exceed the balance due. The invoice becomes PARTIALLY_PAID, and PAID once the balance reaches zero.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tables"
+                    "invoices"
                 ],
-                "summary": "Get single table by ID",
+                "summary": "Record a payment",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Table"
+                            "$ref": "#/definitions/controllers.InvoiceViewFormat"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Takes a table JSON and update table stored in DB. Return saved JSON.",
+            }
+        },
+        "/invoices/{invoice_id}/restore": {
+            "post": {
+                "description": "Clears the deletion of the invoice. Its order must not be deleted.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tables"
+                    "invoices"
                 ],
-                "summary": "Update a table",
+                "summary": "Restore a deleted invoice",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Table"
+                            "$ref": "#/definitions/models.Invoice"
                         }
                     }
                 }
             }
         },
-        "/users": {
+        "/invoices/{invoice_id}/splits": {
+            "post": {
+                "description": "Divides the amount due of the invoice into splits. EVEN takes the number of parts, SEAT splits by the seat of the ordered items (items without a seat are shared evenly) and ITEM takes groups of ordered item IDs covering every item. Taxes and service charge are shared in proportion. Replaces earlier splits as long as no payment was taken against them.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invoices"
+                ],
+                "summary": "Split an invoice",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.InvoiceViewFormat"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/kitchen/items/{order_item_id}/bump": {
+            "post": {
+                "description": "Moves the ordered item to its next preparation status (QUEUED -\u003e COOKING -\u003e READY -\u003e SERVED). Firing a QUEUED item takes the ingredients of its food from the stock, it is refused with 409 when a required one is short.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "kitchen"
+                ],
+                "summary": "Bump an ordered item",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderItem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/kitchen/items/{order_item_id}/status": {
+            "post": {
+                "description": "Takes a status JSON and moves the ordered item to it, used to void, re-fire or serve items. Items of an invoiced order cannot be voided. Moving a QUEUED item to COOKING takes the ingredients of its food from the stock, it is refused with 409 when a required one is short.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "kitchen"
+                ],
+                "summary": "Set the preparation status of an ordered item",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderItem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/kitchen/tickets": {
             "get": {
-                "description": "Responds with the list of all users as JSON.",
+                "description": "Responds with the ordered items that are QUEUED, COOKING or READY grouped into one ticket per order, oldest ticket first. Tickets and items older than late_after minutes (default 15) are flagged late.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "kitchen"
+                ],
+                "summary": "Get the kitchen queue",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "minutes after which a ticket is late",
+                        "name": "late_after",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all users",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.User"
+                                "$ref": "#/definitions/controllers.KitchenTicket"
                             }
                         }
                     }
                 }
             }
         },
-        "/users/login": {
+        "/menus": {
+            "get": {
+                "description": "Responds with a page of menus, filtered by category and name.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "menus"
+                ],
+                "summary": "Get all menus",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based page, used when offset is not given",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only menus of the category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only menus with this name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "name, category, start_date, end_date or created_at, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also list deleted records, admins only",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ListResponse"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Log a user in.",
+                "description": "Takes a menu JSON and store in DB. Return saved JSON.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "menus"
                 ],
-                "summary": "Log a user in..",
+                "summary": "Store a new menu",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.Menu"
                         }
                     }
                 }
             }
         },
-        "/users/signup": {
+        "/menus/active": {
+            "get": {
+                "description": "Responds with the menus that are served at the given time, within their start and end dates and one of their schedule windows in the time zone of the restaurant, each with its available foods that are not 86'd.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "menus"
+                ],
+                "summary": "Get the menus served now",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time, now by default",
+                        "name": "at",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ActiveMenusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/menus/{menu_id}": {
+            "get": {
+                "description": "Responds with the menu with provided ID as JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "menus"
+                ],
+                "summary": "Get single menu by ID",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Menu"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the menu"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Marks the menu deleted, it is hidden from the list of menus until restored. A menu that still has foods cannot be deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "menus"
+                ],
+                "summary": "Delete a menu",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Menu"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Takes a menu JSON and update menu stored in DB. Return saved JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "menus"
+                ],
+                "summary": "Update a menu",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of the menu as read, the update is refused with 409 when it changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Menu"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the menu"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/menus/{menu_id}/restore": {
             "post": {
-                "description": "Create a new user.",
+                "description": "Clears the deletion of the menu.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "menus"
                 ],
-                "summary": "Create a new user.",
+                "summary": "Restore a deleted menu",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.Menu"
                         }
                     }
                 }
             }
         },
-        "/users/{user_id}": {
+        "/notes": {
             "get": {
-                "description": "Responds with the user with provided ID as JSON.",
+                "description": "Responds with a page of notes, oldest first, filtered by the record they are attached to and by author.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "notes"
+                ],
+                "summary": "Get all notes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based page, used when offset is not given",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ORDER, ORDER_ITEM, TABLE or RESERVATION",
+                        "name": "parent_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only notes on the record with this ID",
+                        "name": "parent_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only notes written by the user",
+                        "name": "author_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created_at or updated_at, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also list deleted records, admins only",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get single user by ID",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/controllers.ListResponse"
                         }
                     }
                 }
+            },
+            "post": {
+                "description": "Takes a note JSON attached to an order, an ordered item, a table or a reservation and store in DB. The author is the user making the request. Return saved JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Store a new note",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Note"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{note_id}": {
+            "get": {
+                "description": "Responds with the note with provided ID as JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Get single note by ID",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Note"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the note"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Marks the note deleted, it is hidden from the list of notes, the bills and the kitchen until restored. Only the author, managers and admins may delete a note.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Delete a note",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Note"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Takes a note JSON and changes its title and text. Only the author, managers and admins may change a note. Return saved JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Update a note",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of the note as read, the update is refused with 409 when it changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Note"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the note"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{note_id}/restore": {
+            "post": {
+                "description": "Clears the deletion of the note. The record it is attached to must not be deleted. Only the author, managers and admins may restore a note.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notes"
+                ],
+                "summary": "Restore a deleted note",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Note"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orderItems": {
+            "get": {
+                "description": "Responds with a page of ordered items, filtered by order, food and preparation status.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderItems"
+                ],
+                "summary": "Get all ordered items",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based page, used when offset is not given",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only items of the order",
+                        "name": "order_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only items of the food",
+                        "name": "food_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated preparation statuses",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created_at, status_updated_at or quantity, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also list deleted records, admins only",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ListResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Takes a ordered item JSON and store in DB. Return saved JSON. Foods that are 86'd or whose menu is not served at the time of the order are refused with 409.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderItems"
+                ],
+                "summary": "Store a new ordered item",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderItem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orderItems-order/{order_id}": {
+            "get": {
+                "description": "Responds with the ordered items of an order with provided order's ID, priced with line totals, taxes and service charge.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderItems"
+                ],
+                "summary": "Get ordered items order's ID",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.OrderBill"
+                        }
+                    }
+                }
+            }
+        },
+        "/orderItems/{order_item_id}": {
+            "get": {
+                "description": "Responds with the ordered item with provided ID as JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderItems"
+                ],
+                "summary": "Get single ordered item by ID",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderItem"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the ordered item"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Marks the ordered item deleted, it is hidden from the lists of ordered items, the kitchen and the bill until restored. An item of an invoiced order cannot be deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderItems"
+                ],
+                "summary": "Delete an ordered item",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderItem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Takes a ordered item JSON and update ordered item stored in DB. Return saved JSON. The unit price always follows the food and its modifiers. An item of an invoiced order cannot be changed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderItems"
+                ],
+                "summary": "Update a ordered item",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of the ordered item as read, the update is refused with 409 when it changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderItem"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the ordered item"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orderItems/{order_item_id}/restore": {
+            "post": {
+                "description": "Clears the deletion of the ordered item. Its order must not be deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orderItems"
+                ],
+                "summary": "Restore a deleted ordered item",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderItem"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders": {
+            "get": {
+                "description": "Responds with a page of orders, newest first, filtered by table, status and date range.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get all orders",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based page, used when offset is not given",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only orders of the table",
+                        "name": "table_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated order statuses",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "orders dated at or after, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "orders dated before, RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "order_date, created_at or updated_at, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also list deleted records, admins only",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ListResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Takes a order JSON and store in DB. Return saved JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Store a new order",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}": {
+            "get": {
+                "description": "Responds with the order with provided ID as JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Get single order by ID",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the order"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Marks the order deleted, it is hidden from the list of orders until restored. An order with an invoice or with items the kitchen is still preparing cannot be deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Delete an order",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Takes a order JSON and update order stored in DB. Return saved JSON. Moving an open order to another table marks the new table ORDERED and makes the old one AVAILABLE once it has no open order left.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Update a order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of the order as read, the update is refused with 409 when it changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the order"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/restore": {
+            "post": {
+                "description": "Clears the deletion of the order. The table of the order must not be deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Restore a deleted order",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{order_id}/transitions": {
+            "post": {
+                "description": "Takes a status JSON and moves the order to it if the lifecycle allows it (OPEN -\u003e SENT_TO_KITCHEN -\u003e SERVED -\u003e BILLED -\u003e CLOSED, or CANCELLED). The change is appended to the status history of the order. Cancelling voids the items the kitchen has not served yet and is refused for an invoiced order; the table goes back to SEATED once it has no open order left.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Change the status of an order",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/purchaseOrders": {
+            "get": {
+                "description": "Responds with a page of purchase orders, newest first, filtered by supplier, status and expected delivery.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchaseOrders"
+                ],
+                "summary": "Get all purchase orders",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based page, used when offset is not given",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only purchase orders placed with the supplier",
+                        "name": "supplier_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated statuses: ORDERED, PARTIAL, RECEIVED, CANCELLED",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "expected at or after, RFC 3339",
+                        "name": "expected_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "expected before, RFC 3339",
+                        "name": "expected_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created_at or expected_at, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also list deleted records, admins only",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ListResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Takes a purchase order JSON with a supplier and lines of ingredients and store in DB as ORDERED. Without an expected delivery it is expected after the lead time of the supplier. Return saved JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchaseOrders"
+                ],
+                "summary": "Place a purchase order",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PurchaseOrder"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/purchaseOrders/{purchase_order_id}": {
+            "get": {
+                "description": "Responds with the purchase order with provided ID as JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchaseOrders"
+                ],
+                "summary": "Get single purchase order by ID",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PurchaseOrder"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the purchase order"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Marks the purchase order deleted, it is hidden from the list of purchase orders until restored. A purchase order some of which was received cannot be deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchaseOrders"
+                ],
+                "summary": "Delete a purchase order",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PurchaseOrder"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Takes a purchase order JSON and changes its expected delivery, its lines while nothing is received, or cancels it with status CANCELLED. Received and cancelled orders cannot be changed. Return saved JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchaseOrders"
+                ],
+                "summary": "Update a purchase order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of the purchase order as read, the update is refused with 409 when it changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PurchaseOrder"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the purchase order"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/purchaseOrders/{purchase_order_id}/receipts": {
+            "post": {
+                "description": "Takes a receipt JSON with the quantities of the ingredients delivered, or no lines when all that is still to be received arrived. The quantities are added to the stock as deliveries and the order becomes PARTIAL or RECEIVED. Receiving more than is still to be received is refused.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchaseOrders"
+                ],
+                "summary": "Receive a purchase order",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.PurchaseReceiptResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the purchase order"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/purchaseOrders/{purchase_order_id}/restore": {
+            "post": {
+                "description": "Clears the deletion of the purchase order. Its supplier must not be deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "purchaseOrders"
+                ],
+                "summary": "Restore a deleted purchase order",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PurchaseOrder"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Pings every dependency (MongoDB and its required indexes) and reports its status and latency. Responds with 503 when one of them is down, so no traffic should be routed to the instance.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Readiness"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Readiness"
+                        }
+                    }
+                }
+            }
+        },
+        "/reservations": {
+            "get": {
+                "description": "Responds with the reservations starting between from and to (RFC 3339, without either: the next 24 hours), optionally only those with the given statuses or table, ordered by start time.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reservations"
+                ],
+                "summary": "Get reservations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based page, used when offset is not given",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "start of the window",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "end of the window",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated reservation statuses",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only reservations of the table",
+                        "name": "table_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "start_time, party_size or created_at, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ListResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Takes a reservation JSON and books the requested table, or the smallest free table large enough when no table_id is given. A table already booked in an overlapping window is refused with 409.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reservations"
+                ],
+                "summary": "Book a table",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Reservation"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/reservations/availability": {
+            "get": {
+                "description": "Responds with the tables fitting party_size guests (number_of_guests or max_capacity at least party_size, min_capacity at most) that have no reservation overlapping start (RFC 3339) to start + duration minutes (default 90), smallest table first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reservations"
+                ],
+                "summary": "Search available tables",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "start of the booking",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "length of the booking in minutes",
+                        "name": "duration",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of guests",
+                        "name": "party_size",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Table"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/reservations/no-shows": {
+            "post": {
+                "description": "Moves every BOOKED reservation that started more than grace minutes ago (default 15) to NO_SHOW and releases its table. Responds with the reservations marked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reservations"
+                ],
+                "summary": "Mark late reservations as no-shows",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "minutes a party may be late",
+                        "name": "grace",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Reservation"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/reservations/{reservation_id}": {
+            "get": {
+                "description": "Responds with the reservation with provided ID as JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reservations"
+                ],
+                "summary": "Get single reservation by ID",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Reservation"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the reservation"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Takes a reservation JSON and updates the guest, party size, time, duration or table of a BOOKED reservation. A new time or table is booked before the old one is released, a conflict is refused with 409 and leaves the reservation unchanged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reservations"
+                ],
+                "summary": "Modify a reservation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of the reservation as read, the update is refused with 409 when it changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Reservation"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the reservation"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/reservations/{reservation_id}/cancel": {
+            "post": {
+                "description": "Moves the reservation to SEATED, COMPLETED, CANCELLED or NO_SHOW. Cancelled, completed and no-show reservations release their table.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reservations"
+                ],
+                "summary": "Seat, complete, cancel or mark a reservation as no-show",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Reservation"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/reservations/{reservation_id}/complete": {
+            "post": {
+                "description": "Moves the reservation to SEATED, COMPLETED, CANCELLED or NO_SHOW. Cancelled, completed and no-show reservations release their table.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reservations"
+                ],
+                "summary": "Seat, complete, cancel or mark a reservation as no-show",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Reservation"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/reservations/{reservation_id}/no-show": {
+            "post": {
+                "description": "Moves the reservation to SEATED, COMPLETED, CANCELLED or NO_SHOW. Cancelled, completed and no-show reservations release their table.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reservations"
+                ],
+                "summary": "Seat, complete, cancel or mark a reservation as no-show",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Reservation"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/reservations/{reservation_id}/seat": {
+            "post": {
+                "description": "Moves the reservation to SEATED, COMPLETED, CANCELLED or NO_SHOW. Cancelled, completed and no-show reservations release their table.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reservations"
+                ],
+                "summary": "Seat, complete, cancel or mark a reservation as no-show",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Reservation"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "description": "Responds with the foods and menus matching every word of q, ranked by relevance. Foods are matched by name, description and the name and category of their menu, menus by name and category. Words being typed match by their start, and words of 4 letters or more tolerate a typo after their first two letters, two from 8 letters.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Search the catalog",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "searched text",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "only that menu and its foods",
+                        "name": "menu_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only foods that are, or are not, available",
+                        "name": "available",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "foods and menus returned, each, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SearchResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/suppliers": {
+            "get": {
+                "description": "Responds with a page of suppliers, filtered by name.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "suppliers"
+                ],
+                "summary": "Get all suppliers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based page, used when offset is not given",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only suppliers with this name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "name, created_at or updated_at, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also list deleted records, admins only",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ListResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Takes a supplier JSON and store in DB. Return saved JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "suppliers"
+                ],
+                "summary": "Store a new supplier",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Supplier"
+                        }
+                    }
+                }
+            }
+        },
+        "/suppliers/{supplier_id}": {
+            "get": {
+                "description": "Responds with the supplier with provided ID as JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "suppliers"
+                ],
+                "summary": "Get single supplier by ID",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Supplier"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the supplier"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Marks the supplier deleted, it is hidden from the list of suppliers and cannot be ordered from until restored. A supplier with purchase orders still to be delivered cannot be deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "suppliers"
+                ],
+                "summary": "Delete a supplier",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Supplier"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Takes a supplier JSON and changes its name, contact details and lead time. Return saved JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "suppliers"
+                ],
+                "summary": "Update a supplier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of the supplier as read, the update is refused with 409 when it changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Supplier"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the supplier"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/suppliers/{supplier_id}/restore": {
+            "post": {
+                "description": "Clears the deletion of the supplier.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "suppliers"
+                ],
+                "summary": "Restore a deleted supplier",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Supplier"
+                        }
+                    }
+                }
+            }
+        },
+        "/tables": {
+            "get": {
+                "description": "Responds with a page of tables, filtered by status, section and size.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Get all tables",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based page, used when offset is not given",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated table statuses",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only tables of the section",
+                        "name": "section",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "only tables seating at least that many guests",
+                        "name": "min_guests",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "table_number, number_of_guests, section or created_at, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "also list deleted records, admins only",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ListResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Takes a table JSON and store in DB. Return saved JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Store a new table",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Table"
+                        }
+                    }
+                }
+            }
+        },
+        "/tables/{table_id}": {
+            "get": {
+                "description": "Responds with the table with provided ID as JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Get single table by ID",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Table"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the table"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Marks the table deleted, it is hidden from the list of tables and the floor plan and cannot be booked until restored. A table with an open order or an upcoming reservation cannot be deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Delete a table",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Table"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Takes a table JSON and update table stored in DB. Return saved JSON.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Update a table",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of the table as read, the update is refused with 409 when it changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Table"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the table"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/tables/{table_id}/restore": {
+            "post": {
+                "description": "Clears the deletion of the table.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Restore a deleted table",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Table"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "description": "Responds with a page of users, filtered by role and email.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get all users",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page size, 20 by default, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of items to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based page, used when offset is not given",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated roles",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the user with this email",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "first_name, last_name, email or created_at, prefixed with - for descending",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ListResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/login": {
+            "post": {
+                "description": "Log a user in.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Log a user in..",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AuthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/logout": {
+            "post": {
+                "description": "Revokes the token and refresh token of the authenticated user. Tokens issued before the logout are rejected afterwards.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Log a user out",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/refresh": {
+            "post": {
+                "description": "Takes a refresh token and responds with a new token and refresh token. The old refresh token stops working; presenting it again revokes every token of the user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Refresh the tokens of a user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/signup": {
+            "post": {
+                "description": "Create a new user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a new user.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.AuthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}": {
+            "get": {
+                "description": "Responds with the user with provided ID as JSON. Users may read themselves, managers and admins anyone.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get single user by ID",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the user"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}/role": {
+            "patch": {
+                "description": "Takes a role JSON and assigns it to the user. Admin only. The tokens of the user are revoked, the new role applies from their next login.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Change the role of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of the user as read, the update is refused with 409 when it changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the user"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "controllers.ActiveMenu": {
+            "type": "object",
+            "required": [
+                "category",
+                "name"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "food_id": {
+                    "type": "string"
+                },
+                "foods": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Food"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "schedule": {
+                    "description": "Schedule lists when the menu is served between its start and end\ndates, in the time zone of the restaurant. Without windows it is\nserved all day.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MenuWindow"
+                    }
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.ActiveMenusResponse": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "menus": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.ActiveMenu"
+                    }
+                }
+            }
+        },
+        "controllers.AuthResponse": {
+            "type": "object",
+            "required": [
+                "email",
+                "first_name",
+                "last_name",
+                "phone"
+            ],
+            "properties": {
+                "avatar": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "first_name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.DependencyStatus": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.FloorSection": {
+            "type": "object",
+            "properties": {
+                "section": {
+                    "type": "string"
+                },
+                "tables": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.FloorTable"
+                    }
+                }
+            }
+        },
+        "controllers.FloorTable": {
+            "type": "object",
+            "required": [
+                "number_of_guests",
+                "table_number"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_capacity": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "min_capacity": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "next_reservation": {
+                    "type": "string"
+                },
+                "number_of_guests": {
+                    "type": "integer"
+                },
+                "position_x": {
+                    "type": "number"
+                },
+                "position_y": {
+                    "type": "number"
+                },
+                "section": {
+                    "type": "string"
+                },
+                "shape": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "table_id": {
+                    "type": "string"
+                },
+                "table_number": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.FoodResult": {
+            "type": "object",
+            "required": [
+                "food_image",
+                "menu_id",
+                "name",
+                "price"
+            ],
+            "properties": {
+                "available": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "food_id": {
+                    "type": "string"
+                },
+                "food_image": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "menu_id": {
+                    "type": "string"
+                },
+                "modifier_groups": {
+                    "description": "Modifier_groups are the choices offered when the food is ordered.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ModifierGroup"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2
+                },
+                "out_of_stock": {
+                    "description": "Out_of_stock is set while a required ingredient of the recipe is\nshort of one portion: the food is 86'd and cannot be ordered.",
+                    "type": "boolean"
+                },
+                "price": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "recipe": {
+                    "description": "Recipe lists the ingredients taken from the stock for every portion\nthe kitchen fires.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RecipeIngredient"
+                    }
+                },
+                "score": {
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.InvoiceViewFormat": {
+            "type": "object",
+            "properties": {
+                "amount_paid": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "balance_due": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "invoice_id": {
+                    "type": "string"
+                },
+                "order_details": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/helpers.BillLine"
+                    }
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "payment_due": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "payment_due_date": {
+                    "type": "string"
+                },
+                "payment_method": {
+                    "type": "string"
+                },
+                "payment_status": {
+                    "type": "string"
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Payment"
+                    }
+                },
+                "rounding_adjustment": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "service_charge": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "service_charge_rate": {
+                    "type": "number"
+                },
+                "splits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.SplitView"
+                    }
+                },
+                "subtotal": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "table_number": {
+                    "type": "integer"
+                },
+                "tax_total": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "taxes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/helpers.TaxLine"
+                    }
+                },
+                "tip_total": {
+                    "$ref": "#/definitions/models.Money"
+                }
+            }
+        },
+        "controllers.KitchenTicket": {
+            "type": "object",
+            "properties": {
+                "age_seconds": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "is_late": {
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.KitchenTicketItem"
+                    }
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "table_id": {
+                    "type": "string"
+                },
+                "table_number": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.KitchenTicketItem": {
+            "type": "object",
+            "properties": {
+                "age_seconds": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "food_id": {
+                    "type": "string"
+                },
+                "food_name": {
+                    "type": "string"
+                },
+                "is_late": {
+                    "type": "boolean"
+                },
+                "modifiers": {
+                    "description": "Modifiers are the picked options, e.g. \"Size: Large\".",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "notes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "order_item_id": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "status_updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.ListResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "items": {},
+                "limit": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.MenuResult": {
+            "type": "object",
+            "required": [
+                "category",
+                "name"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "food_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "schedule": {
+                    "description": "Schedule lists when the menu is served between its start and end\ndates, in the time zone of the restaurant. Without windows it is\nserved all day.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MenuWindow"
+                    }
+                },
+                "score": {
+                    "type": "number"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.OrderBill": {
+            "type": "object",
+            "properties": {
+                "order_id": {
+                    "type": "string"
+                },
+                "order_items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/helpers.BillLine"
+                    }
+                },
+                "rounding_adjustment": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "service_charge": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "service_charge_rate": {
+                    "type": "number"
+                },
+                "subtotal": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "table_id": {
+                    "type": "string"
+                },
+                "table_number": {
+                    "type": "integer"
+                },
+                "tax_total": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "taxes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/helpers.TaxLine"
+                    }
+                },
+                "total": {
+                    "$ref": "#/definitions/models.Money"
+                }
+            }
+        },
+        "controllers.PurchaseReceiptResponse": {
+            "type": "object",
+            "properties": {
+                "movements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StockMovement"
+                    }
+                },
+                "purchase_order": {
+                    "$ref": "#/definitions/models.PurchaseOrder"
+                }
+            }
+        },
+        "controllers.Readiness": {
+            "type": "object",
+            "properties": {
+                "dependencies": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/controllers.DependencyStatus"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.ReorderLine": {
+            "type": "object",
+            "properties": {
+                "average_daily_usage": {
+                    "type": "number"
+                },
+                "ingredient_id": {
+                    "type": "string"
+                },
+                "lead_time_days": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "on_hand": {
+                    "type": "number"
+                },
+                "on_order": {
+                    "type": "number"
+                },
+                "par_level": {
+                    "type": "number"
+                },
+                "suggested_quantity": {
+                    "type": "number"
+                },
+                "supplier_id": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.ReorderReport": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.ReorderLine"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.SearchResponse": {
+            "type": "object",
+            "properties": {
+                "foods": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.FoodResult"
+                    }
+                },
+                "menus": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.MenuResult"
+                    }
+                },
+                "query": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.SplitView": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "amount_paid": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "balance_due": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "order_item_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "seat": {
+                    "type": "integer"
+                },
+                "split_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.StockAdjustmentResponse": {
+            "type": "object",
+            "properties": {
+                "ingredient": {
+                    "$ref": "#/definitions/models.Ingredient"
+                },
+                "movement": {
+                    "$ref": "#/definitions/models.StockMovement"
+                }
+            }
+        },
+        "helpers.BillLine": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "food_id": {
+                    "type": "string"
+                },
+                "food_image": {
+                    "type": "string"
+                },
+                "food_name": {
+                    "type": "string"
+                },
+                "line_total": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "modifiers": {
+                    "description": "Modifiers are the options picked for the item, their price deltas\nare part of the unit price.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderItemModifier"
+                    }
+                },
+                "notes": {
+                    "description": "Notes are the texts of the notes on the item, e.g. \"no onions\".",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "order_item_id": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "seat": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tax_rate": {
+                    "type": "number"
+                },
+                "unit_price": {
+                    "$ref": "#/definitions/models.Money"
+                }
+            }
+        },
+        "helpers.TaxLine": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "number"
+                },
+                "taxable": {
+                    "$ref": "#/definitions/models.Money"
+                }
+            }
+        },
+        "models.Food": {
+            "type": "object",
+            "required": [
+                "food_image",
+                "menu_id",
+                "name",
+                "price"
+            ],
+            "properties": {
+                "available": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "food_id": {
+                    "type": "string"
+                },
+                "food_image": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "menu_id": {
+                    "type": "string"
+                },
+                "modifier_groups": {
+                    "description": "Modifier_groups are the choices offered when the food is ordered.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ModifierGroup"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2
+                },
+                "out_of_stock": {
+                    "description": "Out_of_stock is set while a required ingredient of the recipe is\nshort of one portion: the food is 86'd and cannot be ordered.",
+                    "type": "boolean"
+                },
+                "price": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "recipe": {
+                    "description": "Recipe lists the ingredients taken from the stock for every portion\nthe kitchen fires.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RecipeIngredient"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Ingredient": {
+            "type": "object",
+            "required": [
+                "name",
+                "unit"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ingredient_id": {
+                    "type": "string"
+                },
+                "low_stock": {
+                    "type": "boolean"
+                },
+                "low_stock_threshold": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "on_hand": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "par_level": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "supplier_id": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string",
+                    "enum": [
+                        "g",
+                        "kg",
+                        "ml",
+                        "l",
+                        "piece"
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Invoice": {
+            "type": "object",
+            "required": [
+                "payment_status"
+            ],
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "invoice_id": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "payment_due_date": {
+                    "type": "string"
+                },
+                "payment_method": {
+                    "type": "string"
+                },
+                "payment_status": {
+                    "type": "string"
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Payment"
+                    }
+                },
+                "splits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.InvoiceSplit"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.InvoiceSplit": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "order_item_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "seat": {
+                    "type": "integer"
+                },
+                "split_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Menu": {
+            "type": "object",
+            "required": [
+                "category",
+                "name"
+            ],
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "food_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "schedule": {
+                    "description": "Schedule lists when the menu is served between its start and end\ndates, in the time zone of the restaurant. Without windows it is\nserved all day.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MenuWindow"
+                    }
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.MenuWindow": {
+            "type": "object",
+            "required": [
+                "days",
+                "end",
+                "start"
+            ],
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "end": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ModifierGroup": {
+            "type": "object",
+            "required": [
+                "name",
+                "options"
+            ],
+            "properties": {
+                "max": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "min": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "options": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.ModifierOption"
+                    }
+                }
+            }
+        },
+        "models.ModifierOption": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "price_delta": {
+                    "$ref": "#/definitions/models.Money"
+                }
+            }
+        },
+        "models.Money": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "currency": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Note": {
+            "type": "object",
+            "required": [
+                "parent_id",
+                "parent_type",
+                "text"
+            ],
+            "properties": {
+                "author_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "note_id": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "string"
+                },
+                "parent_type": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
             }
-        }
-    },
-    "definitions": {
-        "models.Food": {
+        },
+        "models.Order": {
             "type": "object",
             "required": [
-                "food_image",
-                "menu_id",
-                "name"
+                "order_date",
+                "table_id"
             ],
             "properties": {
                 "created_at": {
                     "type": "string"
                 },
-                "food_id": {
+                "deleted_at": {
                     "type": "string"
                 },
-                "food_image": {
+                "deleted_by": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "menu_id": {
+                "order_date": {
                     "type": "string"
                 },
-                "name": {
-                    "type": "string",
-                    "maxLength": 100,
-                    "minLength": 2
+                "order_id": {
+                    "type": "string"
                 },
-                "price": {
-                    "type": "number"
+                "status": {
+                    "type": "string"
+                },
+                "status_history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderTransition"
+                    }
+                },
+                "table_id": {
+                    "type": "string"
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "models.Invoice": {
+        "models.OrderItem": {
             "type": "object",
             "required": [
-                "payment_status"
+                "food_id",
+                "order_id",
+                "quantity"
             ],
             "properties": {
                 "created_at": {
                     "type": "string"
                 },
-                "id": {
+                "deleted_at": {
                     "type": "string"
                 },
-                "invoice_id": {
+                "deleted_by": {
+                    "type": "string"
+                },
+                "food_id": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "string"
                 },
+                "modifiers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrderItemModifier"
+                    }
+                },
                 "order_id": {
                     "type": "string"
                 },
-                "payment_due_date": {
+                "order_item_id": {
                     "type": "string"
                 },
-                "payment_method": {
+                "quantity": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "seat": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "status": {
                     "type": "string"
                 },
-                "payment_status": {
+                "status_updated_at": {
                     "type": "string"
                 },
+                "unit_price": {
+                    "$ref": "#/definitions/models.Money"
+                },
                 "updated_at": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "models.Menu": {
+        "models.OrderItemModifier": {
             "type": "object",
+            "required": [
+                "group",
+                "option"
+            ],
             "properties": {
-                "category": {
+                "group": {
+                    "type": "string"
+                },
+                "option": {
+                    "type": "string"
+                },
+                "price_delta": {
+                    "$ref": "#/definitions/models.Money"
+                }
+            }
+        },
+        "models.OrderTransition": {
+            "type": "object",
+            "properties": {
+                "changed_at": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Payment": {
+            "type": "object",
+            "required": [
+                "amount",
+                "method"
+            ],
+            "properties": {
+                "amount": {
+                    "$ref": "#/definitions/models.Money"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "payment_id": {
+                    "type": "string"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "split_id": {
+                    "type": "string"
+                },
+                "taken_by": {
                     "type": "string"
                 },
+                "tip": {
+                    "$ref": "#/definitions/models.Money"
+                }
+            }
+        },
+        "models.PurchaseOrder": {
+            "type": "object",
+            "required": [
+                "lines",
+                "supplier_id"
+            ],
+            "properties": {
                 "created_at": {
                     "type": "string"
                 },
-                "end_date": {
+                "deleted_at": {
                     "type": "string"
                 },
-                "food_id": {
+                "deleted_by": {
+                    "type": "string"
+                },
+                "expected_at": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "name": {
+                "lines": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/models.PurchaseOrderLine"
+                    }
+                },
+                "ordered_by": {
                     "type": "string"
                 },
-                "start_date": {
+                "purchase_order_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "supplier_id": {
                     "type": "string"
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "models.Order": {
+        "models.PurchaseOrderLine": {
             "type": "object",
             "required": [
-                "order_date",
-                "table_id"
+                "ingredient_id"
+            ],
+            "properties": {
+                "ingredient_id": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "received": {
+                    "type": "number"
+                },
+                "unit_cost": {
+                    "$ref": "#/definitions/models.Money"
+                }
+            }
+        },
+        "models.RecipeIngredient": {
+            "type": "object",
+            "required": [
+                "ingredient_id"
+            ],
+            "properties": {
+                "ingredient_id": {
+                    "type": "string"
+                },
+                "optional": {
+                    "type": "boolean"
+                },
+                "quantity": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.Reservation": {
+            "type": "object",
+            "required": [
+                "guest_name",
+                "party_size",
+                "phone",
+                "start_time"
             ],
             "properties": {
                 "created_at": {
                     "type": "string"
                 },
+                "duration_minutes": {
+                    "type": "integer",
+                    "maximum": 720,
+                    "minimum": 15
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "guest_name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2
+                },
                 "id": {
                     "type": "string"
                 },
-                "order_date": {
+                "party_size": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "phone": {
                     "type": "string"
                 },
-                "order_id": {
+                "reservation_id": {
+                    "type": "string"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string"
                 },
                 "table_id": {
@@ -751,41 +4800,96 @@ const docTemplate = `{
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "models.OrderItem": {
+        "models.StockMovement": {
             "type": "object",
-            "required": [
-                "food_id",
-                "order_id",
-                "quantity",
-                "unit_price"
-            ],
             "properties": {
                 "created_at": {
                     "type": "string"
                 },
-                "food_id": {
+                "created_by": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "order_id": {
+                "ingredient_id": {
+                    "type": "string"
+                },
+                "kind": {
                     "type": "string"
                 },
+                "movement_id": {
+                    "type": "string"
+                },
+                "on_hand": {
+                    "type": "number"
+                },
                 "order_item_id": {
                     "type": "string"
                 },
-                "quantity": {
+                "purchase_order_id": {
                     "type": "string"
                 },
-                "unit_price": {
+                "quantity": {
                     "type": "number"
                 },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Supplier": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "contact_name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lead_time_days": {
+                    "type": "integer",
+                    "maximum": 90,
+                    "minimum": 0
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "phone": {
+                    "type": "string",
+                    "maxLength": 30
+                },
+                "supplier_id": {
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
@@ -799,12 +4903,41 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
+                "max_capacity": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "min_capacity": {
+                    "type": "integer",
+                    "minimum": 1
+                },
                 "number_of_guests": {
                     "type": "integer"
                 },
+                "position_x": {
+                    "type": "number"
+                },
+                "position_y": {
+                    "type": "number"
+                },
+                "section": {
+                    "type": "string"
+                },
+                "shape": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
                 "table_id": {
                     "type": "string"
                 },
@@ -813,23 +4946,21 @@ const docTemplate = `{
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
         "models.User": {
             "type": "object",
             "required": [
-                "Password",
                 "email",
                 "first_name",
                 "last_name",
                 "phone"
             ],
             "properties": {
-                "Password": {
-                    "type": "string",
-                    "minLength": 6
-                },
                 "avatar": {
                     "type": "string"
                 },
@@ -855,10 +4986,7 @@ const docTemplate = `{
                 "phone": {
                     "type": "string"
                 },
-                "refresh_token": {
-                    "type": "string"
-                },
-                "token": {
+                "role": {
                     "type": "string"
                 },
                 "updated_at": {
@@ -866,6 +4994,9 @@ const docTemplate = `{
                 },
                 "user_id": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         }
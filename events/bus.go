@@ -0,0 +1,163 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types published by the controllers.
+const (
+	OrderCreated           = "order.created"
+	OrderStatusChanged     = "order.status_changed"
+	OrderItemCreated       = "order_item.created"
+	OrderItemUpdated       = "order_item.updated"
+	OrderItemStatusChanged = "order_item.status_changed"
+	InvoiceCreated         = "invoice.created"
+	InvoiceUpdated         = "invoice.updated"
+	TableStatusChanged     = "table.status_changed"
+)
+
+// subscriberBuffer is how many events may wait for a slow subscriber before
+// it is dropped. A dropped client reconnects with its last event ID and
+// catches up from the history.
+const subscriberBuffer = 64
+
+type Event struct {
+	ID         uint64      `json:"id"`
+	Type       string      `json:"type"`
+	Table_id   string      `json:"table_id,omitempty"`
+	Order_id   string      `json:"order_id,omitempty"`
+	Data       interface{} `json:"data"`
+	Created_at time.Time   `json:"created_at"`
+}
+
+// Filter selects the events a subscriber receives. Empty fields match
+// everything.
+type Filter struct {
+	Types    []string
+	Table_id string
+	Order_id string
+}
+
+func (f Filter) Match(e Event) bool {
+	if f.Table_id != "" && f.Table_id != e.Table_id {
+		return false
+	}
+	if f.Order_id != "" && f.Order_id != e.Order_id {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
+
+type Subscription struct {
+	// Events is closed when the subscription ends, either by Unsubscribe or
+	// because the subscriber fell too far behind.
+	Events <-chan Event
+	events chan Event
+	filter Filter
+}
+
+// Bus is an in-process publish/subscribe hub that keeps the most recent
+// events around so reconnecting clients can resume where they left off.
+type Bus struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     []Event
+	historySize int
+	subscribers map[*Subscription]struct{}
+}
+
+func NewBus(historySize int) *Bus {
+	return &Bus{
+		historySize: historySize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish assigns the next event ID and delivers the event to every
+// matching subscriber without blocking.
+func (b *Bus) Publish(eventType, tableId, orderId string, data interface{}) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	e := Event{
+		ID:         b.lastID,
+		Type:       eventType,
+		Table_id:   tableId,
+		Order_id:   orderId,
+		Data:       data,
+		Created_at: time.Now(),
+	}
+
+	b.history = append(b.history, e)
+	if len(b.history) > b.historySize {
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+
+	for sub := range b.subscribers {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			b.remove(sub)
+		}
+	}
+	return e
+}
+
+// Subscribe registers a subscriber and returns, together with it, the
+// retained events newer than lastEventID that match the filter. Both happen
+// under the same lock so no event is missed or delivered twice.
+func (b *Bus) Subscribe(filter Filter, lastEventID uint64) (*Subscription, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	sub := &Subscription{Events: ch, events: ch, filter: filter}
+	b.subscribers[sub] = struct{}{}
+
+	var backlog []Event
+	if lastEventID > 0 {
+		for _, e := range b.history {
+			if e.ID > lastEventID && filter.Match(e) {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+	return sub, backlog
+}
+
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(sub)
+}
+
+func (b *Bus) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Close ends every subscription, so open streams return and their clients
+// reconnect. It is called when the server shuts down, streams would
+// otherwise hold the shutdown until its deadline.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
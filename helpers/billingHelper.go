@@ -0,0 +1,208 @@
+package helpers
+
+import (
+	"sort"
+
+	"github.com/minhtran241/restaurant-management/config"
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+// Rounding modes for the final amount of a bill.
+const (
+	RoundHalfUp   = "HALF_UP"
+	RoundHalfEven = "HALF_EVEN"
+	RoundUp       = "UP"
+	RoundDown     = "DOWN"
+)
+
+// BillingSettings holds the tax and service charge rules applied to every
+// bill. Rates are percentages.
+type BillingSettings struct {
+	Default_tax_rate    float64
+	Tax_rates           map[string]float64
+	Service_charge_rate float64
+	Rounding_increment  float64
+	Rounding_mode       string
+}
+
+// BillLine is one ordered item on a bill.
+type BillLine struct {
+	Order_item_id string
+	Food_id       string
+	Food_name     string
+	Food_image    string
+	Category      string
+	Quantity      int
+	Seat          *int
+	// Modifiers are the options picked for the item, their price deltas
+	// are part of the unit price.
+	Modifiers  []models.OrderItemModifier
+	Unit_price models.Money
+	Line_total models.Money
+	Tax_rate   float64
+	Status     string
+	// Notes are the texts of the notes on the item, e.g. "no onions".
+	Notes []string
+}
+
+// TaxLine is the tax due for all lines sharing a tax rate.
+type TaxLine struct {
+	Category string
+	Rate     float64
+	Taxable  models.Money
+	Amount   models.Money
+}
+
+type BillTotals struct {
+	Subtotal            models.Money
+	Taxes               []TaxLine
+	Tax_total           models.Money
+	Service_charge_rate float64
+	Service_charge      models.Money
+	Rounding_adjustment models.Money
+	Total               models.Money
+}
+
+// NewBillingSettings takes the rules from the validated configuration.
+func NewBillingSettings(billing config.Billing) BillingSettings {
+	settings := BillingSettings{
+		Default_tax_rate:    billing.Default_tax_rate,
+		Tax_rates:           map[string]float64{},
+		Service_charge_rate: billing.Service_charge_rate,
+		Rounding_increment:  billing.Rounding_increment,
+		Rounding_mode:       billing.Rounding_mode,
+	}
+	for category, rate := range billing.Tax_rates {
+		settings.Tax_rates[category] = rate
+	}
+	return settings
+}
+
+// TaxRate returns the tax percentage of a menu category.
+func (s BillingSettings) TaxRate(category string) float64 {
+	if rate, ok := s.Tax_rates[category]; ok {
+		return rate
+	}
+	return s.Default_tax_rate
+}
+
+// ComputeBill fills in the line totals and tax rates of lines and returns
+// the totals of the bill, all in minor units of DefaultCurrency. Taxes and
+// the service charge are rounded half up to the minor unit; only the final
+// total follows the configured rounding rule, the difference is reported as
+// rounding adjustment. It returns models.ErrCurrencyMismatch when a line is
+// priced in another currency.
+func ComputeBill(lines []BillLine, settings BillingSettings) (BillTotals, error) {
+	zero := models.NewMoney(0, models.DefaultCurrency)
+	totals := BillTotals{Subtotal: zero, Tax_total: zero}
+	taxable := map[float64]*TaxLine{}
+
+	var err error
+	for i := range lines {
+		line := &lines[i]
+		line.Line_total = line.Unit_price.Mul(int64(line.Quantity))
+		line.Tax_rate = settings.TaxRate(line.Category)
+		if totals.Subtotal, err = totals.Subtotal.Add(line.Line_total); err != nil {
+			return totals, err
+		}
+
+		tax, ok := taxable[line.Tax_rate]
+		if !ok {
+			tax = &TaxLine{Category: line.Category, Rate: line.Tax_rate, Taxable: zero}
+			taxable[line.Tax_rate] = tax
+		} else if tax.Category != line.Category {
+			tax.Category = ""
+		}
+		if tax.Taxable, err = tax.Taxable.Add(line.Line_total); err != nil {
+			return totals, err
+		}
+	}
+
+	totals.Taxes = []TaxLine{}
+	for _, tax := range taxable {
+		if tax.Rate == 0 {
+			continue
+		}
+		tax.Amount = tax.Taxable.Percent(tax.Rate)
+		if totals.Tax_total, err = totals.Tax_total.Add(tax.Amount); err != nil {
+			return totals, err
+		}
+		totals.Taxes = append(totals.Taxes, *tax)
+	}
+	sort.Slice(totals.Taxes, func(i, j int) bool { return totals.Taxes[i].Rate < totals.Taxes[j].Rate })
+
+	totals.Service_charge_rate = settings.Service_charge_rate
+	totals.Service_charge = totals.Subtotal.Percent(settings.Service_charge_rate)
+
+	exact, err := totals.Subtotal.Add(totals.Tax_total)
+	if err != nil {
+		return totals, err
+	}
+	if exact, err = exact.Add(totals.Service_charge); err != nil {
+		return totals, err
+	}
+	increment := models.MoneyFromMajor(settings.Rounding_increment, exact.Currency).Amount
+	totals.Total = models.NewMoney(roundTo(exact.Amount, increment, settings.Rounding_mode), exact.Currency)
+	totals.Rounding_adjustment, err = totals.Total.Sub(exact)
+	return totals, err
+}
+
+// roundTo rounds minor units to a multiple of increment.
+func roundTo(amount, increment int64, mode string) int64 {
+	if increment <= 1 {
+		return amount
+	}
+	units, remainder := amount/increment, amount%increment
+	if remainder < 0 {
+		units, remainder = units-1, remainder+increment
+	}
+	switch mode {
+	case RoundUp:
+		if remainder > 0 {
+			units++
+		}
+	case RoundDown:
+	case RoundHalfEven:
+		if 2*remainder > increment || (2*remainder == increment && units%2 != 0) {
+			units++
+		}
+	default:
+		if 2*remainder >= increment {
+			units++
+		}
+	}
+	return units * increment
+}
+
+// Allocate divides total into parts proportional to weights using the
+// largest remainder method, so the parts always add up to total exactly.
+// Equal weights split the total evenly, the first parts taking the odd
+// minor units.
+func Allocate(total models.Money, weights []int64) []models.Money {
+	parts := make([]models.Money, len(weights))
+	var sum int64
+	for _, w := range weights {
+		sum += w
+	}
+	if sum == 0 {
+		return parts
+	}
+
+	type remainder struct {
+		index int
+		value int64
+	}
+	remainders := make([]remainder, len(weights))
+	allocated := int64(0)
+	for i, w := range weights {
+		share := total.Amount * w / sum
+		parts[i] = models.NewMoney(share, total.Currency)
+		remainders[i] = remainder{i, total.Amount * w % sum}
+		allocated += share
+	}
+	sort.SliceStable(remainders, func(i, j int) bool { return remainders[i].value > remainders[j].value })
+	for i := int64(0); i < total.Amount-allocated; i++ {
+		parts[remainders[i].index].Amount++
+	}
+	return parts
+}
@@ -0,0 +1,179 @@
+package helpers
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+func usd(minorUnits int64) models.Money {
+	return models.NewMoney(minorUnits, "USD")
+}
+
+func TestComputeBill(t *testing.T) {
+	settings := BillingSettings{
+		Default_tax_rate:    8.875,
+		Tax_rates:           map[string]float64{"Drinks": 10, "Groceries": 0},
+		Service_charge_rate: 18,
+		Rounding_increment:  0.05,
+		Rounding_mode:       RoundHalfUp,
+	}
+	lines := []BillLine{
+		{Food_name: "Burger", Category: "Mains", Quantity: 2, Unit_price: usd(1250)},
+		{Food_name: "Fries", Category: "Sides", Quantity: 1, Unit_price: usd(399)},
+		{Food_name: "Cola", Category: "Drinks", Quantity: 3, Unit_price: usd(250)},
+		{Food_name: "Bread", Category: "Groceries", Quantity: 1, Unit_price: usd(100)},
+	}
+
+	totals, err := ComputeBill(lines, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantLines := []struct {
+		total int64
+		rate  float64
+	}{{2500, 8.875}, {399, 8.875}, {750, 10}, {100, 0}}
+	for i, want := range wantLines {
+		if lines[i].Line_total != usd(want.total) || lines[i].Tax_rate != want.rate {
+			t.Errorf("line %s totals %v at %v%%, want %d at %v%%",
+				lines[i].Food_name, lines[i].Line_total, lines[i].Tax_rate, want.total, want.rate)
+		}
+	}
+	// Mains and Sides share a rate, their tax line names no category; the
+	// untaxed category has no tax line
+	wantTaxes := []TaxLine{
+		{Category: "", Rate: 8.875, Taxable: usd(2899), Amount: usd(257)},
+		{Category: "Drinks", Rate: 10, Taxable: usd(750), Amount: usd(75)},
+	}
+	if !reflect.DeepEqual(totals.Taxes, wantTaxes) {
+		t.Errorf("taxes are %+v, want %+v", totals.Taxes, wantTaxes)
+	}
+	want := BillTotals{
+		Subtotal:            usd(3749),
+		Taxes:               wantTaxes,
+		Tax_total:           usd(332),
+		Service_charge_rate: 18,
+		Service_charge:      usd(675),
+		Rounding_adjustment: usd(-1),
+		Total:               usd(4755),
+	}
+	if !reflect.DeepEqual(totals, want) {
+		t.Errorf("totals are %+v, want %+v", totals, want)
+	}
+
+	settings.Rounding_mode = RoundUp
+	if totals, _ := ComputeBill(lines, settings); totals.Total != usd(4760) || totals.Rounding_adjustment != usd(4) {
+		t.Errorf("rounded up the total is %v with %v adjustment, want 47.60 and 0.04", totals.Total, totals.Rounding_adjustment)
+	}
+}
+
+func TestComputeBillEmpty(t *testing.T) {
+	totals, err := ComputeBill(nil, BillingSettings{Default_tax_rate: 10, Service_charge_rate: 15, Rounding_increment: 0.01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if totals.Total != usd(0) || totals.Tax_total != usd(0) || len(totals.Taxes) != 0 {
+		t.Errorf("empty bill totals %+v, want zero", totals)
+	}
+}
+
+func TestComputeBillCurrencyMismatch(t *testing.T) {
+	lines := []BillLine{
+		{Quantity: 1, Unit_price: usd(100)},
+		{Quantity: 1, Unit_price: models.NewMoney(100, "EUR")},
+	}
+	if _, err := ComputeBill(lines, BillingSettings{Rounding_increment: 0.01}); !errors.Is(err, models.ErrCurrencyMismatch) {
+		t.Errorf("bill in USD and EUR: got %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestRoundTo(t *testing.T) {
+	tests := []struct {
+		amount, increment int64
+		mode              string
+		want              int64
+	}{
+		{1234, 1, RoundHalfUp, 1234},
+		{1234, 0, RoundUp, 1234},
+		{1232, 5, RoundHalfUp, 1230},
+		{1233, 5, RoundHalfUp, 1235},
+		{1225, 10, RoundHalfUp, 1230},
+		{1225, 10, RoundHalfEven, 1220},
+		{1235, 10, RoundHalfEven, 1240},
+		{1226, 10, RoundHalfEven, 1230},
+		{1221, 10, RoundUp, 1230},
+		{1220, 10, RoundUp, 1220},
+		{1229, 10, RoundDown, 1220},
+		{1225, 10, "", 1230},
+		{-1225, 10, RoundHalfUp, -1220},
+		{-1221, 10, RoundUp, -1220},
+		{-1221, 10, RoundDown, -1230},
+		{-1225, 10, RoundHalfEven, -1220},
+	}
+	for _, tt := range tests {
+		if got := roundTo(tt.amount, tt.increment, tt.mode); got != tt.want {
+			t.Errorf("roundTo(%d, %d, %q) = %d, want %d", tt.amount, tt.increment, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		weights []int64
+		want    []int64
+	}{
+		{"even", 1000, []int64{1, 1, 1}, []int64{334, 333, 333}},
+		{"even, two odd cents", 1001, []int64{1, 1, 1}, []int64{334, 334, 333}},
+		{"exact", 900, []int64{1, 2}, []int64{300, 600}},
+		// 1000 x 3/7 = 428.57, 1000 x 4/7 = 571.43
+		{"largest remainder", 1000, []int64{3, 4}, []int64{429, 571}},
+		// 100 x 1/6 = 16.67, 100 x 2/6 = 33.33, 100 x 3/6 = 50
+		{"remainders ordered", 100, []int64{1, 2, 3}, []int64{17, 33, 50}},
+		{"zero weight", 1000, []int64{0, 1}, []int64{0, 1000}},
+		{"zero total", 0, []int64{1, 1}, []int64{0, 0}},
+	}
+	for _, tt := range tests {
+		parts := Allocate(usd(tt.total), tt.weights)
+		got := make([]int64, len(parts))
+		for i, part := range parts {
+			got[i] = part.Amount
+			if part.Currency != "USD" {
+				t.Errorf("%s: part %d is in %q, want USD", tt.name, i, part.Currency)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Allocate(%d, %v) = %v, want %v", tt.name, tt.total, tt.weights, got, tt.want)
+		}
+	}
+
+	if parts := Allocate(usd(1000), []int64{0, 0}); len(parts) != 2 || parts[0].Amount != 0 || parts[1].Amount != 0 {
+		t.Errorf("Allocate over zero weights = %v, want two zero parts", parts)
+	}
+}
+
+func TestAllocateAddsUp(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		total := r.Int63n(1000000)
+		weights := make([]int64, 1+r.Intn(10))
+		for j := range weights {
+			weights[j] = 1 + r.Int63n(10000)
+		}
+		var sum int64
+		for _, part := range Allocate(usd(total), weights) {
+			if part.Amount < 0 {
+				t.Fatalf("Allocate(%d, %v) has a negative part", total, weights)
+			}
+			sum += part.Amount
+		}
+		if sum != total {
+			t.Fatalf("Allocate(%d, %v) adds up to %d", total, weights, sum)
+		}
+	}
+}
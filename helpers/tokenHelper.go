@@ -1,108 +1,118 @@
 package helpers
 
 import (
-	"context"
 	"errors"
 	"fmt"
-	"log"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+)
 
-	"github.com/minhtran241/restaurant-management/database"
+// Values of SignedDetails.Token_type.
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
 )
 
 type SignedDetails struct {
-	Email      string
-	First_name string
-	Last_name  string
-	Uid        string
+	Email         string
+	First_name    string
+	Last_name     string
+	Uid           string
+	Role          string
+	Token_type    string
+	Token_version int
 	jwt.RegisteredClaims
 }
 
-var userCollection *mongo.Collection = database.OpenCollection(database.Client, "user")
+var (
+	secretKey       string
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+)
 
-var SECRET_KEY string = os.Getenv("SECRET_KEY")
+// ErrNoSecretKey is returned while ConfigureTokens has not been given a
+// key, tokens are never signed or accepted with an empty one.
+var ErrNoSecretKey = errors.New("token secret key is not configured")
 
-func GenerateAllTokens(email, firstName, lastName, uid string) (
+// ConfigureTokens sets the key signing the tokens and their lifetimes. It
+// is called once at startup, before the server accepts requests.
+func ConfigureTokens(secret string, accessTTL, refreshTTL time.Duration) {
+	secretKey = secret
+	accessTokenTTL = accessTTL
+	refreshTokenTTL = refreshTTL
+}
+
+// GenerateAllTokens signs a new access and refresh token pair. tokenVersion
+// is the user's current token version, bumping it on the user document
+// revokes every token signed with an older one.
+func GenerateAllTokens(email, firstName, lastName, uid, role string, tokenVersion int) (
 	signedToken, signedRefreshToken string, err error,
 ) {
+	if secretKey == "" {
+		return "", "", ErrNoSecretKey
+	}
 	claims := &SignedDetails{
-		Email:      email,
-		First_name: firstName,
-		Last_name:  lastName,
-		Uid:        uid,
+		Email:         email,
+		First_name:    firstName,
+		Last_name:     lastName,
+		Uid:           uid,
+		Role:          role,
+		Token_type:    AccessToken,
+		Token_version: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Local().Add(time.Minute * time.Duration(30))),
+			ID:        primitive.NewObjectID().Hex(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Local().Add(accessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
+	// the refresh token carries a unique ID so that two rotations within the
+	// same second still produce different tokens
 	refreshClaims := &SignedDetails{
+		Uid:           uid,
+		Token_type:    RefreshToken,
+		Token_version: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Local().Add(time.Hour * time.Duration(24))),
+			ID:        primitive.NewObjectID().Hex(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Local().Add(refreshTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(SECRET_KEY))
-	refreshToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString([]byte(SECRET_KEY))
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secretKey))
 	if err != nil {
-		log.Panic(err)
-		return
+		return "", "", err
 	}
-	return token, refreshToken, err
-}
-
-func UpdateAllTokens(signedToken, signedRefreshToken string, userId string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	var updateObj primitive.D
-	updateObj = append(updateObj, bson.E{Key: "token", Value: signedToken})
-	updateObj = append(updateObj, bson.E{Key: "refresh_token", Value: signedRefreshToken})
-
-	Updated_at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-	updateObj = append(updateObj, bson.E{Key: "updated_at", Value: Updated_at})
-
-	upsert := true
-	filter := bson.M{"user_id": userId}
-	opt := options.UpdateOptions{
-		Upsert: &upsert,
-	}
-	_, err := userCollection.UpdateOne(
-		ctx,
-		filter,
-		bson.D{
-			{Key: "$set", Value: updateObj},
-		},
-		&opt,
-	)
+	refreshToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString([]byte(secretKey))
 	if err != nil {
-		log.Panic(err)
-		return
+		return "", "", err
 	}
-	return
+	return token, refreshToken, nil
 }
 
 func ValidateToken(signedToken string) (claims *SignedDetails, msg string) {
+	if secretKey == "" {
+		return nil, fmt.Sprintf("invalid token: %v", ErrNoSecretKey)
+	}
 	token, err := jwt.ParseWithClaims(
 		signedToken,
 		&SignedDetails{},
 		func(token *jwt.Token) (interface{}, error) {
-			return []byte(SECRET_KEY), nil
+			return []byte(secretKey), nil
 		})
 
 	// invalid token
+	if token == nil {
+		msg = fmt.Sprintf("invalid token: %v", err)
+		return
+	}
 	claims, ok := token.Claims.(*SignedDetails)
 	if !ok || errors.Is(err, jwt.ErrTokenMalformed) {
-		msg = fmt.Sprintf("invalid token: %v", err.Error())
-		return
+		msg = fmt.Sprintf("invalid token: %v", err)
+		return nil, msg
 	}
 
 	// expired token
@@ -110,5 +120,12 @@ func ValidateToken(signedToken string) (claims *SignedDetails, msg string) {
 		msg = fmt.Sprintf("token is expired: %v", err.Error())
 		return
 	}
+
+	// bad signature or any other verification failure, the role claim must
+	// not be trusted in that case
+	if err != nil || !token.Valid {
+		msg = fmt.Sprintf("invalid token: %v", err)
+		return nil, msg
+	}
 	return claims, msg
 }
@@ -1,23 +1,36 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
-	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 
+	"github.com/minhtran241/restaurant-management/config"
+	"github.com/minhtran241/restaurant-management/controllers"
 	"github.com/minhtran241/restaurant-management/database"
 	_ "github.com/minhtran241/restaurant-management/docs"
+	"github.com/minhtran241/restaurant-management/events"
+	"github.com/minhtran241/restaurant-management/helpers"
 	"github.com/minhtran241/restaurant-management/middleware"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
 	"github.com/minhtran241/restaurant-management/routes"
 )
 
-var foodCollection *mongo.Collection = database.OpenCollection(database.Client, "food")
-
 // @title Restaurant Management Service
 // @version 1.0
 // @description Restaurant Management Service API using Gin framework.
@@ -32,33 +45,164 @@ var foodCollection *mongo.Collection = database.OpenCollection(database.Client,
 // @host localhost:8000
 // @BasePath /
 func main() {
-	port := os.Getenv("PORT")
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML configuration file")
+	flag.Parse()
 
-	if port == "" {
-		port = "8000"
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := run(cfg); err != nil {
+		log.Fatal(err)
 	}
+}
+
+// run serves the API until SIGINT or SIGTERM. In-flight requests are then
+// given the shutdown timeout to finish before the database is disconnected.
+func run(cfg config.Config) error {
+	models.DefaultCurrency = cfg.Billing.Currency
+	helpers.ConfigureTokens(
+		cfg.Auth.Secret_key,
+		time.Duration(cfg.Auth.Access_token_ttl),
+		time.Duration(cfg.Auth.Refresh_token_ttl),
+	)
+
+	client, err := database.DBinstance(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Database.Connect_timeout))
+		defer cancel()
+		if err := client.Disconnect(ctx); err != nil {
+			log.Printf("disconnecting from database: %v", err)
+			return
+		}
+		log.Print("disconnected from database")
+	}()
+
+	db := database.Database(client, cfg.Database)
+	// a database that is down or holds duplicates must not keep the service
+	// from starting, /readyz reports the indexes that are missing
+	indexCtx, cancelIndexes := context.WithTimeout(context.Background(), time.Duration(cfg.Database.Connect_timeout))
+	if err := repository.EnsureIndexes(indexCtx, db); err != nil {
+		log.Print(err)
+	}
+	cancelIndexes()
+
+	repos := repository.NewMongo(db)
+	// the last 1000 events are kept for clients resuming a stream
+	bus := events.NewBus(1000)
+	controller := controllers.New(repos, bus, cfg)
+	controller.AddReadinessCheck("mongodb", func(ctx context.Context) error {
+		return client.Ping(ctx, readpref.Primary())
+	})
+	controller.AddReadinessCheck("indexes", func(ctx context.Context) error {
+		missing, err := repository.MissingIndexes(ctx, db)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("missing indexes: %s", strings.Join(missing, ", "))
+		}
+		return nil
+	})
+	router := newRouter(controller, repos.Users, cfg.Server)
+
+	server := &http.Server{
+		Addr:    cfg.Server.Address,
+		Handler: router,
+	}
+	// event streams never finish by themselves, end them so they do not hold
+	// the shutdown until its deadline
+	server.RegisterOnShutdown(bus.Close)
+
+	stop, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", cfg.Server.Address)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-stop.Done():
+	}
+	// a second signal kills the process right away
+	cancel()
+
+	log.Print("shutting down, draining in-flight requests")
+	ctx, cancelShutdown := context.WithTimeout(context.Background(), time.Duration(cfg.Server.Shutdown_timeout))
+	defer cancelShutdown()
+	if err := server.Shutdown(ctx); err != nil {
+		// the deadline passed, drop the connections that are still open
+		server.Close()
+		return err
+	}
+	if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	log.Print("server stopped")
+	return nil
+}
+
+// newRouter serves the routes of the API with the controller. The routes
+// registered past the public ones require the token of one of the users.
+func newRouter(controller *controllers.Controller, users repository.UserRepository, server config.Server) *gin.Engine {
+	authentication := middleware.Authentication(users)
 
 	router := gin.New()
+	router.Use(middleware.RequestID())
+	// event streams may carry the access token in their query
+	router.Use(gin.LoggerWithConfig(gin.LoggerConfig{SkipPaths: []string{routes.EventStreamPath}}))
+	router.Use(middleware.Errors())
+	router.Use(middleware.Recovery())
 	// router.Use(middleware.CORSMiddleware())
-	router.Use(cors.Default())
-	router.Use(gin.Logger())
+	router.Use(cors.New(corsConfig(server)))
 
-	routes.UserRoutes(router)
+	routes.HealthRoutes(router, controller)
+	routes.UserRoutes(router, controller, authentication)
+	routes.EventRoutes(router, controller, authentication)
 
 	router.GET("/", HealthCheck)
-	url := ginSwagger.URL("http://localhost:8000/swagger/doc.json") // The url pointing to API definition
+	url := ginSwagger.URL(server.Public_url + "/swagger/doc.json") // The url pointing to API definition
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
 
-	router.Use(middleware.Authentication())
-
-	routes.FoodRoutes(router)
-	routes.MenuRoutes(router)
-	routes.TableRoutes(router)
-	routes.OrderRoutes(router)
-	routes.OrderItemRoutes(router)
-	routes.InvoiceRoutes(router)
+	router.Use(authentication)
+
+	routes.FoodRoutes(router, controller)
+	routes.MenuRoutes(router, controller)
+	routes.TableRoutes(router, controller)
+	routes.OrderRoutes(router, controller)
+	routes.OrderItemRoutes(router, controller)
+	routes.InvoiceRoutes(router, controller)
+	routes.KitchenRoutes(router, controller)
+	routes.ReservationRoutes(router, controller)
+	routes.SearchRoutes(router, controller)
+	routes.AuditRoutes(router, controller)
+	routes.NoteRoutes(router, controller)
+	routes.IngredientRoutes(router, controller)
+	routes.SupplierRoutes(router, controller)
+	routes.PurchaseOrderRoutes(router, controller)
+	return router
+}
 
-	router.Run(":" + port)
+// corsConfig allows the configured origins, "*" allows any. The token
+// header carries the access token and must be allowed as well.
+func corsConfig(server config.Server) cors.Config {
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AddAllowHeaders("token")
+	for _, origin := range server.Cors_origins {
+		if origin == "*" {
+			corsConfig.AllowAllOrigins = true
+			return corsConfig
+		}
+	}
+	corsConfig.AllowOrigins = server.Cors_origins
+	return corsConfig
 }
 
 // HealthCheck godoc
@@ -0,0 +1,559 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/minhtran241/restaurant-management/config"
+	"github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/events"
+	"github.com/minhtran241/restaurant-management/helpers"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
+)
+
+// api serves the router of the service on an in-memory store.
+type api struct {
+	t      *testing.T
+	router *gin.Engine
+}
+
+func newAPI(t *testing.T) *api {
+	t.Helper()
+	return newAPIOn(t, repository.NewMemory())
+}
+
+// newAPIOn serves the router on the given repositories.
+func newAPIOn(t *testing.T, repos *repository.Repositories) *api {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.Default()
+	cfg.Auth.Secret_key = "test-secret"
+	cfg.Auth.Bcrypt_cost = bcrypt.MinCost
+	helpers.ConfigureTokens(
+		cfg.Auth.Secret_key,
+		time.Duration(cfg.Auth.Access_token_ttl),
+		time.Duration(cfg.Auth.Refresh_token_ttl),
+	)
+
+	bus := events.NewBus(100)
+	t.Cleanup(bus.Close)
+	controller := controllers.New(repos, bus, cfg)
+	return &api{t: t, router: newRouter(controller, repos.Users, cfg.Server)}
+}
+
+// do sends the request with the token and the headers given as name, value
+// pairs, and decodes the JSON response into out unless it is nil.
+func (a *api) do(method, path, token string, body interface{}, out interface{}, headers ...string) *httptest.ResponseRecorder {
+	a.t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			a.t.Fatalf("encoding the body of %s %s: %v", method, path, err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("token", token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, req)
+	if out != nil && rec.Code < 300 {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			a.t.Fatalf("decoding the response of %s %s: %v\n%s", method, path, err, rec.Body)
+		}
+	}
+	return rec
+}
+
+// expect fails the test unless the response has the status.
+func (a *api) expect(rec *httptest.ResponseRecorder, status int, what string) {
+	a.t.Helper()
+	if rec.Code != status {
+		a.t.Fatalf("%s: got status %d, want %d\n%s", what, rec.Code, status, rec.Body)
+	}
+}
+
+type session struct {
+	User_id       string `json:"user_id"`
+	Role          string `json:"role"`
+	Token         string `json:"token"`
+	Refresh_token string `json:"refresh_token"`
+}
+
+func (a *api) signUp(email, phone string) session {
+	a.t.Helper()
+	var s session
+	rec := a.do(http.MethodPost, "/users/signup", "", map[string]string{
+		"first_name": "Test", "last_name": "User", "email": email,
+		"password": "secret-password", "phone": phone,
+	}, &s)
+	a.expect(rec, http.StatusOK, "sign up "+email)
+	return s
+}
+
+func TestAuth(t *testing.T) {
+	a := newAPI(t)
+
+	admin := a.signUp("admin@example.com", "5550100")
+	if admin.Role != "ADMIN" {
+		t.Fatalf("first user has role %q, want ADMIN", admin.Role)
+	}
+	waiter := a.signUp("waiter@example.com", "5550101")
+	if waiter.Role != "WAITER" {
+		t.Fatalf("second user has role %q, want WAITER", waiter.Role)
+	}
+	a.expect(a.do(http.MethodPost, "/users/signup", "", map[string]string{
+		"first_name": "Test", "last_name": "User", "email": "waiter@example.com",
+		"password": "secret-password", "phone": "5550102",
+	}, nil), http.StatusConflict, "sign up with a taken email")
+
+	a.expect(a.do(http.MethodGet, "/tables", "", nil, nil), http.StatusUnauthorized, "list tables without a token")
+	a.expect(a.do(http.MethodGet, "/tables", "not-a-token", nil, nil), http.StatusUnauthorized, "list tables with a bad token")
+
+	a.expect(a.do(http.MethodPost, "/users/login", "", map[string]string{
+		"email": "waiter@example.com", "password": "wrong-password",
+	}, nil), http.StatusUnauthorized, "log in with a wrong password")
+	var login session
+	rec := a.do(http.MethodPost, "/users/login", "", map[string]string{
+		"email": "waiter@example.com", "password": "secret-password",
+	}, &login)
+	a.expect(rec, http.StatusOK, "log in")
+	if login.User_id != waiter.User_id || login.Token == "" || login.Refresh_token == "" {
+		t.Fatalf("log in responded with %s", rec.Body)
+	}
+
+	// users only see themselves, secrets are never served
+	rec = a.do(http.MethodGet, "/users/"+waiter.User_id, login.Token, nil, nil)
+	a.expect(rec, http.StatusOK, "get own user")
+	var fields map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"password", "Password", "token", "refresh_token"} {
+		if _, ok := fields[secret]; ok {
+			t.Errorf("user response carries %q: %s", secret, rec.Body)
+		}
+	}
+	a.expect(a.do(http.MethodGet, "/users/"+admin.User_id, login.Token, nil, nil), http.StatusForbidden, "waiter gets another user")
+	a.expect(a.do(http.MethodGet, "/users", login.Token, nil, nil), http.StatusForbidden, "waiter lists users")
+	a.expect(a.do(http.MethodGet, "/users/"+waiter.User_id, admin.Token, nil, nil), http.StatusOK, "admin gets another user")
+
+	// a refresh token is good for one refresh, replaying it revokes every session
+	var refreshed session
+	a.expect(a.do(http.MethodPost, "/users/refresh", "", map[string]string{
+		"refresh_token": login.Refresh_token,
+	}, &refreshed), http.StatusOK, "refresh")
+	a.expect(a.do(http.MethodGet, "/tables", refreshed.Token, nil, nil), http.StatusOK, "list tables with the refreshed token")
+	a.expect(a.do(http.MethodPost, "/users/refresh", "", map[string]string{
+		"refresh_token": login.Refresh_token,
+	}, nil), http.StatusUnauthorized, "replay a refresh token")
+	a.expect(a.do(http.MethodGet, "/tables", refreshed.Token, nil, nil), http.StatusUnauthorized, "list tables after a replay")
+
+	// a role change takes effect at once, the tokens of the old role are revoked
+	a.expect(a.do(http.MethodPost, "/users/login", "", map[string]string{
+		"email": "waiter@example.com", "password": "secret-password",
+	}, &login), http.StatusOK, "log in again")
+	a.expect(a.do(http.MethodPatch, "/users/"+waiter.User_id+"/role", login.Token, map[string]string{
+		"role": "MANAGER",
+	}, nil), http.StatusForbidden, "waiter changes a role")
+	a.expect(a.do(http.MethodPatch, "/users/"+waiter.User_id+"/role", admin.Token, map[string]string{
+		"role": "MANAGER",
+	}, nil), http.StatusOK, "admin changes a role")
+	a.expect(a.do(http.MethodGet, "/tables", login.Token, nil, nil), http.StatusUnauthorized, "list tables with a token of the old role")
+	a.expect(a.do(http.MethodPost, "/users/refresh", "", map[string]string{
+		"refresh_token": login.Refresh_token,
+	}, nil), http.StatusUnauthorized, "refresh a token of the old role")
+
+	a.expect(a.do(http.MethodPost, "/users/logout", admin.Token, nil, nil), http.StatusOK, "log out")
+	a.expect(a.do(http.MethodGet, "/tables", admin.Token, nil, nil), http.StatusUnauthorized, "list tables after logging out")
+}
+
+type money struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+type invoiceView struct {
+	Invoice_id     string
+	Payment_status *string
+	Payment_due    money
+	Amount_paid    money
+	Balance_due    money
+}
+
+// setUpTable creates a table seating guests as the user of the token.
+func (a *api) setUpTable(token string, number, guests int) string {
+	a.t.Helper()
+	var table struct {
+		Table_id string `json:"table_id"`
+	}
+	a.expect(a.do(http.MethodPost, "/tables", token, map[string]int{
+		"table_number": number, "number_of_guests": guests,
+	}, &table), http.StatusOK, "create a table")
+	return table.Table_id
+}
+
+// setUpFood creates a menu with a food at price as the user of the token.
+func (a *api) setUpFood(token, price string) string {
+	a.t.Helper()
+	var menu struct {
+		Menu_id string `json:"food_id"`
+	}
+	a.expect(a.do(http.MethodPost, "/menus", token, map[string]string{
+		"name": "Dinner", "category": "Mains",
+	}, &menu), http.StatusOK, "create a menu")
+	var food struct {
+		Food_id string `json:"food_id"`
+	}
+	a.expect(a.do(http.MethodPost, "/foods", token, map[string]interface{}{
+		"name": "Burger", "price": price, "food_image": "burger.png", "menu_id": menu.Menu_id,
+	}, &food), http.StatusOK, "create a food")
+	return food.Food_id
+}
+
+// failingOrders fails to store new orders.
+type failingOrders struct {
+	repository.OrderRepository
+}
+
+func (failingOrders) Create(context.Context, models.Order) error {
+	return errors.New("database is down")
+}
+
+func TestOrderItemsWithoutOrder(t *testing.T) {
+	repos := repository.NewMemory()
+	repos.Orders = failingOrders{repos.Orders}
+	a := newAPIOn(t, repos)
+	admin := a.signUp("admin@example.com", "5550100")
+	foodId := a.setUpFood(admin.Token, "12.50")
+	tableId := a.setUpTable(admin.Token, 1, 4)
+
+	a.expect(a.do(http.MethodPost, "/orderItems", admin.Token, map[string]interface{}{
+		"table_id":    tableId,
+		"order_items": []map[string]interface{}{{"food_id": foodId, "quantity": 1}},
+	}, nil), http.StatusInternalServerError, "order items when the order cannot be stored")
+	var list struct {
+		Total_count int64 `json:"total_count"`
+	}
+	a.expect(a.do(http.MethodGet, "/orderItems", admin.Token, nil, &list), http.StatusOK, "list the ordered items")
+	if list.Total_count != 0 {
+		t.Errorf("%d items were stored without their order", list.Total_count)
+	}
+}
+
+func TestOrderInvoicePayment(t *testing.T) {
+	a := newAPI(t)
+	admin := a.signUp("admin@example.com", "5550100")
+	waiter := a.signUp("waiter@example.com", "5550101")
+
+	foodId := a.setUpFood(admin.Token, "12.50")
+	tableId := a.setUpTable(admin.Token, 1, 4)
+
+	var items []struct {
+		Order_id      string `json:"order_id"`
+		Order_item_id string `json:"order_item_id"`
+		Unit_price    money  `json:"unit_price"`
+	}
+	a.expect(a.do(http.MethodPost, "/orderItems", waiter.Token, map[string]interface{}{
+		"table_id": tableId,
+		"order_items": []map[string]interface{}{
+			{"food_id": foodId, "quantity": 2, "unit_price": "0.01"},
+		},
+	}, &items), http.StatusOK, "order items")
+	if len(items) != 1 || items[0].Unit_price.Amount != "12.50" {
+		t.Fatalf("ordered items %+v, want one priced 12.50 from the food", items)
+	}
+	orderId := items[0].Order_id
+
+	a.expect(a.do(http.MethodPost, "/invoices", waiter.Token, map[string]string{
+		"order_id": orderId,
+	}, nil), http.StatusForbidden, "waiter creates an invoice")
+	var invoice struct {
+		Invoice_id string `json:"invoice_id"`
+	}
+	a.expect(a.do(http.MethodPost, "/invoices", admin.Token, map[string]string{
+		"order_id": orderId, "payment_method": "CARD",
+	}, &invoice), http.StatusOK, "create an invoice")
+	a.expect(a.do(http.MethodPost, "/invoices", admin.Token, map[string]string{
+		"order_id": orderId, "payment_method": "CARD",
+	}, nil), http.StatusConflict, "create a second invoice of the order")
+	a.expect(a.do(http.MethodPatch, "/orderItems/"+items[0].Order_item_id, waiter.Token, map[string]interface{}{
+		"quantity": 3,
+	}, nil), http.StatusConflict, "change an item of an invoiced order")
+	a.expect(a.do(http.MethodPost, "/kitchen/items/"+items[0].Order_item_id+"/status", waiter.Token, map[string]string{
+		"status": "VOIDED",
+	}, nil), http.StatusConflict, "void an item of an invoiced order")
+
+	var view invoiceView
+	a.expect(a.do(http.MethodGet, "/invoices/"+invoice.Invoice_id, admin.Token, nil, &view), http.StatusOK, "get the invoice")
+	if view.Payment_due.Amount != "25.00" || *view.Payment_status != "PENDING" {
+		t.Fatalf("invoice is due %s and %s, want 25.00 and PENDING", view.Payment_due.Amount, *view.Payment_status)
+	}
+
+	payments := "/invoices/" + invoice.Invoice_id + "/payments"
+	a.expect(a.do(http.MethodPost, payments, admin.Token, map[string]string{
+		"method": "CARD", "amount": "30.00",
+	}, nil), http.StatusBadRequest, "pay more than the balance")
+	a.expect(a.do(http.MethodPost, payments, admin.Token, map[string]string{
+		"method": "CASH", "amount": "10.00",
+	}, &view), http.StatusOK, "pay a part")
+	if *view.Payment_status != "PARTIALLY_PAID" || view.Balance_due.Amount != "15.00" {
+		t.Fatalf("after a part the invoice is %s with %s due, want PARTIALLY_PAID with 15.00", *view.Payment_status, view.Balance_due.Amount)
+	}
+	a.expect(a.do(http.MethodPost, payments, admin.Token, map[string]interface{}{
+		"method": "CARD", "amount": "15.00", "tip": "3.00",
+	}, &view), http.StatusOK, "pay the rest")
+	if *view.Payment_status != "PAID" || view.Balance_due.Amount != "0.00" || view.Amount_paid.Amount != "25.00" {
+		t.Fatalf("after the rest the invoice is %s with %s paid and %s due, want PAID with 25.00 and 0.00",
+			*view.Payment_status, view.Amount_paid.Amount, view.Balance_due.Amount)
+	}
+	a.expect(a.do(http.MethodPost, payments, admin.Token, map[string]string{
+		"method": "CASH", "amount": "1.00",
+	}, nil), http.StatusConflict, "pay a paid invoice")
+
+	var table struct {
+		Status string `json:"status"`
+	}
+	a.expect(a.do(http.MethodGet, "/tables/"+tableId, admin.Token, nil, &table), http.StatusOK, "get the table")
+	if table.Status != "NEEDS_CLEANING" {
+		t.Errorf("table of the paid order is %s, want NEEDS_CLEANING", table.Status)
+	}
+
+	a.expect(a.do(http.MethodDelete, "/invoices/"+invoice.Invoice_id, admin.Token, nil, nil), http.StatusConflict, "delete a paid invoice")
+
+	// a deleted invoice takes no payment
+	a.expect(a.do(http.MethodPost, "/orderItems", waiter.Token, map[string]interface{}{
+		"table_id":    tableId,
+		"order_items": []map[string]interface{}{{"food_id": foodId, "quantity": 1}},
+	}, &items), http.StatusOK, "order again")
+	a.expect(a.do(http.MethodPost, "/invoices", admin.Token, map[string]string{
+		"order_id": items[0].Order_id, "payment_method": "CASH",
+	}, &invoice), http.StatusOK, "create the invoice of the second order")
+	a.expect(a.do(http.MethodDelete, "/invoices/"+invoice.Invoice_id, admin.Token, nil, nil), http.StatusOK, "delete an unpaid invoice")
+	a.expect(a.do(http.MethodPost, "/invoices/"+invoice.Invoice_id+"/payments", admin.Token, map[string]string{
+		"method": "CASH", "amount": "1.00",
+	}, nil), http.StatusNotFound, "pay a deleted invoice")
+
+	// nothing is due once every item is voided, the invoice is settled as is
+	a.expect(a.do(http.MethodPost, "/orderItems", waiter.Token, map[string]interface{}{
+		"table_id":    tableId,
+		"order_items": []map[string]interface{}{{"food_id": foodId, "quantity": 1}},
+	}, &items), http.StatusOK, "order a third time")
+	a.expect(a.do(http.MethodPost, "/kitchen/items/"+items[0].Order_item_id+"/status", waiter.Token, map[string]string{
+		"status": "VOIDED",
+	}, nil), http.StatusOK, "void the item")
+	var comped struct {
+		Payment_status string `json:"payment_status"`
+	}
+	a.expect(a.do(http.MethodPost, "/invoices", admin.Token, map[string]string{
+		"order_id": items[0].Order_id, "payment_method": "CASH",
+	}, &comped), http.StatusOK, "create the invoice of a voided order")
+	if comped.Payment_status != "PAID" {
+		t.Errorf("invoice with nothing due is %s, want PAID", comped.Payment_status)
+	}
+}
+
+type reservation struct {
+	Reservation_id string `json:"reservation_id"`
+	Table_id       string `json:"table_id"`
+	Version        int    `json:"version"`
+}
+
+func (a *api) reserve(token, tableId string, start time.Time) (reservation, *httptest.ResponseRecorder) {
+	a.t.Helper()
+	body := map[string]interface{}{
+		"guest_name": "Guest", "phone": "5550199", "party_size": 2,
+		"start_time": start.Format(time.RFC3339), "duration_minutes": 60,
+	}
+	if tableId != "" {
+		body["table_id"] = tableId
+	}
+	var booked reservation
+	rec := a.do(http.MethodPost, "/reservations", token, body, &booked)
+	return booked, rec
+}
+
+func TestReservations(t *testing.T) {
+	a := newAPI(t)
+	admin := a.signUp("admin@example.com", "5550100")
+	tableId := a.setUpTable(admin.Token, 7, 4)
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+
+	booked, rec := a.reserve(admin.Token, "", start)
+	a.expect(rec, http.StatusOK, "book without a table")
+	if booked.Table_id != tableId {
+		t.Fatalf("booked table %q, want %q", booked.Table_id, tableId)
+	}
+	_, rec = a.reserve(admin.Token, tableId, start.Add(30*time.Minute))
+	a.expect(rec, http.StatusConflict, "book an overlapping slot of the table")
+	_, rec = a.reserve(admin.Token, "", start.Add(30*time.Minute))
+	a.expect(rec, http.StatusConflict, "book an overlapping slot of any table")
+	_, rec = a.reserve(admin.Token, tableId, start.Add(-time.Hour))
+	a.expect(rec, http.StatusOK, "book the slot ending as the first starts")
+	_, rec = a.reserve(admin.Token, tableId, time.Now().Add(-time.Hour))
+	a.expect(rec, http.StatusBadRequest, "book in the past")
+
+	// a stale version is refused and keeps the slot where it was
+	path := "/reservations/" + booked.Reservation_id
+	moved := start.Add(3 * time.Hour).Format(time.RFC3339)
+	a.expect(a.do(http.MethodPatch, path, admin.Token, map[string]interface{}{
+		"start_time": moved,
+	}, nil, "If-Match", fmt.Sprintf(`"%d"`, booked.Version+1)), http.StatusConflict, "reschedule a stale version")
+	_, rec = a.reserve(admin.Token, tableId, start.Add(3*time.Hour))
+	a.expect(rec, http.StatusOK, "book the slot of the refused reschedule")
+
+	// rescheduling onto a taken slot keeps the reservation, a free one
+	// releases the old slot
+	a.expect(a.do(http.MethodPatch, path, admin.Token, map[string]interface{}{
+		"start_time": moved,
+	}, nil, "If-Match", fmt.Sprintf(`"%d"`, booked.Version)), http.StatusConflict, "reschedule onto a taken slot")
+	var rescheduled reservation
+	a.expect(a.do(http.MethodPatch, path, admin.Token, map[string]interface{}{
+		"start_time": start.Add(6 * time.Hour).Format(time.RFC3339),
+	}, &rescheduled, "If-Match", fmt.Sprintf(`"%d"`, booked.Version)), http.StatusOK, "reschedule onto a free slot")
+	if rescheduled.Version != booked.Version+1 {
+		t.Errorf("rescheduled reservation has version %d, want %d", rescheduled.Version, booked.Version+1)
+	}
+	_, rec = a.reserve(admin.Token, tableId, start)
+	a.expect(rec, http.StatusOK, "book the slot released by the reschedule")
+
+	// a cancelled reservation gives its slot back and cannot be changed
+	a.expect(a.do(http.MethodPost, path+"/cancel", admin.Token, nil, nil), http.StatusOK, "cancel")
+	a.expect(a.do(http.MethodPatch, path, admin.Token, map[string]interface{}{
+		"party_size": 3,
+	}, nil), http.StatusConflict, "change a cancelled reservation")
+	_, rec = a.reserve(admin.Token, tableId, start.Add(6*time.Hour))
+	a.expect(rec, http.StatusOK, "book the slot released by the cancellation")
+}
+
+type orderItem struct {
+	Order_id      string `json:"order_id"`
+	Order_item_id string `json:"order_item_id"`
+	Status        string `json:"status"`
+}
+
+func TestOrderCancellation(t *testing.T) {
+	a := newAPI(t)
+	admin := a.signUp("admin@example.com", "5550100")
+	foodId := a.setUpFood(admin.Token, "12.50")
+	tableId := a.setUpTable(admin.Token, 1, 4)
+
+	var items []orderItem
+	a.expect(a.do(http.MethodPost, "/orderItems", admin.Token, map[string]interface{}{
+		"table_id": tableId,
+		"order_items": []map[string]interface{}{
+			{"food_id": foodId, "quantity": 1}, {"food_id": foodId, "quantity": 2},
+		},
+	}, &items), http.StatusOK, "order items")
+	orderId := items[0].Order_id
+	a.expect(a.do(http.MethodPost, "/kitchen/items/"+items[0].Order_item_id+"/bump", admin.Token, nil, nil), http.StatusOK, "fire an item")
+
+	transitions := "/orders/" + orderId + "/transitions"
+	a.expect(a.do(http.MethodPost, transitions, admin.Token, map[string]string{
+		"status": "SERVED",
+	}, nil), http.StatusConflict, "serve an open order")
+	a.expect(a.do(http.MethodPost, transitions, admin.Token, map[string]string{
+		"status": "CANCELLED", "reason": "guest left",
+	}, nil), http.StatusOK, "cancel the order")
+
+	// the kitchen no longer prepares the items of a cancelled order
+	for _, item := range items {
+		var got orderItem
+		a.expect(a.do(http.MethodGet, "/orderItems/"+item.Order_item_id, admin.Token, nil, &got), http.StatusOK, "get an item")
+		if got.Status != "VOIDED" {
+			t.Errorf("item of the cancelled order is %s, want VOIDED", got.Status)
+		}
+	}
+	var tickets []struct{ Order_id string }
+	a.expect(a.do(http.MethodGet, "/kitchen/tickets", admin.Token, nil, &tickets), http.StatusOK, "get the kitchen tickets")
+	if len(tickets) != 0 {
+		t.Errorf("kitchen shows %d tickets after the cancellation, want none", len(tickets))
+	}
+	a.expect(a.do(http.MethodPost, transitions, admin.Token, map[string]string{
+		"status": "OPEN",
+	}, nil), http.StatusConflict, "reopen a cancelled order")
+
+	// a deleted order keeps its status
+	var order struct {
+		Order_id string `json:"order_id"`
+	}
+	a.expect(a.do(http.MethodPost, "/orders", admin.Token, map[string]string{
+		"table_id": tableId, "order_date": time.Now().Format(time.RFC3339),
+	}, &order), http.StatusOK, "create an order")
+	a.expect(a.do(http.MethodDelete, "/orders/"+order.Order_id, admin.Token, nil, nil), http.StatusOK, "delete the order")
+	a.expect(a.do(http.MethodPost, "/orders/"+order.Order_id+"/transitions", admin.Token, map[string]string{
+		"status": "SENT_TO_KITCHEN",
+	}, nil), http.StatusNotFound, "move a deleted order")
+}
+
+// tableStatus returns the status of the table.
+func (a *api) tableStatus(token, tableId string) string {
+	a.t.Helper()
+	var table struct {
+		Status string `json:"status"`
+	}
+	a.expect(a.do(http.MethodGet, "/tables/"+tableId, token, nil, &table), http.StatusOK, "get the table")
+	return table.Status
+}
+
+func TestTableFollowsOrders(t *testing.T) {
+	a := newAPI(t)
+	admin := a.signUp("admin@example.com", "5550100")
+	foodId := a.setUpFood(admin.Token, "12.50")
+	first, second := a.setUpTable(admin.Token, 1, 4), a.setUpTable(admin.Token, 2, 4)
+
+	order := func(tableId string) string {
+		var items []orderItem
+		a.expect(a.do(http.MethodPost, "/orderItems", admin.Token, map[string]interface{}{
+			"table_id":    tableId,
+			"order_items": []map[string]interface{}{{"food_id": foodId, "quantity": 1}},
+		}, &items), http.StatusOK, "order items")
+		return items[0].Order_id
+	}
+	expectStatus := func(tableId, want, when string) {
+		t.Helper()
+		if got := a.tableStatus(admin.Token, tableId); got != want {
+			t.Errorf("%s the table is %s, want %s", when, got, want)
+		}
+	}
+
+	moving, staying := order(first), order(first)
+	expectStatus(first, "ORDERED", "after ordering")
+
+	// the first table keeps an open order
+	a.expect(a.do(http.MethodPatch, "/orders/"+moving, admin.Token, map[string]string{
+		"table_id": second,
+	}, nil), http.StatusOK, "move an order")
+	expectStatus(first, "ORDERED", "with an order left")
+	expectStatus(second, "ORDERED", "after an order moved in")
+
+	a.expect(a.do(http.MethodPatch, "/orders/"+staying, admin.Token, map[string]string{
+		"table_id": second,
+	}, nil), http.StatusOK, "move the other order")
+	expectStatus(first, "AVAILABLE", "after every order moved out")
+
+	a.expect(a.do(http.MethodPost, "/orders/"+moving+"/transitions", admin.Token, map[string]string{
+		"status": "CANCELLED",
+	}, nil), http.StatusOK, "cancel an order")
+	expectStatus(second, "ORDERED", "with an order left")
+	a.expect(a.do(http.MethodPost, "/orders/"+staying+"/transitions", admin.Token, map[string]string{
+		"status": "CANCELLED",
+	}, nil), http.StatusOK, "cancel the other order")
+	expectStatus(second, "SEATED", "after every order was cancelled")
+}
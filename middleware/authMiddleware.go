@@ -1,36 +1,94 @@
 package middleware
 
 import (
-	"fmt"
-	"net/http"
+	"context"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/minhtran241/restaurant-management/apperrors"
 	"github.com/minhtran241/restaurant-management/helpers"
+	"github.com/minhtran241/restaurant-management/models"
+	"github.com/minhtran241/restaurant-management/repository"
 )
 
-func Authentication() gin.HandlerFunc {
+// Authentication accepts requests carrying a valid access token. Tokens
+// issued before the user's last logout, or of a deleted user, are rejected.
+func Authentication(users repository.UserRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		clientToken := c.Request.Header.Get("token")
 		if clientToken == "" {
-			c.JSON(
-				http.StatusInternalServerError,
-				gin.H{"error": fmt.Sprintf("no authorization header provided")},
-			)
-			c.Abort()
+			Fail(c, apperrors.Unauthorized("no authorization header provided"))
 			return
 		}
 		claims, err := helpers.ValidateToken(clientToken)
 		if err != "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
-			c.Abort()
+			Fail(c, apperrors.Unauthorized(err))
+			return
+		}
+		// refresh tokens may only be exchanged at /users/refresh
+		if claims.Token_type == helpers.RefreshToken {
+			Fail(c, apperrors.Unauthorized("refresh token cannot be used for authentication"))
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		tokenVersion, versionErr := users.TokenVersion(ctx, claims.Uid)
+		if versionErr != nil && versionErr != repository.ErrNotFound {
+			Fail(c, apperrors.Internal("error occurred while checking the token", versionErr))
+			return
+		}
+		if versionErr == repository.ErrNotFound || tokenVersion != claims.Token_version {
+			Fail(c, apperrors.Unauthorized("token has been revoked"))
 			return
 		}
 		c.Set("email", claims.Email)
 		c.Set("first_name", claims.First_name)
 		c.Set("last_name", claims.Last_name)
 		c.Set("uid", claims.Uid)
+		c.Set("role", claims.Role)
+
+		c.Next()
+	}
+}
 
+// Authorization lets the request through only when the authenticated user
+// holds one of the given roles. Admins are always allowed. It must be
+// registered after Authentication.
+func Authorization(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if role == models.RoleAdmin {
+			c.Next()
+			return
+		}
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		Fail(c, apperrors.Forbidden("you are not allowed to access this resource"))
+	}
+}
+
+// TokenFromQuery copies a token passed as query parameter into the token
+// header when the header is missing. Browsers' EventSource cannot set
+// headers, so streaming routes register it in front of Authentication. The
+// parameter is then removed from the URL so the token does not end up in a
+// log line; the access log must skip these routes as it reads the URL first.
+func TokenFromQuery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// the query is read from the URL, c.Query would cache it with the
+		// token before it is removed
+		query := c.Request.URL.Query()
+		if token := query.Get("token"); token != "" && c.Request.Header.Get("token") == "" {
+			c.Request.Header.Set("token", token)
+		}
+		if query.Has("token") {
+			query.Del("token")
+			c.Request.URL.RawQuery = query.Encode()
+		}
 		c.Next()
 	}
 }
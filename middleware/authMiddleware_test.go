@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTokenFromQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name, target, header string
+		wantToken, wantQuery string
+	}{
+		{"query token", "/events/stream?token=abc&type=order.created", "", "abc", "type=order.created"},
+		{"header wins", "/events/stream?token=abc", "xyz", "xyz", ""},
+		{"no token", "/events/stream?table_id=1", "", "", "table_id=1"},
+	}
+	for _, tt := range tests {
+		var token, query, seen string
+		router := gin.New()
+		router.GET("/events/stream", TokenFromQuery(), func(c *gin.Context) {
+			token = c.Request.Header.Get("token")
+			query = c.Request.URL.RawQuery
+			seen = c.Query("token")
+		})
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.header != "" {
+			req.Header.Set("token", tt.header)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if token != tt.wantToken {
+			t.Errorf("%s: token header is %q, want %q", tt.name, token, tt.wantToken)
+		}
+		if query != tt.wantQuery || seen != "" {
+			t.Errorf("%s: query is %q with token %q, want %q without token", tt.name, query, seen, tt.wantQuery)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+)
+
+// Errors renders the last error a handler or middleware added with c.Error
+// as an apperrors.Response. Errors that are not *apperrors.Error are
+// answered as internal errors; the cause of an internal error is logged
+// under the request ID and never sent to the client. It must be registered
+// after RequestID and in front of every middleware that reports errors.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 {
+			return
+		}
+		err := apperrors.From(c.Errors.Last().Err)
+		requestId := c.GetString("request_id")
+		if err.Code == apperrors.CodeInternal && err.Err != nil {
+			log.Printf("[%s] %s %s: %v", requestId, c.Request.Method, c.Request.URL.Path, err.Err)
+		}
+		if c.Writer.Written() {
+			return
+		}
+		c.JSON(err.Status(), apperrors.Response{Error: err, Request_id: requestId})
+	}
+}
+
+// Fail reports err and stops the handler chain, Errors renders it.
+func Fail(c *gin.Context, err *apperrors.Error) {
+	c.Error(err)
+	c.Abort()
+}
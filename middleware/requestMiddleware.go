@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/minhtran241/restaurant-management/apperrors"
+)
+
+// RequestIDHeader carries the ID of a request in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits the IDs accepted from clients, anything else is
+// replaced so it cannot garble the logs.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID tags every request with an ID, kept from the X-Request-ID header
+// when the client sent a usable one. It is stored as "request_id" in the
+// context and echoed in the response header.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestId) {
+			requestId = primitive.NewObjectID().Hex()
+		}
+		c.Set("request_id", requestId)
+		c.Header(RequestIDHeader, requestId)
+		c.Next()
+	}
+}
+
+// Recovery turns a panic in a handler into an internal error response
+// carrying the request ID, and logs the panic with its stack under the same
+// ID. It must be registered after Errors.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// the client went away, there is nobody to answer
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			requestId := c.GetString("request_id")
+			log.Printf("[%s] panic serving %s %s: %v\n%s",
+				requestId, c.Request.Method, c.Request.URL.Path, recovered, debug.Stack())
+			Fail(c, apperrors.Internal("internal server error", nil))
+		}()
+		c.Next()
+	}
+}
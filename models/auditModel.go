@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditCreate  = "CREATE"
+	AuditUpdate  = "UPDATE"
+	AuditDelete  = "DELETE"
+	AuditRestore = "RESTORE"
+)
+
+// AuditEntry records one change made through the API. Before and After are
+// the entity as the API returned it, Before is empty for a creation.
+type AuditEntry struct {
+	ID          primitive.ObjectID     `bson:"_id"`
+	Audit_id    string                 `json:"audit_id"`
+	Actor_id    string                 `json:"actor_id"`
+	Action      string                 `json:"action"`
+	Entity_type string                 `json:"entity_type"`
+	Entity_id   string                 `json:"entity_id"`
+	Before      map[string]interface{} `json:"before"`
+	After       map[string]interface{} `json:"after"`
+	Request_id  string                 `json:"request_id"`
+	Created_at  time.Time              `json:"created_at"`
+}
@@ -6,13 +6,56 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// ModifierGroup is a choice offered with a food, e.g. "Size" where exactly
+// one option is picked or "Extras" where up to three are. Min and Max bound
+// the number of options an ordered item picks from the group, a Max of 0
+// allows any number.
+type ModifierGroup struct {
+	Name    string           `json:"name" validate:"required,max=50"`
+	Min     int              `json:"min" validate:"min=0"`
+	Max     int              `json:"max" validate:"min=0"`
+	Options []ModifierOption `json:"options" validate:"required,min=1,dive"`
+}
+
+// ModifierOption is an option of a modifier group. Price_delta is added to
+// the price of the food when it is picked.
+type ModifierOption struct {
+	Name        string `json:"name" validate:"required,max=50"`
+	Price_delta *Money `json:"price_delta"`
+}
+
+// RecipeIngredient is the quantity of an ingredient, in its unit, that goes
+// into one portion of a food. An optional ingredient, e.g. a garnish, is
+// left out when it runs out instead of keeping the food from being ordered.
+type RecipeIngredient struct {
+	Ingredient_id string  `json:"ingredient_id" validate:"required"`
+	Quantity      float64 `json:"quantity" validate:"gt=0"`
+	Optional      bool    `json:"optional"`
+}
+
+// Food is a dish of a menu. Available is false while it cannot be ordered,
+// e.g. sold out; foods stored before it existed have none and count as
+// available.
 type Food struct {
-	ID         primitive.ObjectID `bson:"_id"`
-	Name       *string            `json:"name" validate:"required,min=2,max=100"`
-	Price      *float64           `json:"price" validate:"require"`
-	Food_image *string            `json:"food_image" validate:"required"`
-	Created_at time.Time          `json:"created_at"`
-	Updated_at time.Time          `json:"updated_at"`
-	Food_id    string             `json:"food_id"`
-	Menu_id    *string            `json:"menu_id" validate:"required"`
+	ID          primitive.ObjectID `bson:"_id"`
+	Name        *string            `json:"name" validate:"required,min=2,max=100"`
+	Description *string            `json:"description" validate:"omitempty,max=500"`
+	Price       *Money             `json:"price" validate:"required"`
+	Food_image  *string            `json:"food_image" validate:"required"`
+	Available   *bool              `json:"available"`
+	// Modifier_groups are the choices offered when the food is ordered.
+	Modifier_groups []ModifierGroup `json:"modifier_groups" validate:"omitempty,dive"`
+	// Recipe lists the ingredients taken from the stock for every portion
+	// the kitchen fires.
+	Recipe []RecipeIngredient `json:"recipe" validate:"omitempty,dive"`
+	// Out_of_stock is set while a required ingredient of the recipe is
+	// short of one portion: the food is 86'd and cannot be ordered.
+	Out_of_stock bool       `json:"out_of_stock"`
+	Created_at   time.Time  `json:"created_at"`
+	Updated_at   time.Time  `json:"updated_at"`
+	Version      int        `json:"version"`
+	Food_id      string     `json:"food_id"`
+	Menu_id      *string    `json:"menu_id" validate:"required"`
+	Deleted_at   *time.Time `json:"deleted_at"`
+	Deleted_by   *string    `json:"deleted_by"`
 }
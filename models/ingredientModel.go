@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Units of measure of ingredients.
+const (
+	UnitGram       = "g"
+	UnitKilogram   = "kg"
+	UnitMilliliter = "ml"
+	UnitLiter      = "l"
+	UnitPiece      = "piece"
+)
+
+// Kinds of stock movements. Deliveries, waste and counts are recorded by
+// staff, usage is taken when the kitchen fires an ordered item. Receiving a
+// purchase order records deliveries.
+const (
+	StockDelivery = "DELIVERY"
+	StockWaste    = "WASTE"
+	StockCount    = "COUNT"
+	StockUsage    = "USAGE"
+)
+
+// Ingredient is an item of the inventory. On_hand is the stock in Unit, it
+// only changes through stock movements. Low_stock is set while On_hand is
+// at or below Low_stock_threshold. Par_level is the stock to restock up to
+// from Supplier_id, the supplier it is usually bought from.
+type Ingredient struct {
+	ID                  primitive.ObjectID `bson:"_id"`
+	Name                string             `json:"name" validate:"required,max=100"`
+	Unit                string             `json:"unit" validate:"required,oneof=g kg ml l piece"`
+	On_hand             float64            `json:"on_hand" validate:"min=0"`
+	Low_stock_threshold *float64           `json:"low_stock_threshold" validate:"omitempty,min=0"`
+	Low_stock           bool               `json:"low_stock"`
+	Par_level           *float64           `json:"par_level" validate:"omitempty,min=0"`
+	Supplier_id         *string            `json:"supplier_id"`
+	Created_at          time.Time          `json:"created_at"`
+	Updated_at          time.Time          `json:"updated_at"`
+	Version             int                `json:"version"`
+	Ingredient_id       string             `json:"ingredient_id"`
+	Deleted_at          *time.Time         `json:"deleted_at"`
+	Deleted_by          *string            `json:"deleted_by"`
+}
+
+// BelowThreshold reports whether the ingredient is low on stock.
+func (i Ingredient) BelowThreshold() bool {
+	return i.Low_stock_threshold != nil && i.On_hand <= *i.Low_stock_threshold
+}
+
+// StockMovement is an entry of the append-only log of stock changes.
+// Quantity is the change of the stock, negative when it is taken, and
+// On_hand the stock after it. Order_item_id names the ordered item of a
+// usage and Purchase_order_id the purchase order of a delivery.
+type StockMovement struct {
+	ID                primitive.ObjectID `bson:"_id"`
+	Movement_id       string             `json:"movement_id"`
+	Ingredient_id     string             `json:"ingredient_id"`
+	Kind              string             `json:"kind"`
+	Quantity          float64            `json:"quantity"`
+	On_hand           float64            `json:"on_hand"`
+	Reason            string             `json:"reason"`
+	Order_item_id     *string            `json:"order_item_id"`
+	Purchase_order_id *string            `json:"purchase_order_id"`
+	Created_by        string             `json:"created_by"`
+	Created_at        time.Time          `json:"created_at"`
+}
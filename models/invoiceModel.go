@@ -6,13 +6,55 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Payment statuses of an invoice. They follow the recorded payments: an
+// invoice is PAID once the payments cover the amount due.
+const (
+	PaymentPending       = "PENDING"
+	PaymentPartiallyPaid = "PARTIALLY_PAID"
+	PaymentPaid          = "PAID"
+)
+
+// Ways an invoice can be split.
+const (
+	SplitEven = "EVEN"
+	SplitSeat = "SEAT"
+	SplitItem = "ITEM"
+)
+
 type Invoice struct {
 	ID               primitive.ObjectID `bson:"_id"`
 	Invoice_id       string             `json:"invoice_id"`
 	Order_id         string             `json:"order_id"`
 	Payment_method   *string            `json:"payment_method" validate:"eq=CARD|eq=CASH|eq="`
-	Payment_status   *string            `json:"payment_status" validate:"required,eq=PENDING|eq=PAID"`
+	Payment_status   *string            `json:"payment_status" validate:"required,eq=PENDING|eq=PARTIALLY_PAID|eq=PAID"`
 	Payment_due_date time.Time          `json:"payment_due_date"`
+	Payments         []Payment          `json:"payments"`
+	Splits           []InvoiceSplit     `json:"splits"`
 	Created_at       time.Time          `json:"created_at"`
 	Updated_at       time.Time          `json:"updated_at"`
+	Version          int                `json:"version"`
+	Deleted_at       *time.Time         `json:"deleted_at"`
+	Deleted_by       *string            `json:"deleted_by"`
+}
+
+// Payment is one payment taken against an invoice. The tip comes on top of
+// the amount and does not reduce the balance.
+type Payment struct {
+	Payment_id string    `json:"payment_id"`
+	Method     *string   `json:"method" validate:"required,eq=CARD|eq=CASH"`
+	Amount     *Money    `json:"amount" validate:"required"`
+	Tip        *Money    `json:"tip"`
+	Reference  string    `json:"reference"`
+	Split_id   string    `json:"split_id"`
+	Taken_by   string    `json:"taken_by"`
+	Created_at time.Time `json:"created_at"`
+}
+
+// InvoiceSplit is the share of an invoice one guest or party pays.
+type InvoiceSplit struct {
+	Split_id       string   `json:"split_id"`
+	Label          string   `json:"label"`
+	Seat           *int     `json:"seat,omitempty"`
+	Order_item_ids []string `json:"order_item_ids"`
+	Amount         Money    `json:"amount"`
 }
@@ -6,13 +6,86 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Weekdays as written in menu schedules, indexed by time.Weekday.
+var Weekdays = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
+// MenuWindow is a recurring time of day a menu is served on the given
+// days, e.g. 07:00 to 11:00 on weekdays. A window ending before it starts
+// runs past midnight into the next day, and an end of 00:00 is midnight.
+type MenuWindow struct {
+	Days  []string `json:"days" validate:"required,min=1,dive,oneof=MON TUE WED THU FRI SAT SUN"`
+	Start string   `json:"start" validate:"required,datetime=15:04"`
+	End   string   `json:"end" validate:"required,datetime=15:04"`
+}
+
 type Menu struct {
 	ID         primitive.ObjectID `bson:"_id"`
-	Name       string             `json:"name" validate="required"`
-	Category   string             `json:"category" validate="required"`
+	Name       string             `json:"name" validate:"required"`
+	Category   string             `json:"category" validate:"required"`
 	Start_Date *time.Time         `json:"start_date"`
 	End_Date   *time.Time         `json:"end_date"`
-	Created_at time.Time          `json:"created_at"`
-	Updated_at time.Time          `json:"updated_at"`
-	Menu_id    string             `json:"food_id"`
+	// Schedule lists when the menu is served between its start and end
+	// dates, in the time zone of the restaurant. Without windows it is
+	// served all day.
+	Schedule   []MenuWindow `json:"schedule" validate:"omitempty,dive"`
+	Created_at time.Time    `json:"created_at"`
+	Updated_at time.Time    `json:"updated_at"`
+	Version    int          `json:"version"`
+	Menu_id    string       `json:"food_id"`
+	Deleted_at *time.Time   `json:"deleted_at"`
+	Deleted_by *string      `json:"deleted_by"`
+}
+
+// ActiveAt reports whether the menu is served at the given time, reading
+// its schedule in the location of the restaurant. Deleted menus are never
+// served.
+func (m Menu) ActiveAt(at time.Time, location *time.Location) bool {
+	if m.Deleted_at != nil {
+		return false
+	}
+	if m.Start_Date != nil && at.Before(*m.Start_Date) {
+		return false
+	}
+	if m.End_Date != nil && !at.Before(*m.End_Date) {
+		return false
+	}
+	if len(m.Schedule) == 0 {
+		return true
+	}
+	local := at.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	today := Weekdays[local.Weekday()]
+	yesterday := Weekdays[(local.Weekday()+6)%7]
+	for _, window := range m.Schedule {
+		start, end := clockMinutes(window.Start), clockMinutes(window.End)
+		if end <= start {
+			// past midnight: the evening belongs to the listed day, the
+			// early hours to the day after it
+			if (minute >= start && window.on(today)) || (minute < end && window.on(yesterday)) {
+				return true
+			}
+		} else if minute >= start && minute < end && window.on(today) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w MenuWindow) on(day string) bool {
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// clockMinutes returns the minutes since midnight of a validated "15:04"
+// time of day.
+func clockMinutes(clock string) int {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0
+	}
+	return t.Hour()*60 + t.Minute()
 }
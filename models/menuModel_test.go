@@ -0,0 +1,92 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMenuActiveAt(t *testing.T) {
+	// the restaurant is five hours behind UTC; 2024-05-10 is a Friday
+	local := time.FixedZone("UTC-5", -5*60*60)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 5, day, hour, minute, 0, 0, local).UTC()
+	}
+	menu := Menu{Schedule: []MenuWindow{
+		{Days: []string{"SAT", "SUN"}, Start: "09:00", End: "14:00"},
+		{Days: []string{"FRI", "SAT"}, Start: "22:00", End: "02:00"},
+		{Days: []string{"MON", "TUE", "WED", "THU", "FRI"}, Start: "17:00", End: "00:00"},
+	}}
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"saturday brunch opens", at(11, 9, 0), true},
+		{"saturday brunch", at(11, 13, 59), true},
+		{"saturday brunch closed", at(11, 14, 0), false},
+		{"before saturday brunch", at(11, 8, 59), false},
+		{"no brunch on friday", at(10, 10, 0), false},
+		{"friday late night", at(10, 23, 30), true},
+		{"friday late night after midnight", at(11, 1, 59), true},
+		{"friday late night closed", at(11, 2, 0), false},
+		{"saturday late night after midnight", at(12, 1, 0), true},
+		// thursday has no late night, friday's doesn't start before 22:00
+		{"early friday", at(10, 1, 0), false},
+		{"sunday late night", at(12, 23, 0), false},
+		{"early monday", at(13, 1, 0), false},
+		{"weekday dinner", at(13, 17, 0), true},
+		{"weekday dinner until midnight", at(13, 23, 59), true},
+		{"weekday dinner closed at midnight", at(14, 0, 0), false},
+		{"no dinner on sunday", at(12, 18, 0), false},
+	}
+	for _, tt := range tests {
+		if got := menu.ActiveAt(tt.at, local); got != tt.want {
+			t.Errorf("%s (%s): ActiveAt = %v, want %v", tt.name, tt.at.In(local).Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestMenuActiveAtDates(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	deleted := time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		menu Menu
+		at   time.Time
+		want bool
+	}{
+		{"all day without a schedule", Menu{}, time.Date(2024, 5, 12, 3, 0, 0, 0, time.UTC), true},
+		{"from the start date", Menu{Start_Date: &start}, start, true},
+		{"before the start date", Menu{Start_Date: &start}, start.Add(-time.Minute), false},
+		{"until the end date", Menu{End_Date: &end}, end.Add(-time.Minute), true},
+		{"at the end date", Menu{End_Date: &end}, end, false},
+		{"deleted", Menu{Deleted_at: &deleted}, start, false},
+		{"schedule within the dates", Menu{
+			Start_Date: &start,
+			End_Date:   &end,
+			Schedule:   []MenuWindow{{Days: []string{"FRI"}, Start: "12:00", End: "15:00"}},
+		}, time.Date(2024, 5, 10, 13, 0, 0, 0, time.UTC), true},
+		{"schedule after the end date", Menu{
+			End_Date: &end,
+			Schedule: []MenuWindow{{Days: []string{"FRI"}, Start: "12:00", End: "15:00"}},
+		}, time.Date(2024, 6, 7, 13, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		if got := tt.menu.ActiveAt(tt.at, time.UTC); got != tt.want {
+			t.Errorf("%s: ActiveAt = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClockMinutes(t *testing.T) {
+	tests := []struct {
+		clock string
+		want  int
+	}{{"00:00", 0}, {"07:30", 450}, {"23:59", 1439}, {"noon", 0}}
+	for _, tt := range tests {
+		if got := clockMinutes(tt.clock); got != tt.want {
+			t.Errorf("clockMinutes(%q) = %d, want %d", tt.clock, got, tt.want)
+		}
+	}
+}
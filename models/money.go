@@ -0,0 +1,286 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// DefaultCurrency is the ISO 4217 code used for amounts that do not name
+// one, including every price stored before Money existed. It is set from
+// the billing currency of the configuration at startup.
+var DefaultCurrency = "USD"
+
+// ErrCurrencyMismatch is returned when amounts in different currencies are
+// combined.
+var ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+
+// currencyExponents lists the currencies whose minor unit is not a
+// hundredth of the major unit.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0,
+	"XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// CurrencyExponent returns the number of decimals of the currency's minor
+// unit, 2 for most currencies.
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// Money is an amount in integer minor units (cents for USD) of an ISO 4217
+// currency. Add and Sub return ErrCurrencyMismatch for amounts in different
+// currencies.
+//
+// In JSON it is written as {"amount": "12.34", "minor_units": 1234,
+// "currency": "USD"} and read from that object, a decimal string or a plain
+// number in major units as older clients send it. In BSON it is stored as
+// {amount: <minor units>, currency: <code>}; stored float prices are read
+// as major units of DefaultCurrency.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+func NewMoney(minorUnits int64, currency string) Money {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{Amount: minorUnits, Currency: currency}
+}
+
+// MoneyFromMajor converts a float amount in major units, rounding half away
+// from zero to the minor unit. Only legacy float prices should go through it.
+func MoneyFromMajor(amount float64, currency string) Money {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	scale := math.Pow10(CurrencyExponent(currency))
+	return Money{Amount: int64(math.Round(amount * scale)), Currency: currency}
+}
+
+// ParseMoney reads a decimal amount in major units such as "12.34" without
+// going through floating point, with at most one leading sign. Extra
+// decimals are rounded half away from zero. An empty amount is an error,
+// not zero.
+func ParseMoney(s, currency string) (Money, error) {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, fmt.Errorf("amount is empty")
+	}
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return Money{}, fmt.Errorf("invalid amount %q", s)
+		}
+		return MoneyFromMajor(f, currency), nil
+	}
+
+	digits := s
+	negative := false
+	switch digits[0] {
+	case '-':
+		negative = true
+		digits = digits[1:]
+	case '+':
+		digits = digits[1:]
+	}
+	whole, fraction, _ := strings.Cut(digits, ".")
+	// every character is checked before the extra decimals are dropped
+	if whole+fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	exponent := CurrencyExponent(currency)
+	roundUp := false
+	if len(fraction) > exponent {
+		roundUp = fraction[exponent] >= '5'
+		fraction = fraction[:exponent]
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+	if roundUp {
+		minor++
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount in major units, e.g. "12.34".
+func (m Money) String() string {
+	exponent := CurrencyExponent(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if exponent == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+	scale := int64(math.Pow10(exponent))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, exponent, amount%scale)
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	currency, err := m.currency(other)
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}, err
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	currency, err := m.currency(other)
+	return Money{Amount: m.Amount - other.Amount, Currency: currency}, err
+}
+
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// Percent returns rate percent of the amount rounded half up to the minor
+// unit. The rate is kept to four decimals, enough for rates like 8.875.
+func (m Money) Percent(rate float64) Money {
+	scaledRate := int64(math.Round(rate * 1e4))
+	return Money{Amount: divRound(m.Amount*scaledRate, 1e6), Currency: m.Currency}
+}
+
+// currency picks the currency of the result of combining m with other, a
+// zero value without currency takes the other side's. It returns
+// ErrCurrencyMismatch when both sides name different currencies.
+func (m Money) currency(other Money) (string, error) {
+	switch {
+	case m.Currency == "":
+		return other.Currency, nil
+	case other.Currency != "" && other.Currency != m.Currency:
+		return m.Currency, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return m.Currency, nil
+}
+
+// divRound divides rounding half away from zero.
+func divRound(n, d int64) int64 {
+	if (n < 0) != (d < 0) {
+		return (n - d/2) / d
+	}
+	return (n + d/2) / d
+}
+
+type moneyJSON struct {
+	Amount      *string `json:"amount,omitempty"`
+	Minor_units *int64  `json:"minor_units,omitempty"`
+	Currency    string  `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	amount := m.String()
+	minor := m.Amount
+	return json.Marshal(moneyJSON{Amount: &amount, Minor_units: &minor, Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		return nil
+	case bytes.HasPrefix(data, []byte("{")):
+		var v moneyJSON
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		currency := strings.ToUpper(v.Currency)
+		if v.Minor_units != nil {
+			*m = NewMoney(*v.Minor_units, currency)
+			return nil
+		}
+		if v.Amount != nil {
+			parsed, err := ParseMoney(*v.Amount, currency)
+			*m = parsed
+			return err
+		}
+		return fmt.Errorf("money needs an amount or minor_units")
+	case bytes.HasPrefix(data, []byte(`"`)):
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		parsed, err := ParseMoney(s, "")
+		*m = parsed
+		return err
+	default:
+		// a bare number is an amount in major units, as prices were sent
+		// before Money existed; parse its text to avoid float rounding
+		parsed, err := ParseMoney(string(data), "")
+		*m = parsed
+		return err
+	}
+}
+
+type moneyBSON struct {
+	Amount   int64  `bson:"amount"`
+	Currency string `bson:"currency"`
+}
+
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(moneyBSON{Amount: m.Amount, Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bsontype.EmbeddedDocument:
+		var v moneyBSON
+		if err := raw.Unmarshal(&v); err != nil {
+			return err
+		}
+		*m = NewMoney(v.Amount, v.Currency)
+	case bsontype.Double:
+		*m = MoneyFromMajor(raw.Double(), DefaultCurrency)
+	case bsontype.Int32:
+		*m = MoneyFromMajor(float64(raw.Int32()), DefaultCurrency)
+	case bsontype.Int64:
+		*m = MoneyFromMajor(float64(raw.Int64()), DefaultCurrency)
+	case bsontype.Decimal128:
+		parsed, err := ParseMoney(raw.Decimal128().String(), DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case bsontype.Null, bsontype.Undefined:
+		*m = Money{}
+	default:
+		return fmt.Errorf("cannot decode %v into Money", t)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     int64
+	}{
+		{"12.34", "USD", 1234},
+		{"12", "USD", 1200},
+		{"12.3", "USD", 1230},
+		{".5", "USD", 50},
+		{"5.", "USD", 500},
+		{"+5", "USD", 500},
+		{"-5.25", "USD", -525},
+		{" 7.00 ", "USD", 700},
+		{"0.005", "USD", 1},
+		{"0.0049", "USD", 0},
+		{"-0.005", "USD", -1},
+		{"1.999", "USD", 200},
+		{"1.2e1", "USD", 1200},
+		{"1234", "JPY", 1234},
+		{"1234.5", "JPY", 1235},
+		{"1.2345", "KWD", 1235},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in, tt.currency)
+		if err != nil {
+			t.Errorf("ParseMoney(%q, %s) failed: %v", tt.in, tt.currency, err)
+			continue
+		}
+		if got.Amount != tt.want || got.Currency != tt.currency {
+			t.Errorf("ParseMoney(%q, %s) = %d %s, want %d %s", tt.in, tt.currency, got.Amount, got.Currency, tt.want, tt.currency)
+		}
+	}
+}
+
+func TestParseMoneyDefaultCurrency(t *testing.T) {
+	got, err := ParseMoney("1.00", "")
+	if err != nil || got.Currency != DefaultCurrency {
+		t.Errorf("ParseMoney without a currency = %v, %v, want %s", got, err, DefaultCurrency)
+	}
+}
+
+func TestParseMoneyInvalid(t *testing.T) {
+	for _, in := range []string{
+		"", " ", ".", "-", "+", "abc", "1.23abc", "1.23-", "1.2.3", "--5", "+-5", "-+5",
+		"1,50", "1 000", "0x10", "NaN", "Inf", "1e", "1e400", "١٢",
+	} {
+		if got, err := ParseMoney(in, "USD"); err == nil {
+			t.Errorf("ParseMoney(%q) = %d, want an error", in, got.Amount)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{NewMoney(1234, "USD"), "12.34"},
+		{NewMoney(5, "USD"), "0.05"},
+		{NewMoney(0, "USD"), "0.00"},
+		{NewMoney(-5, "USD"), "-0.05"},
+		{NewMoney(-1234, "USD"), "-12.34"},
+		{NewMoney(1234, "JPY"), "1234"},
+		{NewMoney(1234, "KWD"), "1.234"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("%d %s formats as %q, want %q", tt.money.Amount, tt.money.Currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyPercent(t *testing.T) {
+	tests := []struct {
+		amount int64
+		rate   float64
+		want   int64
+	}{
+		{1000, 10, 100},
+		{1999, 8.875, 177},  // 177.41
+		{1000, 8.875, 89},   // 88.75 rounds up
+		{1, 50, 1},          // 0.5 rounds up
+		{3, 10, 0},          // 0.3 rounds down
+		{-1000, 8.875, -89}, // half away from zero
+		{333, 33.3333, 111}, // 110.99989
+		{12345, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := NewMoney(tt.amount, "USD").Percent(tt.rate); got.Amount != tt.want {
+			t.Errorf("%v%% of %d = %d, want %d", tt.rate, tt.amount, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestDivRound(t *testing.T) {
+	tests := []struct{ n, d, want int64 }{
+		{5, 2, 3},
+		{4, 2, 2},
+		{7, 3, 2},
+		{-5, 2, -3},
+		{5, -2, -3},
+		{-5, -2, 3},
+		{-7, 3, -2},
+	}
+	for _, tt := range tests {
+		if got := divRound(tt.n, tt.d); got != tt.want {
+			t.Errorf("divRound(%d, %d) = %d, want %d", tt.n, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyAddSub(t *testing.T) {
+	usd, eur := NewMoney(150, "USD"), NewMoney(100, "EUR")
+	if sum, err := usd.Add(NewMoney(50, "USD")); err != nil || sum.Amount != 200 || sum.Currency != "USD" {
+		t.Errorf("1.50 + 0.50 USD = %v, %v", sum, err)
+	}
+	if diff, err := usd.Sub(NewMoney(200, "USD")); err != nil || diff.Amount != -50 {
+		t.Errorf("1.50 - 2.00 USD = %v, %v", diff, err)
+	}
+	// a zero value without currency takes the other side's
+	if sum, err := (Money{}).Add(eur); err != nil || sum.Currency != "EUR" || sum.Amount != 100 {
+		t.Errorf("zero + 1.00 EUR = %v, %v", sum, err)
+	}
+	if _, err := usd.Add(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("USD + EUR: got %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := usd.Sub(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("USD - EUR: got %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+	}{
+		{`{"amount": "12.34", "currency": "usd"}`, NewMoney(1234, "USD")},
+		{`{"minor_units": 1234, "currency": "EUR"}`, NewMoney(1234, "EUR")},
+		{`{"amount": "1", "minor_units": 5, "currency": "USD"}`, NewMoney(5, "USD")},
+		{`"12.34"`, NewMoney(1234, DefaultCurrency)},
+		{`12.34`, NewMoney(1234, DefaultCurrency)},
+		{`0.1`, NewMoney(10, DefaultCurrency)},
+		{`null`, Money{}},
+	}
+	for _, tt := range tests {
+		var got Money
+		if err := json.Unmarshal([]byte(tt.in), &got); err != nil {
+			t.Errorf("decoding %s failed: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("decoding %s = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{`{"currency": "USD"}`, `"1.23abc"`, `"--5"`, `""`, `true`} {
+		var got Money
+		if err := json.Unmarshal([]byte(in), &got); err == nil {
+			t.Errorf("decoding %s = %v, want an error", in, got)
+		}
+	}
+
+	out, err := json.Marshal(NewMoney(-1205, "USD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"amount":"-12.05","minor_units":-1205,"currency":"USD"}`; string(out) != want {
+		t.Errorf("encoding -12.05 USD = %s, want %s", out, want)
+	}
+}
+
+func TestMoneyBSON(t *testing.T) {
+	type priced struct {
+		Price Money `bson:"price"`
+	}
+	decimal, _ := primitive.ParseDecimal128("12.345")
+	tests := []struct {
+		name   string
+		stored interface{}
+		want   Money
+	}{
+		{"document", bson.M{"amount": int64(1234), "currency": "EUR"}, NewMoney(1234, "EUR")},
+		{"legacy double", 12.34, NewMoney(1234, DefaultCurrency)},
+		{"legacy double below a cent", 0.1 + 0.2, NewMoney(30, DefaultCurrency)},
+		{"legacy int32", int32(12), NewMoney(1200, DefaultCurrency)},
+		{"legacy int64", int64(12), NewMoney(1200, DefaultCurrency)},
+		{"legacy decimal", decimal, NewMoney(1235, DefaultCurrency)},
+		{"null", nil, Money{}},
+	}
+	for _, tt := range tests {
+		data, err := bson.Marshal(bson.M{"price": tt.stored})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got priced
+		if err := bson.Unmarshal(data, &got); err != nil {
+			t.Errorf("%s: decoding failed: %v", tt.name, err)
+			continue
+		}
+		if got.Price != tt.want {
+			t.Errorf("%s: decoded %v, want %v", tt.name, got.Price, tt.want)
+		}
+	}
+
+	// amounts round-trip as integer minor units
+	data, err := bson.Marshal(priced{NewMoney(-705, "JPY")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got priced
+	if err := bson.Unmarshal(data, &got); err != nil || got.Price != NewMoney(-705, "JPY") {
+		t.Errorf("round trip of -705 JPY = %v, %v", got.Price, err)
+	}
+
+	data, err = bson.Marshal(bson.M{"price": "12.34"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bson.Unmarshal(data, &got); err == nil {
+		t.Errorf("decoding a string price = %v, want an error", got.Price)
+	}
+}
@@ -6,11 +6,27 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Records a note can be attached to.
+const (
+	NoteOnOrder       = "ORDER"
+	NoteOnOrderItem   = "ORDER_ITEM"
+	NoteOnTable       = "TABLE"
+	NoteOnReservation = "RESERVATION"
+)
+
+// Note annotates an order, an ordered item, a table or a reservation, e.g.
+// "no onions" on an item. Author_id is the user who wrote it.
 type Note struct {
-	ID         primitive.ObjectID `bson:"_id"`
-	Text       string             `json:"text"`
-	Title      string             `json:"title"`
-	Created_at time.Time          `json:"created_at"`
-	Updated_at time.Time          `json:"updated_at"`
-	Note_id    string             `json:"note_id"`
+	ID          primitive.ObjectID `bson:"_id"`
+	Text        string             `json:"text" validate:"required,max=1000"`
+	Title       string             `json:"title" validate:"max=100"`
+	Parent_type string             `json:"parent_type" validate:"required,eq=ORDER|eq=ORDER_ITEM|eq=TABLE|eq=RESERVATION"`
+	Parent_id   string             `json:"parent_id" validate:"required"`
+	Author_id   string             `json:"author_id"`
+	Created_at  time.Time          `json:"created_at"`
+	Updated_at  time.Time          `json:"updated_at"`
+	Version     int                `json:"version"`
+	Note_id     string             `json:"note_id"`
+	Deleted_at  *time.Time         `json:"deleted_at"`
+	Deleted_by  *string            `json:"deleted_by"`
 }
@@ -3,16 +3,79 @@ package models
 import (
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Preparation statuses of an ordered item as seen by the kitchen.
+const (
+	ItemQueued  = "QUEUED"
+	ItemCooking = "COOKING"
+	ItemReady   = "READY"
+	ItemServed  = "SERVED"
+	ItemVoided  = "VOIDED"
+)
+
+// OrderItemModifier is an option picked for an ordered item, with its
+// price delta at the time it was ordered.
+type OrderItemModifier struct {
+	Group       string `json:"group" validate:"required"`
+	Option      string `json:"option" validate:"required"`
+	Price_delta *Money `json:"price_delta"`
+}
+
+// String writes the modifier as printed on tickets, e.g. "Size: Large".
+func (m OrderItemModifier) String() string {
+	return m.Group + ": " + m.Option
+}
+
+// OrderItem is a count of one food in an order. Unit_price is the price of
+// one of them with its modifiers, captured when it was ordered.
 type OrderItem struct {
-	ID            primitive.ObjectID `bson:"_id"`
-	Quantity      *string            `json:"quantity" validate:"required,eq=S|eq=M|eq=L"`
-	Unit_price    *float64           `json:"unit_price" validate:"required"`
-	Created_at    time.Time          `json:"created_at"`
-	Updated_at    time.Time          `json:"updated_at"`
-	Food_id       *string            `json:"food_id" validate:"required"`
-	Order_item_id string             `json:"order_item_id"`
-	Order_id      string             `json:"order_id" validate:"required"`
+	ID                primitive.ObjectID  `bson:"_id"`
+	Quantity          *int                `json:"quantity" validate:"required,min=1"`
+	Unit_price        *Money              `json:"unit_price"`
+	Created_at        time.Time           `json:"created_at"`
+	Updated_at        time.Time           `json:"updated_at"`
+	Version           int                 `json:"version"`
+	Food_id           *string             `json:"food_id" validate:"required"`
+	Order_item_id     string              `json:"order_item_id"`
+	Order_id          string              `json:"order_id" validate:"required"`
+	Seat              *int                `json:"seat" validate:"omitempty,min=1"`
+	Modifiers         []OrderItemModifier `json:"modifiers" validate:"omitempty,dive"`
+	Status            *string             `json:"status"`
+	Status_updated_at time.Time           `json:"status_updated_at"`
+	Deleted_at        *time.Time          `json:"deleted_at"`
+	Deleted_by        *string             `json:"deleted_by"`
+}
+
+// UnmarshalBSON reads the items stored before quantities were counts, whose
+// quantity is S, M or L, as one item, however they are loaded. Until
+// cmd/migrate-money rewrites them the stored quantity is left as it is.
+func (o *OrderItem) UnmarshalBSON(data []byte) error {
+	type plain OrderItem
+	quantity, err := bson.Raw(data).LookupErr("quantity")
+	if err == nil && quantity.Type != bsontype.String && quantity.Type != bsontype.Null {
+		return bson.Unmarshal(data, (*plain)(o))
+	}
+
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	counted := false
+	for i := range doc {
+		if doc[i].Key == "quantity" {
+			doc[i].Value, counted = 1, true
+		}
+	}
+	if !counted {
+		doc = append(doc, bson.E{Key: "quantity", Value: 1})
+	}
+	data, err = bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, (*plain)(o))
 }
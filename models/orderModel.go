@@ -6,11 +6,36 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Order lifecycle statuses. An order starts OPEN and only moves along the
+// transitions allowed by the order controller.
+const (
+	OrderOpen          = "OPEN"
+	OrderSentToKitchen = "SENT_TO_KITCHEN"
+	OrderServed        = "SERVED"
+	OrderBilled        = "BILLED"
+	OrderClosed        = "CLOSED"
+	OrderCancelled     = "CANCELLED"
+)
+
 type Order struct {
-	ID         primitive.ObjectID `bson:"_id"`
-	Order_Date time.Time          `json:"order_date" validate:"required"`
-	Created_at time.Time          `json:"created_at"`
-	Updated_at time.Time          `json:"updated_at"`
-	Order_id   string             `json:"order_id"`
-	Table_id   *string            `json:"table_id" validate:"required"`
+	ID             primitive.ObjectID `bson:"_id"`
+	Order_Date     time.Time          `json:"order_date" validate:"required"`
+	Created_at     time.Time          `json:"created_at"`
+	Updated_at     time.Time          `json:"updated_at"`
+	Version        int                `json:"version"`
+	Order_id       string             `json:"order_id"`
+	Table_id       *string            `json:"table_id" validate:"required"`
+	Status         *string            `json:"status"`
+	Status_history []OrderTransition  `json:"status_history"`
+	Deleted_at     *time.Time         `json:"deleted_at"`
+	Deleted_by     *string            `json:"deleted_by"`
+}
+
+// OrderTransition records one status change of an order.
+type OrderTransition struct {
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Reason     string    `json:"reason,omitempty"`
+	User_id    string    `json:"user_id"`
+	Changed_at time.Time `json:"changed_at"`
 }
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Reservation statuses. Only BOOKED and SEATED reservations hold their table.
+const (
+	ReservationBooked    = "BOOKED"
+	ReservationSeated    = "SEATED"
+	ReservationCompleted = "COMPLETED"
+	ReservationCancelled = "CANCELLED"
+	ReservationNoShow    = "NO_SHOW"
+)
+
+type Reservation struct {
+	ID               primitive.ObjectID `bson:"_id"`
+	Guest_name       *string            `json:"guest_name" validate:"required,min=2,max=100"`
+	Phone            *string            `json:"phone" validate:"required"`
+	Party_size       *int               `json:"party_size" validate:"required,min=1"`
+	Start_time       *time.Time         `json:"start_time" validate:"required"`
+	Duration_minutes *int               `json:"duration_minutes" validate:"omitempty,min=15,max=720"`
+	End_time         time.Time          `json:"end_time"`
+	Table_id         *string            `json:"table_id"`
+	Status           *string            `json:"status"`
+	Created_at       time.Time          `json:"created_at"`
+	Updated_at       time.Time          `json:"updated_at"`
+	Version          int                `json:"version"`
+	Reservation_id   string             `json:"reservation_id"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Statuses of purchase orders. An order is PARTIAL once some of it is
+// received and RECEIVED once all of it is.
+const (
+	PurchaseOrdered   = "ORDERED"
+	PurchasePartial   = "PARTIAL"
+	PurchaseReceived  = "RECEIVED"
+	PurchaseCancelled = "CANCELLED"
+)
+
+// Supplier is a vendor ingredients are bought from. Lead_time_days is how
+// many days a delivery usually takes after ordering.
+type Supplier struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	Name           string             `json:"name" validate:"required,max=100"`
+	Contact_name   string             `json:"contact_name" validate:"max=100"`
+	Email          string             `json:"email" validate:"omitempty,email"`
+	Phone          string             `json:"phone" validate:"max=30"`
+	Lead_time_days *int               `json:"lead_time_days" validate:"omitempty,min=0,max=90"`
+	Created_at     time.Time          `json:"created_at"`
+	Updated_at     time.Time          `json:"updated_at"`
+	Version        int                `json:"version"`
+	Supplier_id    string             `json:"supplier_id"`
+	Deleted_at     *time.Time         `json:"deleted_at"`
+	Deleted_by     *string            `json:"deleted_by"`
+}
+
+// PurchaseOrderLine is the quantity of an ingredient ordered, in its unit,
+// and how much of it has been received so far.
+type PurchaseOrderLine struct {
+	Ingredient_id string  `json:"ingredient_id" validate:"required"`
+	Quantity      float64 `json:"quantity" validate:"gt=0"`
+	Received      float64 `json:"received"`
+	Unit_cost     *Money  `json:"unit_cost"`
+}
+
+// Outstanding returns how much of the line is still to be received.
+func (l PurchaseOrderLine) Outstanding() float64 {
+	if l.Received >= l.Quantity {
+		return 0
+	}
+	return l.Quantity - l.Received
+}
+
+// PurchaseOrder is an order of ingredients placed with a supplier.
+type PurchaseOrder struct {
+	ID                primitive.ObjectID  `bson:"_id"`
+	Supplier_id       string              `json:"supplier_id" validate:"required"`
+	Lines             []PurchaseOrderLine `json:"lines" validate:"required,min=1,dive"`
+	Status            string              `json:"status"`
+	Expected_at       *time.Time          `json:"expected_at"`
+	Ordered_by        string              `json:"ordered_by"`
+	Created_at        time.Time           `json:"created_at"`
+	Updated_at        time.Time           `json:"updated_at"`
+	Version           int                 `json:"version"`
+	Purchase_order_id string              `json:"purchase_order_id"`
+	Deleted_at        *time.Time          `json:"deleted_at"`
+	Deleted_by        *string             `json:"deleted_by"`
+}
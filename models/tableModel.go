@@ -6,11 +6,49 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Table statuses. RESERVED is shown on the floor plan for an available
+// table whose next booking starts soon, it may also be set by hand.
+const (
+	TableAvailable     = "AVAILABLE"
+	TableSeated        = "SEATED"
+	TableOrdered       = "ORDERED"
+	TableNeedsCleaning = "NEEDS_CLEANING"
+	TableReserved      = "RESERVED"
+)
+
+// Table shapes drawn on the floor plan.
+const (
+	ShapeRound     = "ROUND"
+	ShapeSquare    = "SQUARE"
+	ShapeRectangle = "RECTANGLE"
+	ShapeBooth     = "BOOTH"
+)
+
 type Table struct {
 	ID               primitive.ObjectID `bson:"_id"`
 	Number_of_guests *int               `json:"number_of_guests" validate:"required"`
 	Table_number     *int               `json:"table_number" validate:"required"`
+	Status           *string            `json:"status" validate:"omitempty,eq=AVAILABLE|eq=SEATED|eq=ORDERED|eq=NEEDS_CLEANING|eq=RESERVED"`
+	Section          *string            `json:"section"`
+	Position_x       *float64           `json:"position_x"`
+	Position_y       *float64           `json:"position_y"`
+	Shape            *string            `json:"shape" validate:"omitempty,eq=ROUND|eq=SQUARE|eq=RECTANGLE|eq=BOOTH"`
+	Min_capacity     *int               `json:"min_capacity" validate:"omitempty,min=1"`
+	Max_capacity     *int               `json:"max_capacity" validate:"omitempty,min=1"`
 	Created_at       time.Time          `json:"created_at"`
 	Updated_at       time.Time          `json:"updated_at"`
+	Version          int                `json:"version"`
 	Table_id         string             `json:"table_id"`
+	Booked_slots     []TableSlot        `json:"-" bson:"booked_slots,omitempty"`
+	Deleted_at       *time.Time         `json:"deleted_at"`
+	Deleted_by       *string            `json:"deleted_by"`
+}
+
+// TableSlot is the time window a reservation holds a table for. Slots live
+// on the table document so that checking for overlaps and booking happen in
+// one atomic update.
+type TableSlot struct {
+	Reservation_id string    `bson:"reservation_id"`
+	Start_time     time.Time `bson:"start_time"`
+	End_time       time.Time `bson:"end_time"`
 }
@@ -6,17 +6,32 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Staff roles a user can hold. ADMIN passes every role check.
+const (
+	RoleAdmin   = "ADMIN"
+	RoleManager = "MANAGER"
+	RoleWaiter  = "WAITER"
+	RoleChef    = "CHEF"
+	RoleCashier = "CASHIER"
+)
+
+// User is a member of staff. The password hash and the stored tokens are
+// never rendered, only signing up, logging in and refreshing respond with
+// tokens.
 type User struct {
 	ID            primitive.ObjectID `bson:"_id"`
 	First_name    *string            `json:"first_name" validate:"required,min=2,max=100"`
 	Last_name     *string            `json:"last_name" validate:"required,min=2,max=100"`
-	Password      *string            `json:"Password" validate:"required,min=6"`
+	Password      *string            `json:"-" validate:"required,min=6"`
 	Email         *string            `json:"email" validate:"email,required"`
 	Avatar        *string            `json:"avatar"`
 	Phone         *string            `json:"phone" validate:"required"`
-	Token         *string            `json:"token"`
-	Refresh_Token *string            `json:"refresh_token"`
+	Role          *string            `json:"role" validate:"omitempty,eq=ADMIN|eq=MANAGER|eq=WAITER|eq=CHEF|eq=CASHIER"`
+	Token         *string            `json:"-"`
+	Refresh_Token *string            `json:"-"`
+	Token_version int                `json:"-"`
 	Created_at    time.Time          `json:"created_at"`
 	Updated_at    time.Time          `json:"updated_at"`
+	Version       int                `json:"version"`
 	User_id       string             `json:"user_id"`
 }
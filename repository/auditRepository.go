@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+// AuditRepository is append-only, entries are never changed or removed.
+type AuditRepository interface {
+	Append(ctx context.Context, entry models.AuditEntry) error
+	// List returns the page of entries selected by query and how many
+	// entries match its filters.
+	List(ctx context.Context, query Query) ([]models.AuditEntry, int64, error)
+}
+
+type mongoAuditRepository struct {
+	entries *mongo.Collection
+}
+
+func (r *mongoAuditRepository) Append(ctx context.Context, entry models.AuditEntry) error {
+	_, err := r.entries.InsertOne(ctx, entry)
+	return err
+}
+
+func (r *mongoAuditRepository) List(ctx context.Context, query Query) ([]models.AuditEntry, int64, error) {
+	return findPage[models.AuditEntry](ctx, r.entries, query)
+}
+
+type memoryAuditRepository struct {
+	entries *collection[models.AuditEntry]
+}
+
+func (r *memoryAuditRepository) Append(ctx context.Context, entry models.AuditEntry) error {
+	r.entries.insert(entry)
+	return nil
+}
+
+func (r *memoryAuditRepository) List(ctx context.Context, query Query) ([]models.AuditEntry, int64, error) {
+	entries, total := r.entries.query(query)
+	return entries, total, nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotDeleted keeps the documents that are not soft deleted.
+func (q Query) NotDeleted() Query {
+	return q.Where("deleted_at", OpEq, nil)
+}
+
+// softDelete marks the document whose idField is id deleted by the user at
+// the given time. It returns ErrConflict when the document is already
+// deleted.
+func softDelete[T any](ctx context.Context, collection *mongo.Collection, idField, id, deletedBy string, at time.Time) (T, error) {
+	return setDeletion[T](ctx, collection, idField, id,
+		bson.M{"deleted_at": nil},
+		bson.M{"deleted_at": at, "deleted_by": deletedBy})
+}
+
+// restore clears the deletion of the document whose idField is id. It
+// returns ErrConflict when the document is not deleted.
+func restore[T any](ctx context.Context, collection *mongo.Collection, idField, id string) (T, error) {
+	return setDeletion[T](ctx, collection, idField, id,
+		bson.M{"deleted_at": bson.M{"$ne": nil}},
+		bson.M{"deleted_at": nil, "deleted_by": nil})
+}
+
+func setDeletion[T any](ctx context.Context, collection *mongo.Collection, idField, id string, state, set bson.M) (T, error) {
+	var doc T
+	filter := bson.M{idField: id}
+	for key, value := range state {
+		filter[key] = value
+	}
+	err := collection.FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{"$set": set, "$inc": bumpVersion},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != mongo.ErrNoDocuments {
+		return doc, err
+	}
+	return doc, missingOrConflict(ctx, collection, idField, id)
+}
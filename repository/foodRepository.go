@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+type FoodRepository interface {
+	// List returns the page of foods selected by query and how many
+	// foods match its filters.
+	List(ctx context.Context, query Query) ([]models.Food, int64, error)
+	Get(ctx context.Context, foodId string) (models.Food, error)
+	// GetMany returns the foods with the given IDs keyed by ID, missing ones
+	// are left out.
+	GetMany(ctx context.Context, foodIds []string) (map[string]models.Food, error)
+	Create(ctx context.Context, food models.Food) error
+	// Update sets the name, description, price, image, menu, availability,
+	// modifier groups and recipe of changes that are not nil, the out of
+	// stock flag along with the recipe, and its update time, provided the
+	// food is at version when that is not nil. It returns ErrConflict when
+	// the food is at another version.
+	Update(ctx context.Context, foodId string, version *int, changes models.Food) (models.Food, error)
+	// ListByIngredient returns the foods that are not deleted and have the
+	// ingredient in their recipe.
+	ListByIngredient(ctx context.Context, ingredientId string) ([]models.Food, error)
+	// SetOutOfStock sets whether the food is 86'd.
+	SetOutOfStock(ctx context.Context, foodId string, outOfStock bool, at time.Time) error
+	// Delete marks the food deleted by the user at the given time. It
+	// returns ErrConflict when the food is already deleted.
+	Delete(ctx context.Context, foodId, deletedBy string, at time.Time) (models.Food, error)
+	// Restore clears the deletion of the food. It returns ErrConflict
+	// when the food is not deleted.
+	Restore(ctx context.Context, foodId string) (models.Food, error)
+}
+
+type mongoFoodRepository struct {
+	foods *mongo.Collection
+}
+
+func (r *mongoFoodRepository) List(ctx context.Context, query Query) ([]models.Food, int64, error) {
+	return findPage[models.Food](ctx, r.foods, query)
+}
+
+func (r *mongoFoodRepository) Get(ctx context.Context, foodId string) (models.Food, error) {
+	var food models.Food
+	err := r.foods.FindOne(ctx, bson.M{"food_id": foodId}).Decode(&food)
+	return food, notFound(err)
+}
+
+func (r *mongoFoodRepository) GetMany(ctx context.Context, foodIds []string) (map[string]models.Food, error) {
+	result, err := r.foods.Find(ctx, bson.M{"food_id": bson.M{"$in": foodIds}})
+	if err != nil {
+		return nil, err
+	}
+	var foods []models.Food
+	if err := result.All(ctx, &foods); err != nil {
+		return nil, err
+	}
+	found := map[string]models.Food{}
+	for _, food := range foods {
+		found[food.Food_id] = food
+	}
+	return found, nil
+}
+
+func (r *mongoFoodRepository) Create(ctx context.Context, food models.Food) error {
+	_, err := r.foods.InsertOne(ctx, food)
+	return err
+}
+
+func (r *mongoFoodRepository) Update(ctx context.Context, foodId string, version *int, changes models.Food) (models.Food, error) {
+	var updateObj primitive.D
+
+	if changes.Name != nil {
+		updateObj = append(updateObj, bson.E{Key: "name", Value: changes.Name})
+	}
+	if changes.Description != nil {
+		updateObj = append(updateObj, bson.E{Key: "description", Value: changes.Description})
+	}
+	if changes.Price != nil {
+		updateObj = append(updateObj, bson.E{Key: "price", Value: changes.Price})
+	}
+	if changes.Food_image != nil {
+		updateObj = append(updateObj, bson.E{Key: "food_image", Value: changes.Food_image})
+	}
+	if changes.Menu_id != nil {
+		updateObj = append(updateObj, bson.E{Key: "menu_id", Value: changes.Menu_id})
+	}
+	if changes.Available != nil {
+		updateObj = append(updateObj, bson.E{Key: "available", Value: changes.Available})
+	}
+	if changes.Modifier_groups != nil {
+		updateObj = append(updateObj, bson.E{Key: "modifier_groups", Value: changes.Modifier_groups})
+	}
+	if changes.Recipe != nil {
+		updateObj = append(updateObj, bson.E{Key: "recipe", Value: changes.Recipe})
+		updateObj = append(updateObj, bson.E{Key: "out_of_stock", Value: changes.Out_of_stock})
+	}
+	updateObj = append(updateObj, bson.E{Key: "updated_at", Value: changes.Updated_at})
+
+	return updateVersioned[models.Food](ctx, r.foods, "food_id", foodId, version, updateObj)
+}
+
+func (r *mongoFoodRepository) ListByIngredient(ctx context.Context, ingredientId string) ([]models.Food, error) {
+	result, err := r.foods.Find(ctx, bson.M{"recipe.ingredient_id": ingredientId, "deleted_at": nil})
+	if err != nil {
+		return nil, err
+	}
+	foods := []models.Food{}
+	if err := result.All(ctx, &foods); err != nil {
+		return nil, err
+	}
+	return foods, nil
+}
+
+func (r *mongoFoodRepository) SetOutOfStock(ctx context.Context, foodId string, outOfStock bool, at time.Time) error {
+	result, err := r.foods.UpdateOne(
+		ctx,
+		bson.M{"food_id": foodId},
+		bson.M{"$set": bson.M{"out_of_stock": outOfStock, "updated_at": at}, "$inc": bumpVersion},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoFoodRepository) Delete(ctx context.Context, foodId, deletedBy string, at time.Time) (models.Food, error) {
+	return softDelete[models.Food](ctx, r.foods, "food_id", foodId, deletedBy, at)
+}
+
+func (r *mongoFoodRepository) Restore(ctx context.Context, foodId string) (models.Food, error) {
+	return restore[models.Food](ctx, r.foods, "food_id", foodId)
+}
+
+type memoryFoodRepository struct {
+	foods *collection[models.Food]
+}
+
+func (r *memoryFoodRepository) List(ctx context.Context, query Query) ([]models.Food, int64, error) {
+	foods, total := r.foods.query(query)
+	return foods, total, nil
+}
+
+func (r *memoryFoodRepository) Get(ctx context.Context, foodId string) (models.Food, error) {
+	return r.foods.get(foodId)
+}
+
+func (r *memoryFoodRepository) GetMany(ctx context.Context, foodIds []string) (map[string]models.Food, error) {
+	return r.foods.getMany(foodIds), nil
+}
+
+func (r *memoryFoodRepository) Create(ctx context.Context, food models.Food) error {
+	r.foods.insert(food)
+	return nil
+}
+
+func (r *memoryFoodRepository) Update(ctx context.Context, foodId string, version *int, changes models.Food) (models.Food, error) {
+	return r.foods.update(foodId, func(food *models.Food) error {
+		if err := nextVersion(&food.Version, version); err != nil {
+			return err
+		}
+		if changes.Name != nil {
+			food.Name = changes.Name
+		}
+		if changes.Description != nil {
+			food.Description = changes.Description
+		}
+		if changes.Price != nil {
+			food.Price = changes.Price
+		}
+		if changes.Food_image != nil {
+			food.Food_image = changes.Food_image
+		}
+		if changes.Menu_id != nil {
+			food.Menu_id = changes.Menu_id
+		}
+		if changes.Available != nil {
+			food.Available = changes.Available
+		}
+		if changes.Modifier_groups != nil {
+			food.Modifier_groups = changes.Modifier_groups
+		}
+		if changes.Recipe != nil {
+			food.Recipe = changes.Recipe
+			food.Out_of_stock = changes.Out_of_stock
+		}
+		food.Updated_at = changes.Updated_at
+		return nil
+	})
+}
+
+func (r *memoryFoodRepository) ListByIngredient(ctx context.Context, ingredientId string) ([]models.Food, error) {
+	return r.foods.find(func(food *models.Food) bool {
+		if food.Deleted_at != nil {
+			return false
+		}
+		for _, line := range food.Recipe {
+			if line.Ingredient_id == ingredientId {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (r *memoryFoodRepository) SetOutOfStock(ctx context.Context, foodId string, outOfStock bool, at time.Time) error {
+	_, err := r.foods.update(foodId, func(food *models.Food) error {
+		food.Out_of_stock = outOfStock
+		food.Updated_at = at
+		food.Version++
+		return nil
+	})
+	return err
+}
+
+func (r *memoryFoodRepository) Delete(ctx context.Context, foodId, deletedBy string, at time.Time) (models.Food, error) {
+	return r.foods.update(foodId, func(food *models.Food) error {
+		if food.Deleted_at != nil {
+			return ErrConflict
+		}
+		food.Deleted_at, food.Deleted_by = &at, &deletedBy
+		food.Version++
+		return nil
+	})
+}
+
+func (r *memoryFoodRepository) Restore(ctx context.Context, foodId string) (models.Food, error) {
+	return r.foods.update(foodId, func(food *models.Food) error {
+		if food.Deleted_at == nil {
+			return ErrConflict
+		}
+		food.Deleted_at, food.Deleted_by = nil, nil
+		food.Version++
+		return nil
+	})
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Index is an index the MongoDB repositories rely on, either to look
+// documents up by their ID without a collection scan, to keep a field
+// unique or to search the text of fields.
+type Index struct {
+	Collection string
+	Name       string
+	Keys       bson.D
+	Unique     bool
+}
+
+// RequiredIndexes lists the indexes EnsureIndexes creates and the readiness
+// check expects.
+var RequiredIndexes = []Index{
+	{"food", "food_id", bson.D{{Key: "food_id", Value: 1}}, true},
+	{"food", "text", bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}}, false},
+	{"food", "recipe", bson.D{{Key: "recipe.ingredient_id", Value: 1}}, false},
+	{"menu", "menu_id", bson.D{{Key: "menu_id", Value: 1}}, true},
+	{"menu", "text", bson.D{{Key: "name", Value: "text"}, {Key: "category", Value: "text"}}, false},
+	{"table", "table_id", bson.D{{Key: "table_id", Value: 1}}, true},
+	{"order", "order_id", bson.D{{Key: "order_id", Value: 1}}, true},
+	{"orderItem", "order_item_id", bson.D{{Key: "order_item_id", Value: 1}}, true},
+	{"orderItem", "order_id", bson.D{{Key: "order_id", Value: 1}}, false},
+	{"invoice", "invoice_id", bson.D{{Key: "invoice_id", Value: 1}}, true},
+	{"user", "user_id", bson.D{{Key: "user_id", Value: 1}}, true},
+	{"user", "email", bson.D{{Key: "email", Value: 1}}, true},
+	{"reservation", "reservation_id", bson.D{{Key: "reservation_id", Value: 1}}, true},
+	{"reservation", "start_time", bson.D{{Key: "start_time", Value: 1}}, false},
+	{"audit", "audit_id", bson.D{{Key: "audit_id", Value: 1}}, true},
+	{"audit", "entity", bson.D{{Key: "entity_type", Value: 1}, {Key: "entity_id", Value: 1}, {Key: "created_at", Value: -1}}, false},
+	{"audit", "actor_id", bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}}, false},
+	{"note", "note_id", bson.D{{Key: "note_id", Value: 1}}, true},
+	{"note", "parent", bson.D{{Key: "parent_type", Value: 1}, {Key: "parent_id", Value: 1}, {Key: "created_at", Value: 1}}, false},
+	{"ingredient", "ingredient_id", bson.D{{Key: "ingredient_id", Value: 1}}, true},
+	{"stockMovement", "movement_id", bson.D{{Key: "movement_id", Value: 1}}, true},
+	{"stockMovement", "ingredient", bson.D{{Key: "ingredient_id", Value: 1}, {Key: "created_at", Value: -1}}, false},
+	{"stockMovement", "order_item_id", bson.D{{Key: "order_item_id", Value: 1}}, false},
+	{"supplier", "supplier_id", bson.D{{Key: "supplier_id", Value: 1}}, true},
+	{"purchaseOrder", "purchase_order_id", bson.D{{Key: "purchase_order_id", Value: 1}}, true},
+	{"purchaseOrder", "supplier", bson.D{{Key: "supplier_id", Value: 1}, {Key: "status", Value: 1}}, false},
+}
+
+// EnsureIndexes creates the required indexes that do not exist yet. It
+// tries every index, so one that cannot be built, e.g. a unique index over
+// duplicated data, does not keep the others from being created.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	var failed error
+	for _, index := range RequiredIndexes {
+		_, err := db.Collection(index.Collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    index.Keys,
+			Options: options.Index().SetName(index.Name).SetUnique(index.Unique),
+		})
+		if err != nil && failed == nil {
+			failed = fmt.Errorf("creating index %s.%s: %w", index.Collection, index.Name, err)
+		}
+	}
+	return failed
+}
+
+// MissingIndexes returns the required indexes, as collection.name, that do
+// not exist in db.
+func MissingIndexes(ctx context.Context, db *mongo.Database) ([]string, error) {
+	existing := make(map[string]bool)
+	var missing []string
+	for _, index := range RequiredIndexes {
+		if _, listed := existing[index.Collection]; !listed {
+			if err := listIndexes(ctx, db.Collection(index.Collection), existing); err != nil {
+				return nil, err
+			}
+		}
+		if !existing[index.Collection+"."+index.Name] {
+			missing = append(missing, index.Collection+"."+index.Name)
+		}
+	}
+	return missing, nil
+}
+
+// listIndexes adds the indexes of the collection to existing as
+// collection.name, and the collection itself so it is listed only once.
+func listIndexes(ctx context.Context, collection *mongo.Collection, existing map[string]bool) error {
+	existing[collection.Name()] = true
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		// listing the indexes of a collection that does not exist yet fails
+		// with NamespaceNotFound, it simply has none
+		if cmdErr, ok := err.(mongo.CommandError); ok && cmdErr.Code == 26 {
+			return nil
+		}
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var index struct {
+			Name string `bson:"name"`
+		}
+		if err := cursor.Decode(&index); err != nil {
+			return err
+		}
+		existing[collection.Name()+"."+index.Name] = true
+	}
+	return cursor.Err()
+}
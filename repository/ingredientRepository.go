@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+type IngredientRepository interface {
+	// List returns the page of ingredients selected by query and how many
+	// ingredients match its filters.
+	List(ctx context.Context, query Query) ([]models.Ingredient, int64, error)
+	Get(ctx context.Context, ingredientId string) (models.Ingredient, error)
+	// GetMany returns the ingredients with the given IDs keyed by ID,
+	// missing ones are left out.
+	GetMany(ctx context.Context, ingredientIds []string) (map[string]models.Ingredient, error)
+	Create(ctx context.Context, ingredient models.Ingredient) error
+	// Update sets the name and unit of changes unless empty, its low stock
+	// threshold, par level and supplier unless nil, and its update time,
+	// provided the ingredient is at version when that is not nil. It returns ErrConflict when the
+	// ingredient is at another version. The stock is left alone.
+	Update(ctx context.Context, ingredientId string, version *int, changes models.Ingredient) (models.Ingredient, error)
+	// Adjust adds quantity, negative to take stock, to the stock of the
+	// ingredient with a single conditional increment, so concurrent takes
+	// can never drive the stock below zero. It returns ErrConflict when less
+	// than the quantity taken is on hand.
+	Adjust(ctx context.Context, ingredientId string, quantity float64, at time.Time) (models.Ingredient, error)
+	// Count sets the stock of the ingredient to what was counted.
+	Count(ctx context.Context, ingredientId string, onHand float64, at time.Time) (models.Ingredient, error)
+	// Delete marks the ingredient deleted by the user at the given time. It
+	// returns ErrConflict when the ingredient is already deleted.
+	Delete(ctx context.Context, ingredientId, deletedBy string, at time.Time) (models.Ingredient, error)
+	// Restore clears the deletion of the ingredient. It returns ErrConflict
+	// when the ingredient is not deleted.
+	Restore(ctx context.Context, ingredientId string) (models.Ingredient, error)
+}
+
+type mongoIngredientRepository struct {
+	ingredients *mongo.Collection
+}
+
+// lowStockStage recomputes the low stock flag of an ingredient after its
+// stock or threshold changed. Missing and null thresholds sort below
+// numbers, so an ingredient without one is never low on stock.
+var lowStockStage = bson.D{{Key: "$set", Value: bson.M{
+	"low_stock": bson.M{"$and": bson.A{
+		bson.M{"$gt": bson.A{"$low_stock_threshold", nil}},
+		bson.M{"$lte": bson.A{"$on_hand", "$low_stock_threshold"}},
+	}},
+}}}
+
+func (r *mongoIngredientRepository) List(ctx context.Context, query Query) ([]models.Ingredient, int64, error) {
+	return findPage[models.Ingredient](ctx, r.ingredients, query)
+}
+
+func (r *mongoIngredientRepository) Get(ctx context.Context, ingredientId string) (models.Ingredient, error) {
+	var ingredient models.Ingredient
+	err := r.ingredients.FindOne(ctx, bson.M{"ingredient_id": ingredientId}).Decode(&ingredient)
+	return ingredient, notFound(err)
+}
+
+func (r *mongoIngredientRepository) GetMany(ctx context.Context, ingredientIds []string) (map[string]models.Ingredient, error) {
+	result, err := r.ingredients.Find(ctx, bson.M{"ingredient_id": bson.M{"$in": ingredientIds}})
+	if err != nil {
+		return nil, err
+	}
+	var ingredients []models.Ingredient
+	if err := result.All(ctx, &ingredients); err != nil {
+		return nil, err
+	}
+	found := map[string]models.Ingredient{}
+	for _, ingredient := range ingredients {
+		found[ingredient.Ingredient_id] = ingredient
+	}
+	return found, nil
+}
+
+func (r *mongoIngredientRepository) Create(ctx context.Context, ingredient models.Ingredient) error {
+	_, err := r.ingredients.InsertOne(ctx, ingredient)
+	return err
+}
+
+func (r *mongoIngredientRepository) Update(ctx context.Context, ingredientId string, version *int, changes models.Ingredient) (models.Ingredient, error) {
+	// values are wrapped in $literal, in an update pipeline a string
+	// starting with $ would be read as a field
+	set := bson.D{}
+	if changes.Name != "" {
+		set = append(set, bson.E{Key: "name", Value: bson.M{"$literal": changes.Name}})
+	}
+	if changes.Unit != "" {
+		set = append(set, bson.E{Key: "unit", Value: bson.M{"$literal": changes.Unit}})
+	}
+	if changes.Low_stock_threshold != nil {
+		set = append(set, bson.E{Key: "low_stock_threshold", Value: *changes.Low_stock_threshold})
+	}
+	if changes.Par_level != nil {
+		set = append(set, bson.E{Key: "par_level", Value: *changes.Par_level})
+	}
+	if changes.Supplier_id != nil {
+		set = append(set, bson.E{Key: "supplier_id", Value: bson.M{"$literal": *changes.Supplier_id}})
+	}
+	set = append(set, bson.E{Key: "updated_at", Value: changes.Updated_at})
+
+	return r.updateStock(ctx, ingredientId, atVersion(bson.M{"ingredient_id": ingredientId}, version), set)
+}
+
+func (r *mongoIngredientRepository) Adjust(ctx context.Context, ingredientId string, quantity float64, at time.Time) (models.Ingredient, error) {
+	// the check and the decrement are one write, two tickets fired at once
+	// cannot both pass the check
+	filter := bson.M{"ingredient_id": ingredientId}
+	if quantity < 0 {
+		filter["on_hand"] = bson.M{"$gte": -quantity}
+	}
+	var ingredient models.Ingredient
+	err := r.ingredients.FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{
+			"$inc": bson.M{"on_hand": quantity, "version": 1},
+			"$set": bson.M{"updated_at": at},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&ingredient)
+	if err == mongo.ErrNoDocuments {
+		return ingredient, missingOrConflict(ctx, r.ingredients, "ingredient_id", ingredientId)
+	} else if err != nil {
+		return ingredient, err
+	}
+	// the flag is recomputed from the stored stock, which is safe to repeat
+	// whatever changed the stock meanwhile
+	_, err = r.ingredients.UpdateOne(ctx, bson.M{"ingredient_id": ingredientId}, mongo.Pipeline{lowStockStage})
+	ingredient.Low_stock = ingredient.BelowThreshold()
+	return ingredient, err
+}
+
+func (r *mongoIngredientRepository) Count(ctx context.Context, ingredientId string, onHand float64, at time.Time) (models.Ingredient, error) {
+	return r.updateStock(ctx, ingredientId, bson.M{"ingredient_id": ingredientId}, bson.D{
+		{Key: "on_hand", Value: onHand},
+		{Key: "updated_at", Value: at},
+	})
+}
+
+// updateStock sets the fields of the ingredient matching filter, bumps its
+// version and recomputes its low stock flag in one update pipeline. It
+// returns the updated ingredient, ErrNotFound when there is none and
+// ErrConflict when it does not match filter.
+func (r *mongoIngredientRepository) updateStock(ctx context.Context, ingredientId string, filter bson.M, set bson.D) (models.Ingredient, error) {
+	set = append(set, bson.E{Key: "version", Value: bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}}})
+	var ingredient models.Ingredient
+	err := r.ingredients.FindOneAndUpdate(
+		ctx,
+		filter,
+		mongo.Pipeline{{{Key: "$set", Value: set}}, lowStockStage},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&ingredient)
+	if err != mongo.ErrNoDocuments {
+		return ingredient, err
+	}
+	return ingredient, missingOrConflict(ctx, r.ingredients, "ingredient_id", ingredientId)
+}
+
+func (r *mongoIngredientRepository) Delete(ctx context.Context, ingredientId, deletedBy string, at time.Time) (models.Ingredient, error) {
+	return softDelete[models.Ingredient](ctx, r.ingredients, "ingredient_id", ingredientId, deletedBy, at)
+}
+
+func (r *mongoIngredientRepository) Restore(ctx context.Context, ingredientId string) (models.Ingredient, error) {
+	return restore[models.Ingredient](ctx, r.ingredients, "ingredient_id", ingredientId)
+}
+
+type memoryIngredientRepository struct {
+	ingredients *collection[models.Ingredient]
+}
+
+func (r *memoryIngredientRepository) List(ctx context.Context, query Query) ([]models.Ingredient, int64, error) {
+	ingredients, total := r.ingredients.query(query)
+	return ingredients, total, nil
+}
+
+func (r *memoryIngredientRepository) Get(ctx context.Context, ingredientId string) (models.Ingredient, error) {
+	return r.ingredients.get(ingredientId)
+}
+
+func (r *memoryIngredientRepository) GetMany(ctx context.Context, ingredientIds []string) (map[string]models.Ingredient, error) {
+	return r.ingredients.getMany(ingredientIds), nil
+}
+
+func (r *memoryIngredientRepository) Create(ctx context.Context, ingredient models.Ingredient) error {
+	r.ingredients.insert(ingredient)
+	return nil
+}
+
+func (r *memoryIngredientRepository) Update(ctx context.Context, ingredientId string, version *int, changes models.Ingredient) (models.Ingredient, error) {
+	return r.ingredients.update(ingredientId, func(ingredient *models.Ingredient) error {
+		if err := nextVersion(&ingredient.Version, version); err != nil {
+			return err
+		}
+		if changes.Name != "" {
+			ingredient.Name = changes.Name
+		}
+		if changes.Unit != "" {
+			ingredient.Unit = changes.Unit
+		}
+		if changes.Low_stock_threshold != nil {
+			ingredient.Low_stock_threshold = changes.Low_stock_threshold
+		}
+		if changes.Par_level != nil {
+			ingredient.Par_level = changes.Par_level
+		}
+		if changes.Supplier_id != nil {
+			ingredient.Supplier_id = changes.Supplier_id
+		}
+		ingredient.Low_stock = ingredient.BelowThreshold()
+		ingredient.Updated_at = changes.Updated_at
+		return nil
+	})
+}
+
+func (r *memoryIngredientRepository) Adjust(ctx context.Context, ingredientId string, quantity float64, at time.Time) (models.Ingredient, error) {
+	return r.ingredients.update(ingredientId, func(ingredient *models.Ingredient) error {
+		if quantity < 0 && ingredient.On_hand < -quantity {
+			return ErrConflict
+		}
+		ingredient.On_hand += quantity
+		ingredient.Low_stock = ingredient.BelowThreshold()
+		ingredient.Updated_at = at
+		ingredient.Version++
+		return nil
+	})
+}
+
+func (r *memoryIngredientRepository) Count(ctx context.Context, ingredientId string, onHand float64, at time.Time) (models.Ingredient, error) {
+	return r.ingredients.update(ingredientId, func(ingredient *models.Ingredient) error {
+		ingredient.On_hand = onHand
+		ingredient.Low_stock = ingredient.BelowThreshold()
+		ingredient.Updated_at = at
+		ingredient.Version++
+		return nil
+	})
+}
+
+func (r *memoryIngredientRepository) Delete(ctx context.Context, ingredientId, deletedBy string, at time.Time) (models.Ingredient, error) {
+	return r.ingredients.update(ingredientId, func(ingredient *models.Ingredient) error {
+		if ingredient.Deleted_at != nil {
+			return ErrConflict
+		}
+		ingredient.Deleted_at, ingredient.Deleted_by = &at, &deletedBy
+		ingredient.Version++
+		return nil
+	})
+}
+
+func (r *memoryIngredientRepository) Restore(ctx context.Context, ingredientId string) (models.Ingredient, error) {
+	return r.ingredients.update(ingredientId, func(ingredient *models.Ingredient) error {
+		if ingredient.Deleted_at == nil {
+			return ErrConflict
+		}
+		ingredient.Deleted_at, ingredient.Deleted_by = nil, nil
+		ingredient.Version++
+		return nil
+	})
+}
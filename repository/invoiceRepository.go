@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+type InvoiceRepository interface {
+	// List returns the page of invoices selected by query and how many
+	// invoices match its filters.
+	List(ctx context.Context, query Query) ([]models.Invoice, int64, error)
+	Get(ctx context.Context, invoiceId string) (models.Invoice, error)
+	Create(ctx context.Context, invoice models.Invoice) error
+	// Update sets the payment method of changes unless nil and its update
+	// time, provided the invoice is at version when that is not nil. It
+	// returns ErrConflict when the invoice is at another version.
+	Update(ctx context.Context, invoiceId string, version *int, changes models.Invoice) (models.Invoice, error)
+	// SavePayments stores the payments, splits, payment status and method
+	// and the update time of invoice, provided the stored invoice still has
+	// paymentsSeen payments. Otherwise it returns ErrConflict, as any check
+	// made against the payments read is stale.
+	SavePayments(ctx context.Context, invoice models.Invoice, paymentsSeen int) error
+	// Delete marks the invoice deleted by the user at the given time. It
+	// returns ErrConflict when the invoice is already deleted.
+	Delete(ctx context.Context, invoiceId, deletedBy string, at time.Time) (models.Invoice, error)
+	// Restore clears the deletion of the invoice. It returns ErrConflict
+	// when the invoice is not deleted.
+	Restore(ctx context.Context, invoiceId string) (models.Invoice, error)
+}
+
+type mongoInvoiceRepository struct {
+	invoices *mongo.Collection
+}
+
+func (r *mongoInvoiceRepository) List(ctx context.Context, query Query) ([]models.Invoice, int64, error) {
+	return findPage[models.Invoice](ctx, r.invoices, query)
+}
+
+func (r *mongoInvoiceRepository) Get(ctx context.Context, invoiceId string) (models.Invoice, error) {
+	var invoice models.Invoice
+	err := r.invoices.FindOne(ctx, bson.M{"invoice_id": invoiceId}).Decode(&invoice)
+	return invoice, notFound(err)
+}
+
+func (r *mongoInvoiceRepository) Create(ctx context.Context, invoice models.Invoice) error {
+	_, err := r.invoices.InsertOne(ctx, invoice)
+	return err
+}
+
+func (r *mongoInvoiceRepository) Update(ctx context.Context, invoiceId string, version *int, changes models.Invoice) (models.Invoice, error) {
+	var updateObj primitive.D
+
+	if changes.Payment_method != nil {
+		updateObj = append(updateObj, bson.E{Key: "payment_method", Value: changes.Payment_method})
+	}
+	updateObj = append(updateObj, bson.E{Key: "updated_at", Value: changes.Updated_at})
+
+	return updateVersioned[models.Invoice](ctx, r.invoices, "invoice_id", invoiceId, version, updateObj)
+}
+
+func (r *mongoInvoiceRepository) SavePayments(ctx context.Context, invoice models.Invoice, paymentsSeen int) error {
+	filter := bson.M{"invoice_id": invoice.Invoice_id, "payments": bson.M{"$size": paymentsSeen}}
+	if paymentsSeen == 0 {
+		filter = bson.M{
+			"invoice_id": invoice.Invoice_id,
+			"$or": bson.A{
+				bson.M{"payments": bson.M{"$exists": false}},
+				bson.M{"payments": nil},
+				bson.M{"payments": bson.M{"$size": 0}},
+			},
+		}
+	}
+
+	result, err := r.invoices.UpdateOne(
+		ctx,
+		filter,
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "payments", Value: invoice.Payments},
+				{Key: "splits", Value: invoice.Splits},
+				{Key: "payment_status", Value: invoice.Payment_status},
+				{Key: "payment_method", Value: invoice.Payment_method},
+				{Key: "updated_at", Value: invoice.Updated_at},
+			}},
+			{Key: "$inc", Value: bumpVersion},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *mongoInvoiceRepository) Delete(ctx context.Context, invoiceId, deletedBy string, at time.Time) (models.Invoice, error) {
+	return softDelete[models.Invoice](ctx, r.invoices, "invoice_id", invoiceId, deletedBy, at)
+}
+
+func (r *mongoInvoiceRepository) Restore(ctx context.Context, invoiceId string) (models.Invoice, error) {
+	return restore[models.Invoice](ctx, r.invoices, "invoice_id", invoiceId)
+}
+
+type memoryInvoiceRepository struct {
+	invoices *collection[models.Invoice]
+}
+
+func (r *memoryInvoiceRepository) List(ctx context.Context, query Query) ([]models.Invoice, int64, error) {
+	invoices, total := r.invoices.query(query)
+	return invoices, total, nil
+}
+
+func (r *memoryInvoiceRepository) Get(ctx context.Context, invoiceId string) (models.Invoice, error) {
+	return r.invoices.get(invoiceId)
+}
+
+func (r *memoryInvoiceRepository) Create(ctx context.Context, invoice models.Invoice) error {
+	r.invoices.insert(invoice)
+	return nil
+}
+
+func (r *memoryInvoiceRepository) Update(ctx context.Context, invoiceId string, version *int, changes models.Invoice) (models.Invoice, error) {
+	return r.invoices.update(invoiceId, func(invoice *models.Invoice) error {
+		if err := nextVersion(&invoice.Version, version); err != nil {
+			return err
+		}
+		if changes.Payment_method != nil {
+			invoice.Payment_method = changes.Payment_method
+		}
+		invoice.Updated_at = changes.Updated_at
+		return nil
+	})
+}
+
+func (r *memoryInvoiceRepository) SavePayments(ctx context.Context, invoice models.Invoice, paymentsSeen int) error {
+	_, err := r.invoices.update(invoice.Invoice_id, func(stored *models.Invoice) error {
+		if len(stored.Payments) != paymentsSeen {
+			return ErrConflict
+		}
+		stored.Payments = invoice.Payments
+		stored.Splits = invoice.Splits
+		stored.Payment_status = invoice.Payment_status
+		stored.Payment_method = invoice.Payment_method
+		stored.Updated_at = invoice.Updated_at
+		stored.Version++
+		return nil
+	})
+	if err == ErrNotFound {
+		return ErrConflict
+	}
+	return err
+}
+
+func (r *memoryInvoiceRepository) Delete(ctx context.Context, invoiceId, deletedBy string, at time.Time) (models.Invoice, error) {
+	return r.invoices.update(invoiceId, func(invoice *models.Invoice) error {
+		if invoice.Deleted_at != nil {
+			return ErrConflict
+		}
+		invoice.Deleted_at, invoice.Deleted_by = &at, &deletedBy
+		invoice.Version++
+		return nil
+	})
+}
+
+func (r *memoryInvoiceRepository) Restore(ctx context.Context, invoiceId string) (models.Invoice, error) {
+	return r.invoices.update(invoiceId, func(invoice *models.Invoice) error {
+		if invoice.Deleted_at == nil {
+			return ErrConflict
+		}
+		invoice.Deleted_at, invoice.Deleted_by = nil, nil
+		invoice.Version++
+		return nil
+	})
+}
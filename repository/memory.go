@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// collection is the in-memory counterpart of a MongoDB collection. Documents
+// are kept in insertion order and copied through BSON on the way in and out,
+// so callers never share memory with the store and values round trip the
+// same way they do through the database.
+type collection[T any] struct {
+	mu   sync.Mutex
+	docs []T
+	id   func(*T) string
+}
+
+func newCollection[T any](id func(*T) string) *collection[T] {
+	return &collection[T]{id: id}
+}
+
+func clone[T any](doc T) T {
+	var copied T
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		panic(fmt.Sprintf("repository: cannot copy %T: %v", doc, err))
+	}
+	if err := bson.Unmarshal(data, &copied); err != nil {
+		panic(fmt.Sprintf("repository: cannot copy %T: %v", doc, err))
+	}
+	return copied
+}
+
+func (c *collection[T]) get(id string) (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.docs {
+		if c.id(&c.docs[i]) == id {
+			return clone(c.docs[i]), nil
+		}
+	}
+	var zero T
+	return zero, ErrNotFound
+}
+
+// find returns the documents matching match, all of them if it is nil.
+func (c *collection[T]) find(match func(*T) bool) []T {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	found := []T{}
+	for i := range c.docs {
+		if match == nil || match(&c.docs[i]) {
+			found = append(found, clone(c.docs[i]))
+		}
+	}
+	return found
+}
+
+// findSorted is find with the result ordered by less.
+func (c *collection[T]) findSorted(match func(*T) bool, less func(a, b *T) bool) []T {
+	found := c.find(match)
+	sort.SliceStable(found, func(i, j int) bool { return less(&found[i], &found[j]) })
+	return found
+}
+
+func (c *collection[T]) getMany(ids []string) map[string]T {
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	found := map[string]T{}
+	for _, doc := range c.find(func(doc *T) bool { return wanted[c.id(doc)] }) {
+		found[c.id(&doc)] = doc
+	}
+	return found
+}
+
+func (c *collection[T]) count(match func(*T) bool) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var n int64
+	for i := range c.docs {
+		if match == nil || match(&c.docs[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *collection[T]) insert(docs ...T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, doc := range docs {
+		c.docs = append(c.docs, clone(doc))
+	}
+}
+
+// update applies change to the document with the given ID while holding
+// the lock, so checks made by change and the write are atomic. An error
+// returned by change leaves the document untouched. It returns the updated
+// document.
+func (c *collection[T]) update(id string, change func(*T) error) (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero T
+	for i := range c.docs {
+		if c.id(&c.docs[i]) != id {
+			continue
+		}
+		doc := clone(c.docs[i])
+		if err := change(&doc); err != nil {
+			return zero, err
+		}
+		c.docs[i] = clone(doc)
+		return doc, nil
+	}
+	return zero, ErrNotFound
+}
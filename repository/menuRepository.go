@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+type MenuRepository interface {
+	// List returns the page of menus selected by query and how many
+	// menus match its filters.
+	List(ctx context.Context, query Query) ([]models.Menu, int64, error)
+	Get(ctx context.Context, menuId string) (models.Menu, error)
+	// GetMany returns the menus with the given IDs keyed by ID, missing ones
+	// are left out.
+	GetMany(ctx context.Context, menuIds []string) (map[string]models.Menu, error)
+	Create(ctx context.Context, menu models.Menu) error
+	// Update sets the dates and the schedule of changes that are not nil,
+	// its name and category unless empty, and its update time, provided the
+	// menu is at version when that is not nil. An empty schedule serves the
+	// menu all day. It returns ErrConflict when the menu is at another
+	// version.
+	Update(ctx context.Context, menuId string, version *int, changes models.Menu) (models.Menu, error)
+	// Delete marks the menu deleted by the user at the given time. It
+	// returns ErrConflict when the menu is already deleted.
+	Delete(ctx context.Context, menuId, deletedBy string, at time.Time) (models.Menu, error)
+	// Restore clears the deletion of the menu. It returns ErrConflict
+	// when the menu is not deleted.
+	Restore(ctx context.Context, menuId string) (models.Menu, error)
+}
+
+type mongoMenuRepository struct {
+	menus *mongo.Collection
+}
+
+func (r *mongoMenuRepository) List(ctx context.Context, query Query) ([]models.Menu, int64, error) {
+	return findPage[models.Menu](ctx, r.menus, query)
+}
+
+func (r *mongoMenuRepository) Get(ctx context.Context, menuId string) (models.Menu, error) {
+	var menu models.Menu
+	err := r.menus.FindOne(ctx, bson.M{"menu_id": menuId}).Decode(&menu)
+	return menu, notFound(err)
+}
+
+func (r *mongoMenuRepository) GetMany(ctx context.Context, menuIds []string) (map[string]models.Menu, error) {
+	result, err := r.menus.Find(ctx, bson.M{"menu_id": bson.M{"$in": menuIds}})
+	if err != nil {
+		return nil, err
+	}
+	var menus []models.Menu
+	if err := result.All(ctx, &menus); err != nil {
+		return nil, err
+	}
+	found := map[string]models.Menu{}
+	for _, menu := range menus {
+		found[menu.Menu_id] = menu
+	}
+	return found, nil
+}
+
+func (r *mongoMenuRepository) Create(ctx context.Context, menu models.Menu) error {
+	_, err := r.menus.InsertOne(ctx, menu)
+	return err
+}
+
+func (r *mongoMenuRepository) Update(ctx context.Context, menuId string, version *int, changes models.Menu) (models.Menu, error) {
+	var updateObj primitive.D
+
+	if changes.Start_Date != nil {
+		updateObj = append(updateObj, bson.E{Key: "start_date", Value: changes.Start_Date})
+	}
+	if changes.End_Date != nil {
+		updateObj = append(updateObj, bson.E{Key: "end_date", Value: changes.End_Date})
+	}
+	if changes.Schedule != nil {
+		updateObj = append(updateObj, bson.E{Key: "schedule", Value: changes.Schedule})
+	}
+	if changes.Name != "" {
+		updateObj = append(updateObj, bson.E{Key: "name", Value: changes.Name})
+	}
+	if changes.Category != "" {
+		updateObj = append(updateObj, bson.E{Key: "category", Value: changes.Category})
+	}
+	updateObj = append(updateObj, bson.E{Key: "updated_at", Value: changes.Updated_at})
+
+	return updateVersioned[models.Menu](ctx, r.menus, "menu_id", menuId, version, updateObj)
+}
+
+func (r *mongoMenuRepository) Delete(ctx context.Context, menuId, deletedBy string, at time.Time) (models.Menu, error) {
+	return softDelete[models.Menu](ctx, r.menus, "menu_id", menuId, deletedBy, at)
+}
+
+func (r *mongoMenuRepository) Restore(ctx context.Context, menuId string) (models.Menu, error) {
+	return restore[models.Menu](ctx, r.menus, "menu_id", menuId)
+}
+
+type memoryMenuRepository struct {
+	menus *collection[models.Menu]
+}
+
+func (r *memoryMenuRepository) List(ctx context.Context, query Query) ([]models.Menu, int64, error) {
+	menus, total := r.menus.query(query)
+	return menus, total, nil
+}
+
+func (r *memoryMenuRepository) Get(ctx context.Context, menuId string) (models.Menu, error) {
+	return r.menus.get(menuId)
+}
+
+func (r *memoryMenuRepository) GetMany(ctx context.Context, menuIds []string) (map[string]models.Menu, error) {
+	return r.menus.getMany(menuIds), nil
+}
+
+func (r *memoryMenuRepository) Create(ctx context.Context, menu models.Menu) error {
+	r.menus.insert(menu)
+	return nil
+}
+
+func (r *memoryMenuRepository) Update(ctx context.Context, menuId string, version *int, changes models.Menu) (models.Menu, error) {
+	return r.menus.update(menuId, func(menu *models.Menu) error {
+		if err := nextVersion(&menu.Version, version); err != nil {
+			return err
+		}
+		if changes.Start_Date != nil {
+			menu.Start_Date = changes.Start_Date
+		}
+		if changes.End_Date != nil {
+			menu.End_Date = changes.End_Date
+		}
+		if changes.Schedule != nil {
+			menu.Schedule = changes.Schedule
+		}
+		if changes.Name != "" {
+			menu.Name = changes.Name
+		}
+		if changes.Category != "" {
+			menu.Category = changes.Category
+		}
+		menu.Updated_at = changes.Updated_at
+		return nil
+	})
+}
+
+func (r *memoryMenuRepository) Delete(ctx context.Context, menuId, deletedBy string, at time.Time) (models.Menu, error) {
+	return r.menus.update(menuId, func(menu *models.Menu) error {
+		if menu.Deleted_at != nil {
+			return ErrConflict
+		}
+		menu.Deleted_at, menu.Deleted_by = &at, &deletedBy
+		menu.Version++
+		return nil
+	})
+}
+
+func (r *memoryMenuRepository) Restore(ctx context.Context, menuId string) (models.Menu, error) {
+	return r.menus.update(menuId, func(menu *models.Menu) error {
+		if menu.Deleted_at == nil {
+			return ErrConflict
+		}
+		menu.Deleted_at, menu.Deleted_by = nil, nil
+		menu.Version++
+		return nil
+	})
+}
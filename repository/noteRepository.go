@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+type NoteRepository interface {
+	// List returns the page of notes selected by query and how many notes
+	// match its filters.
+	List(ctx context.Context, query Query) ([]models.Note, int64, error)
+	Get(ctx context.Context, noteId string) (models.Note, error)
+	Create(ctx context.Context, note models.Note) error
+	// Update sets the title and text of changes unless empty and its update
+	// time, provided the note is at version when that is not nil. It
+	// returns ErrConflict when the note is at another version.
+	Update(ctx context.Context, noteId string, version *int, changes models.Note) (models.Note, error)
+	// Delete marks the note deleted by the user at the given time. It
+	// returns ErrConflict when the note is already deleted.
+	Delete(ctx context.Context, noteId, deletedBy string, at time.Time) (models.Note, error)
+	// Restore clears the deletion of the note. It returns ErrConflict
+	// when the note is not deleted.
+	Restore(ctx context.Context, noteId string) (models.Note, error)
+}
+
+type mongoNoteRepository struct {
+	notes *mongo.Collection
+}
+
+func (r *mongoNoteRepository) List(ctx context.Context, query Query) ([]models.Note, int64, error) {
+	return findPage[models.Note](ctx, r.notes, query)
+}
+
+func (r *mongoNoteRepository) Get(ctx context.Context, noteId string) (models.Note, error) {
+	var note models.Note
+	err := r.notes.FindOne(ctx, bson.M{"note_id": noteId}).Decode(&note)
+	return note, notFound(err)
+}
+
+func (r *mongoNoteRepository) Create(ctx context.Context, note models.Note) error {
+	_, err := r.notes.InsertOne(ctx, note)
+	return err
+}
+
+func (r *mongoNoteRepository) Update(ctx context.Context, noteId string, version *int, changes models.Note) (models.Note, error) {
+	var updateObj primitive.D
+
+	if changes.Title != "" {
+		updateObj = append(updateObj, bson.E{Key: "title", Value: changes.Title})
+	}
+	if changes.Text != "" {
+		updateObj = append(updateObj, bson.E{Key: "text", Value: changes.Text})
+	}
+	updateObj = append(updateObj, bson.E{Key: "updated_at", Value: changes.Updated_at})
+
+	return updateVersioned[models.Note](ctx, r.notes, "note_id", noteId, version, updateObj)
+}
+
+func (r *mongoNoteRepository) Delete(ctx context.Context, noteId, deletedBy string, at time.Time) (models.Note, error) {
+	return softDelete[models.Note](ctx, r.notes, "note_id", noteId, deletedBy, at)
+}
+
+func (r *mongoNoteRepository) Restore(ctx context.Context, noteId string) (models.Note, error) {
+	return restore[models.Note](ctx, r.notes, "note_id", noteId)
+}
+
+type memoryNoteRepository struct {
+	notes *collection[models.Note]
+}
+
+func (r *memoryNoteRepository) List(ctx context.Context, query Query) ([]models.Note, int64, error) {
+	notes, total := r.notes.query(query)
+	return notes, total, nil
+}
+
+func (r *memoryNoteRepository) Get(ctx context.Context, noteId string) (models.Note, error) {
+	return r.notes.get(noteId)
+}
+
+func (r *memoryNoteRepository) Create(ctx context.Context, note models.Note) error {
+	r.notes.insert(note)
+	return nil
+}
+
+func (r *memoryNoteRepository) Update(ctx context.Context, noteId string, version *int, changes models.Note) (models.Note, error) {
+	return r.notes.update(noteId, func(note *models.Note) error {
+		if err := nextVersion(&note.Version, version); err != nil {
+			return err
+		}
+		if changes.Title != "" {
+			note.Title = changes.Title
+		}
+		if changes.Text != "" {
+			note.Text = changes.Text
+		}
+		note.Updated_at = changes.Updated_at
+		return nil
+	})
+}
+
+func (r *memoryNoteRepository) Delete(ctx context.Context, noteId, deletedBy string, at time.Time) (models.Note, error) {
+	return r.notes.update(noteId, func(note *models.Note) error {
+		if note.Deleted_at != nil {
+			return ErrConflict
+		}
+		note.Deleted_at, note.Deleted_by = &at, &deletedBy
+		note.Version++
+		return nil
+	})
+}
+
+func (r *memoryNoteRepository) Restore(ctx context.Context, noteId string) (models.Note, error) {
+	return r.notes.update(noteId, func(note *models.Note) error {
+		if note.Deleted_at == nil {
+			return ErrConflict
+		}
+		note.Deleted_at, note.Deleted_by = nil, nil
+		note.Version++
+		return nil
+	})
+}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+type OrderItemRepository interface {
+	// List returns the page of ordered items selected by query and how many
+	// ordered items match its filters.
+	List(ctx context.Context, query Query) ([]models.OrderItem, int64, error)
+	Get(ctx context.Context, orderItemId string) (models.OrderItem, error)
+	// ListByOrder returns the items of the order that are not deleted,
+	// oldest first.
+	ListByOrder(ctx context.Context, orderId string) ([]models.OrderItem, error)
+	// ListByStatus returns the items in one of the statuses that are not
+	// deleted, oldest first.
+	ListByStatus(ctx context.Context, statuses ...string) ([]models.OrderItem, error)
+	CreateMany(ctx context.Context, orderItems []models.OrderItem) error
+	// Update sets the unit price, quantity, seat, food and modifiers of
+	// changes that are not nil, and its update time, provided the item is
+	// at version when that is not nil. It returns ErrConflict when the item
+	// is at another version.
+	Update(ctx context.Context, orderItemId string, version *int, changes models.OrderItem) (models.OrderItem, error)
+	// SetStatus moves the item to status, provided it still has the status
+	// from. Otherwise it returns ErrConflict.
+	SetStatus(ctx context.Context, orderItemId string, from *string, status string, at time.Time) error
+	// Delete marks the ordered item deleted by the user at the given time. It
+	// returns ErrConflict when the ordered item is already deleted.
+	Delete(ctx context.Context, orderItemId, deletedBy string, at time.Time) (models.OrderItem, error)
+	// Restore clears the deletion of the ordered item. It returns ErrConflict
+	// when the ordered item is not deleted.
+	Restore(ctx context.Context, orderItemId string) (models.OrderItem, error)
+}
+
+type mongoOrderItemRepository struct {
+	orderItems *mongo.Collection
+}
+
+// find returns the items matching filter, oldest first. Items stored
+// before quantities were counts have an S/M/L quantity, OrderItem reads it
+// as one here as in List and Update.
+func (r *mongoOrderItemRepository) find(ctx context.Context, filter bson.M) ([]models.OrderItem, error) {
+	result, err := r.orderItems.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	orderItems := []models.OrderItem{}
+	if err := result.All(ctx, &orderItems); err != nil {
+		return nil, err
+	}
+	return orderItems, nil
+}
+
+func (r *mongoOrderItemRepository) List(ctx context.Context, query Query) ([]models.OrderItem, int64, error) {
+	return findPage[models.OrderItem](ctx, r.orderItems, query)
+}
+
+func (r *mongoOrderItemRepository) Get(ctx context.Context, orderItemId string) (models.OrderItem, error) {
+	orderItems, err := r.find(ctx, bson.M{"order_item_id": orderItemId})
+	if err != nil {
+		return models.OrderItem{}, err
+	}
+	if len(orderItems) == 0 {
+		return models.OrderItem{}, ErrNotFound
+	}
+	return orderItems[0], nil
+}
+
+func (r *mongoOrderItemRepository) ListByOrder(ctx context.Context, orderId string) ([]models.OrderItem, error) {
+	return r.find(ctx, bson.M{"order_id": orderId, "deleted_at": nil})
+}
+
+func (r *mongoOrderItemRepository) ListByStatus(ctx context.Context, statuses ...string) ([]models.OrderItem, error) {
+	return r.find(ctx, bson.M{"status": bson.M{"$in": statuses}, "deleted_at": nil})
+}
+
+func (r *mongoOrderItemRepository) CreateMany(ctx context.Context, orderItems []models.OrderItem) error {
+	docs := []interface{}{}
+	for _, orderItem := range orderItems {
+		docs = append(docs, orderItem)
+	}
+	_, err := r.orderItems.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *mongoOrderItemRepository) Update(ctx context.Context, orderItemId string, version *int, changes models.OrderItem) (models.OrderItem, error) {
+	var updateObj primitive.D
+
+	if changes.Unit_price != nil {
+		updateObj = append(updateObj, bson.E{Key: "unit_price", Value: changes.Unit_price})
+	}
+	if changes.Quantity != nil {
+		updateObj = append(updateObj, bson.E{Key: "quantity", Value: changes.Quantity})
+	}
+	if changes.Seat != nil {
+		updateObj = append(updateObj, bson.E{Key: "seat", Value: changes.Seat})
+	}
+	if changes.Food_id != nil {
+		updateObj = append(updateObj, bson.E{Key: "food_id", Value: changes.Food_id})
+	}
+	if changes.Modifiers != nil {
+		updateObj = append(updateObj, bson.E{Key: "modifiers", Value: changes.Modifiers})
+	}
+	updateObj = append(updateObj, bson.E{Key: "updated_at", Value: changes.Updated_at})
+
+	return updateVersioned[models.OrderItem](ctx, r.orderItems, "order_item_id", orderItemId, version, updateObj)
+}
+
+func (r *mongoOrderItemRepository) SetStatus(ctx context.Context, orderItemId string, from *string, status string, at time.Time) error {
+	result, err := r.orderItems.UpdateOne(
+		ctx,
+		bson.M{"order_item_id": orderItemId, "status": from},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "status", Value: status},
+				{Key: "status_updated_at", Value: at},
+				{Key: "updated_at", Value: at},
+			}},
+			{Key: "$inc", Value: bumpVersion},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *mongoOrderItemRepository) Delete(ctx context.Context, orderItemId, deletedBy string, at time.Time) (models.OrderItem, error) {
+	return softDelete[models.OrderItem](ctx, r.orderItems, "order_item_id", orderItemId, deletedBy, at)
+}
+
+func (r *mongoOrderItemRepository) Restore(ctx context.Context, orderItemId string) (models.OrderItem, error) {
+	return restore[models.OrderItem](ctx, r.orderItems, "order_item_id", orderItemId)
+}
+
+type memoryOrderItemRepository struct {
+	orderItems *collection[models.OrderItem]
+}
+
+func createdFirst(a, b *models.OrderItem) bool {
+	return a.Created_at.Before(b.Created_at)
+}
+
+func (r *memoryOrderItemRepository) List(ctx context.Context, query Query) ([]models.OrderItem, int64, error) {
+	orderItems, total := r.orderItems.query(query)
+	return orderItems, total, nil
+}
+
+func (r *memoryOrderItemRepository) Get(ctx context.Context, orderItemId string) (models.OrderItem, error) {
+	return r.orderItems.get(orderItemId)
+}
+
+func (r *memoryOrderItemRepository) ListByOrder(ctx context.Context, orderId string) ([]models.OrderItem, error) {
+	return r.orderItems.findSorted(func(orderItem *models.OrderItem) bool {
+		return orderItem.Order_id == orderId && orderItem.Deleted_at == nil
+	}, createdFirst), nil
+}
+
+func (r *memoryOrderItemRepository) ListByStatus(ctx context.Context, statuses ...string) ([]models.OrderItem, error) {
+	return r.orderItems.findSorted(func(orderItem *models.OrderItem) bool {
+		for _, status := range statuses {
+			if orderItem.Status != nil && *orderItem.Status == status && orderItem.Deleted_at == nil {
+				return true
+			}
+		}
+		return false
+	}, createdFirst), nil
+}
+
+func (r *memoryOrderItemRepository) CreateMany(ctx context.Context, orderItems []models.OrderItem) error {
+	r.orderItems.insert(orderItems...)
+	return nil
+}
+
+func (r *memoryOrderItemRepository) Update(ctx context.Context, orderItemId string, version *int, changes models.OrderItem) (models.OrderItem, error) {
+	return r.orderItems.update(orderItemId, func(orderItem *models.OrderItem) error {
+		if err := nextVersion(&orderItem.Version, version); err != nil {
+			return err
+		}
+		if changes.Unit_price != nil {
+			orderItem.Unit_price = changes.Unit_price
+		}
+		if changes.Quantity != nil {
+			orderItem.Quantity = changes.Quantity
+		}
+		if changes.Seat != nil {
+			orderItem.Seat = changes.Seat
+		}
+		if changes.Food_id != nil {
+			orderItem.Food_id = changes.Food_id
+		}
+		if changes.Modifiers != nil {
+			orderItem.Modifiers = changes.Modifiers
+		}
+		orderItem.Updated_at = changes.Updated_at
+		return nil
+	})
+}
+
+func (r *memoryOrderItemRepository) SetStatus(ctx context.Context, orderItemId string, from *string, status string, at time.Time) error {
+	_, err := r.orderItems.update(orderItemId, func(orderItem *models.OrderItem) error {
+		if !sameString(orderItem.Status, from) {
+			return ErrConflict
+		}
+		orderItem.Status = &status
+		orderItem.Status_updated_at = at
+		orderItem.Updated_at = at
+		orderItem.Version++
+		return nil
+	})
+	if err == ErrNotFound {
+		return ErrConflict
+	}
+	return err
+}
+
+func (r *memoryOrderItemRepository) Delete(ctx context.Context, orderItemId, deletedBy string, at time.Time) (models.OrderItem, error) {
+	return r.orderItems.update(orderItemId, func(orderItem *models.OrderItem) error {
+		if orderItem.Deleted_at != nil {
+			return ErrConflict
+		}
+		orderItem.Deleted_at, orderItem.Deleted_by = &at, &deletedBy
+		orderItem.Version++
+		return nil
+	})
+}
+
+func (r *memoryOrderItemRepository) Restore(ctx context.Context, orderItemId string) (models.OrderItem, error) {
+	return r.orderItems.update(orderItemId, func(orderItem *models.OrderItem) error {
+		if orderItem.Deleted_at == nil {
+			return ErrConflict
+		}
+		orderItem.Deleted_at, orderItem.Deleted_by = nil, nil
+		orderItem.Version++
+		return nil
+	})
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+type OrderRepository interface {
+	// List returns the page of orders selected by query and how many
+	// orders match its filters.
+	List(ctx context.Context, query Query) ([]models.Order, int64, error)
+	Get(ctx context.Context, orderId string) (models.Order, error)
+	// GetMany returns the orders with the given IDs keyed by ID, missing ones
+	// are left out.
+	GetMany(ctx context.Context, orderIds []string) (map[string]models.Order, error)
+	Create(ctx context.Context, order models.Order) error
+	// Update sets the table of changes unless nil and its update time,
+	// provided the order is at version when that is not nil. It returns
+	// ErrConflict when the order is at another version.
+	Update(ctx context.Context, orderId string, version *int, changes models.Order) (models.Order, error)
+	// Transition moves the order to transition.To and appends transition to
+	// its history, provided the order still has the status from (nil for
+	// orders stored without one). Otherwise it returns ErrConflict.
+	Transition(ctx context.Context, orderId string, from *string, transition models.OrderTransition) error
+	// Delete marks the order deleted by the user at the given time. It
+	// returns ErrConflict when the order is already deleted.
+	Delete(ctx context.Context, orderId, deletedBy string, at time.Time) (models.Order, error)
+	// Restore clears the deletion of the order. It returns ErrConflict
+	// when the order is not deleted.
+	Restore(ctx context.Context, orderId string) (models.Order, error)
+}
+
+type mongoOrderRepository struct {
+	orders *mongo.Collection
+}
+
+func (r *mongoOrderRepository) List(ctx context.Context, query Query) ([]models.Order, int64, error) {
+	return findPage[models.Order](ctx, r.orders, query)
+}
+
+func (r *mongoOrderRepository) Get(ctx context.Context, orderId string) (models.Order, error) {
+	var order models.Order
+	err := r.orders.FindOne(ctx, bson.M{"order_id": orderId}).Decode(&order)
+	return order, notFound(err)
+}
+
+func (r *mongoOrderRepository) GetMany(ctx context.Context, orderIds []string) (map[string]models.Order, error) {
+	result, err := r.orders.Find(ctx, bson.M{"order_id": bson.M{"$in": orderIds}})
+	if err != nil {
+		return nil, err
+	}
+	var orders []models.Order
+	if err := result.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+	found := map[string]models.Order{}
+	for _, order := range orders {
+		found[order.Order_id] = order
+	}
+	return found, nil
+}
+
+func (r *mongoOrderRepository) Create(ctx context.Context, order models.Order) error {
+	_, err := r.orders.InsertOne(ctx, order)
+	return err
+}
+
+func (r *mongoOrderRepository) Update(ctx context.Context, orderId string, version *int, changes models.Order) (models.Order, error) {
+	var updateObj primitive.D
+
+	if changes.Table_id != nil {
+		updateObj = append(updateObj, bson.E{Key: "table_id", Value: changes.Table_id})
+	}
+	updateObj = append(updateObj, bson.E{Key: "updated_at", Value: changes.Updated_at})
+
+	return updateVersioned[models.Order](ctx, r.orders, "order_id", orderId, version, updateObj)
+}
+
+func (r *mongoOrderRepository) Transition(ctx context.Context, orderId string, from *string, transition models.OrderTransition) error {
+	result, err := r.orders.UpdateOne(
+		ctx,
+		bson.M{"order_id": orderId, "status": from},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "status", Value: transition.To},
+				{Key: "updated_at", Value: transition.Changed_at},
+			}},
+			{Key: "$push", Value: bson.D{{Key: "status_history", Value: transition}}},
+			{Key: "$inc", Value: bumpVersion},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func (r *mongoOrderRepository) Delete(ctx context.Context, orderId, deletedBy string, at time.Time) (models.Order, error) {
+	return softDelete[models.Order](ctx, r.orders, "order_id", orderId, deletedBy, at)
+}
+
+func (r *mongoOrderRepository) Restore(ctx context.Context, orderId string) (models.Order, error) {
+	return restore[models.Order](ctx, r.orders, "order_id", orderId)
+}
+
+type memoryOrderRepository struct {
+	orders *collection[models.Order]
+}
+
+func (r *memoryOrderRepository) List(ctx context.Context, query Query) ([]models.Order, int64, error) {
+	orders, total := r.orders.query(query)
+	return orders, total, nil
+}
+
+func (r *memoryOrderRepository) Get(ctx context.Context, orderId string) (models.Order, error) {
+	return r.orders.get(orderId)
+}
+
+func (r *memoryOrderRepository) GetMany(ctx context.Context, orderIds []string) (map[string]models.Order, error) {
+	return r.orders.getMany(orderIds), nil
+}
+
+func (r *memoryOrderRepository) Create(ctx context.Context, order models.Order) error {
+	r.orders.insert(order)
+	return nil
+}
+
+func (r *memoryOrderRepository) Update(ctx context.Context, orderId string, version *int, changes models.Order) (models.Order, error) {
+	return r.orders.update(orderId, func(order *models.Order) error {
+		if err := nextVersion(&order.Version, version); err != nil {
+			return err
+		}
+		if changes.Table_id != nil {
+			order.Table_id = changes.Table_id
+		}
+		order.Updated_at = changes.Updated_at
+		return nil
+	})
+}
+
+func (r *memoryOrderRepository) Transition(ctx context.Context, orderId string, from *string, transition models.OrderTransition) error {
+	_, err := r.orders.update(orderId, func(order *models.Order) error {
+		if !sameString(order.Status, from) {
+			return ErrConflict
+		}
+		order.Status = &transition.To
+		order.Updated_at = transition.Changed_at
+		order.Status_history = append(order.Status_history, transition)
+		order.Version++
+		return nil
+	})
+	if err == ErrNotFound {
+		return ErrConflict
+	}
+	return err
+}
+
+// sameString compares optional strings the way a MongoDB equality filter
+// does, nil matching nil only.
+func sameString(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func (r *memoryOrderRepository) Delete(ctx context.Context, orderId, deletedBy string, at time.Time) (models.Order, error) {
+	return r.orders.update(orderId, func(order *models.Order) error {
+		if order.Deleted_at != nil {
+			return ErrConflict
+		}
+		order.Deleted_at, order.Deleted_by = &at, &deletedBy
+		order.Version++
+		return nil
+	})
+}
+
+func (r *memoryOrderRepository) Restore(ctx context.Context, orderId string) (models.Order, error) {
+	return r.orders.update(orderId, func(order *models.Order) error {
+		if order.Deleted_at == nil {
+			return ErrConflict
+		}
+		order.Deleted_at, order.Deleted_by = nil, nil
+		order.Version++
+		return nil
+	})
+}
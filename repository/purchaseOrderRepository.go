@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+type PurchaseOrderRepository interface {
+	// List returns the page of purchase orders selected by query and how
+	// many purchase orders match its filters.
+	List(ctx context.Context, query Query) ([]models.PurchaseOrder, int64, error)
+	Get(ctx context.Context, purchaseOrderId string) (models.PurchaseOrder, error)
+	Create(ctx context.Context, purchaseOrder models.PurchaseOrder) error
+	// Update sets the expected delivery and lines of changes unless nil,
+	// its status unless empty, and its update time, provided the purchase
+	// order is at version when that is not nil. It returns ErrConflict when
+	// the purchase order is at another version.
+	Update(ctx context.Context, purchaseOrderId string, version *int, changes models.PurchaseOrder) (models.PurchaseOrder, error)
+	// Delete marks the purchase order deleted by the user at the given
+	// time. It returns ErrConflict when the purchase order is already
+	// deleted.
+	Delete(ctx context.Context, purchaseOrderId, deletedBy string, at time.Time) (models.PurchaseOrder, error)
+	// Restore clears the deletion of the purchase order. It returns
+	// ErrConflict when the purchase order is not deleted.
+	Restore(ctx context.Context, purchaseOrderId string) (models.PurchaseOrder, error)
+}
+
+type mongoPurchaseOrderRepository struct {
+	purchaseOrders *mongo.Collection
+}
+
+func (r *mongoPurchaseOrderRepository) List(ctx context.Context, query Query) ([]models.PurchaseOrder, int64, error) {
+	return findPage[models.PurchaseOrder](ctx, r.purchaseOrders, query)
+}
+
+func (r *mongoPurchaseOrderRepository) Get(ctx context.Context, purchaseOrderId string) (models.PurchaseOrder, error) {
+	var purchaseOrder models.PurchaseOrder
+	err := r.purchaseOrders.FindOne(ctx, bson.M{"purchase_order_id": purchaseOrderId}).Decode(&purchaseOrder)
+	return purchaseOrder, notFound(err)
+}
+
+func (r *mongoPurchaseOrderRepository) Create(ctx context.Context, purchaseOrder models.PurchaseOrder) error {
+	_, err := r.purchaseOrders.InsertOne(ctx, purchaseOrder)
+	return err
+}
+
+func (r *mongoPurchaseOrderRepository) Update(ctx context.Context, purchaseOrderId string, version *int, changes models.PurchaseOrder) (models.PurchaseOrder, error) {
+	var updateObj primitive.D
+
+	if changes.Expected_at != nil {
+		updateObj = append(updateObj, bson.E{Key: "expected_at", Value: changes.Expected_at})
+	}
+	if changes.Lines != nil {
+		updateObj = append(updateObj, bson.E{Key: "lines", Value: changes.Lines})
+	}
+	if changes.Status != "" {
+		updateObj = append(updateObj, bson.E{Key: "status", Value: changes.Status})
+	}
+	updateObj = append(updateObj, bson.E{Key: "updated_at", Value: changes.Updated_at})
+
+	return updateVersioned[models.PurchaseOrder](ctx, r.purchaseOrders, "purchase_order_id", purchaseOrderId, version, updateObj)
+}
+
+func (r *mongoPurchaseOrderRepository) Delete(ctx context.Context, purchaseOrderId, deletedBy string, at time.Time) (models.PurchaseOrder, error) {
+	return softDelete[models.PurchaseOrder](ctx, r.purchaseOrders, "purchase_order_id", purchaseOrderId, deletedBy, at)
+}
+
+func (r *mongoPurchaseOrderRepository) Restore(ctx context.Context, purchaseOrderId string) (models.PurchaseOrder, error) {
+	return restore[models.PurchaseOrder](ctx, r.purchaseOrders, "purchase_order_id", purchaseOrderId)
+}
+
+type memoryPurchaseOrderRepository struct {
+	purchaseOrders *collection[models.PurchaseOrder]
+}
+
+func (r *memoryPurchaseOrderRepository) List(ctx context.Context, query Query) ([]models.PurchaseOrder, int64, error) {
+	purchaseOrders, total := r.purchaseOrders.query(query)
+	return purchaseOrders, total, nil
+}
+
+func (r *memoryPurchaseOrderRepository) Get(ctx context.Context, purchaseOrderId string) (models.PurchaseOrder, error) {
+	return r.purchaseOrders.get(purchaseOrderId)
+}
+
+func (r *memoryPurchaseOrderRepository) Create(ctx context.Context, purchaseOrder models.PurchaseOrder) error {
+	r.purchaseOrders.insert(purchaseOrder)
+	return nil
+}
+
+func (r *memoryPurchaseOrderRepository) Update(ctx context.Context, purchaseOrderId string, version *int, changes models.PurchaseOrder) (models.PurchaseOrder, error) {
+	return r.purchaseOrders.update(purchaseOrderId, func(purchaseOrder *models.PurchaseOrder) error {
+		if err := nextVersion(&purchaseOrder.Version, version); err != nil {
+			return err
+		}
+		if changes.Expected_at != nil {
+			purchaseOrder.Expected_at = changes.Expected_at
+		}
+		if changes.Lines != nil {
+			purchaseOrder.Lines = changes.Lines
+		}
+		if changes.Status != "" {
+			purchaseOrder.Status = changes.Status
+		}
+		purchaseOrder.Updated_at = changes.Updated_at
+		return nil
+	})
+}
+
+func (r *memoryPurchaseOrderRepository) Delete(ctx context.Context, purchaseOrderId, deletedBy string, at time.Time) (models.PurchaseOrder, error) {
+	return r.purchaseOrders.update(purchaseOrderId, func(purchaseOrder *models.PurchaseOrder) error {
+		if purchaseOrder.Deleted_at != nil {
+			return ErrConflict
+		}
+		purchaseOrder.Deleted_at, purchaseOrder.Deleted_by = &at, &deletedBy
+		purchaseOrder.Version++
+		return nil
+	})
+}
+
+func (r *memoryPurchaseOrderRepository) Restore(ctx context.Context, purchaseOrderId string) (models.PurchaseOrder, error) {
+	return r.purchaseOrders.update(purchaseOrderId, func(purchaseOrder *models.PurchaseOrder) error {
+		if purchaseOrder.Deleted_at == nil {
+			return ErrConflict
+		}
+		purchaseOrder.Deleted_at, purchaseOrder.Deleted_by = nil, nil
+		purchaseOrder.Version++
+		return nil
+	})
+}
@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Operators of a Filter.
+const (
+	OpEq  = "eq"
+	OpIn  = "in"
+	OpGte = "gte"
+	OpLt  = "lt"
+	OpLte = "lte"
+)
+
+// Filter compares a field of the documents, a BSON path such as
+// "price.amount", with a value. OpIn takes a []string.
+type Filter struct {
+	Field string
+	Op    string
+	Value interface{}
+}
+
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Query selects the documents of a list, orders them and cuts out one page.
+// Every filter must match. Documents equal in all sort fields keep the order
+// of their _id, so consecutive pages never overlap. A zero Limit returns
+// every document from Offset on.
+type Query struct {
+	Filters []Filter
+	Sort    []Sort
+	Offset  int
+	Limit   int
+}
+
+// Where returns the query with one more filter.
+func (q Query) Where(field, op string, value interface{}) Query {
+	q.Filters = append(append([]Filter(nil), q.Filters...), Filter{field, op, value})
+	return q
+}
+
+func (q Query) mongoFilter() bson.M {
+	filter := bson.M{}
+	for _, f := range q.Filters {
+		conditions, ok := filter[f.Field].(bson.M)
+		if !ok {
+			conditions = bson.M{}
+			filter[f.Field] = conditions
+		}
+		conditions["$"+f.Op] = f.Value
+	}
+	return filter
+}
+
+func (q Query) findOptions() *options.FindOptions {
+	order := bson.D{}
+	for _, s := range q.Sort {
+		direction := 1
+		if s.Desc {
+			direction = -1
+		}
+		order = append(order, bson.E{Key: s.Field, Value: direction})
+	}
+	order = append(order, bson.E{Key: "_id", Value: 1})
+
+	opts := options.Find().SetSort(order)
+	if q.Offset > 0 {
+		opts.SetSkip(int64(q.Offset))
+	}
+	if q.Limit > 0 {
+		opts.SetLimit(int64(q.Limit))
+	}
+	return opts
+}
+
+// findPage runs the query against the collection. Besides the page it
+// returns how many documents match the filters in total.
+func findPage[T any](ctx context.Context, collection *mongo.Collection, q Query) ([]T, int64, error) {
+	filter := q.mongoFilter()
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	result, err := collection.Find(ctx, filter, q.findOptions())
+	if err != nil {
+		return nil, 0, err
+	}
+	docs := []T{}
+	if err := result.All(ctx, &docs); err != nil {
+		return nil, 0, err
+	}
+	return docs, total, nil
+}
+
+// query is findPage for the in-memory store. Documents are compared through
+// their BSON form so filters and sorting behave as they do in MongoDB for
+// the types the API uses: strings, numbers, times, booleans and null.
+func (c *collection[T]) query(q Query) ([]T, int64) {
+	type entry struct {
+		doc T
+		raw bson.Raw
+	}
+	var entries []entry
+	for _, doc := range c.find(nil) {
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			continue
+		}
+		if q.matches(raw) {
+			entries = append(entries, entry{doc, raw})
+		}
+	}
+
+	sortKeys := append(append([]Sort(nil), q.Sort...), Sort{Field: "_id"})
+	sort.SliceStable(entries, func(i, j int) bool {
+		for _, s := range sortKeys {
+			order := compareValues(lookup(entries[i].raw, s.Field), lookup(entries[j].raw, s.Field))
+			if order == 0 {
+				continue
+			}
+			if s.Desc {
+				return order > 0
+			}
+			return order < 0
+		}
+		return false
+	})
+
+	total := int64(len(entries))
+	if q.Offset > len(entries) {
+		entries = nil
+	} else if q.Offset > 0 {
+		entries = entries[q.Offset:]
+	}
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[:q.Limit]
+	}
+	docs := make([]T, 0, len(entries))
+	for _, e := range entries {
+		docs = append(docs, e.doc)
+	}
+	return docs, total
+}
+
+func (q Query) matches(doc bson.Raw) bool {
+	for _, f := range q.Filters {
+		value := lookup(doc, f.Field)
+		switch f.Op {
+		case OpEq:
+			if compareValues(value, normalize(f.Value)) != 0 || !sameKind(value, normalize(f.Value)) {
+				return false
+			}
+		case OpIn:
+			found := false
+			for _, candidate := range f.Value.([]string) {
+				if value == candidate {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		default:
+			bound := normalize(f.Value)
+			// like MongoDB, range operators only match values of the same type
+			if !sameKind(value, bound) {
+				return false
+			}
+			order := compareValues(value, bound)
+			if (f.Op == OpGte && order < 0) || (f.Op == OpLt && order >= 0) || (f.Op == OpLte && order > 0) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// lookup returns the normalized value at the path, nil when it is missing.
+func lookup(doc bson.Raw, path string) interface{} {
+	value, err := doc.LookupErr(strings.Split(path, ".")...)
+	if err != nil {
+		return nil
+	}
+	switch value.Type {
+	case bsontype.String:
+		return value.StringValue()
+	case bsontype.Int32:
+		return float64(value.Int32())
+	case bsontype.Int64:
+		return float64(value.Int64())
+	case bsontype.Double:
+		return value.Double()
+	case bsontype.DateTime:
+		return time.UnixMilli(value.DateTime()).UTC()
+	case bsontype.Boolean:
+		return value.Boolean()
+	case bsontype.ObjectID:
+		return value.ObjectID().Hex()
+	}
+	return nil
+}
+
+// normalize brings a filter value into the form lookup returns.
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case time.Time:
+		// BSON keeps milliseconds
+		return v.Truncate(time.Millisecond).UTC()
+	case primitive.ObjectID:
+		return v.Hex()
+	}
+	return value
+}
+
+// sameKind reports whether a and b are of the same type.
+func sameKind(a, b interface{}) bool {
+	switch a.(type) {
+	case string:
+		_, ok := b.(string)
+		return ok
+	case float64:
+		_, ok := b.(float64)
+		return ok
+	case time.Time:
+		_, ok := b.(time.Time)
+		return ok
+	case bool:
+		_, ok := b.(bool)
+		return ok
+	}
+	return a == nil && b == nil
+}
+
+// compareValues orders normalized values, missing ones first as MongoDB
+// sorts them.
+func compareValues(a, b interface{}) int {
+	if a == nil || b == nil || !sameKind(a, b) {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		case b == nil:
+			return 1
+		}
+		return 0
+	}
+	switch x := a.(type) {
+	case string:
+		return strings.Compare(x, b.(string))
+	case float64:
+		y := b.(float64)
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+	case time.Time:
+		y := b.(time.Time)
+		if x.Before(y) {
+			return -1
+		} else if x.After(y) {
+			return 1
+		}
+	case bool:
+		y := b.(bool)
+		if !x && y {
+			return -1
+		} else if x && !y {
+			return 1
+		}
+	}
+	return 0
+}
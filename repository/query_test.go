@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+type queryDoc struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Name       string             `bson:"name"`
+	Guests     *int               `bson:"guests"`
+	Price      models.Money       `bson:"price"`
+	Available  bool               `bson:"available"`
+	Created_at time.Time          `bson:"created_at"`
+	Deleted_at *time.Time         `bson:"deleted_at"`
+}
+
+func queryDocs(t *testing.T) *collection[queryDoc] {
+	t.Helper()
+	base := time.Date(2024, 5, 10, 18, 0, 0, 0, time.UTC)
+	guests := func(n int) *int { return &n }
+	deleted := base.Add(time.Hour)
+	docs := newCollection(func(d *queryDoc) string { return d.ID.Hex() })
+	// ObjectIDs grow in creation order, they break the ties of the sorts
+	docs.insert(
+		queryDoc{primitive.NewObjectID(), "soup", guests(2), models.NewMoney(450, "USD"), true, base, nil},
+		queryDoc{primitive.NewObjectID(), "burger", guests(4), models.NewMoney(1250, "USD"), true, base.Add(time.Minute), nil},
+		queryDoc{primitive.NewObjectID(), "steak", nil, models.NewMoney(2900, "USD"), false, base.Add(2 * time.Minute), nil},
+		queryDoc{primitive.NewObjectID(), "salad", guests(4), models.NewMoney(800, "USD"), true, base.Add(3 * time.Minute), &deleted},
+		queryDoc{primitive.NewObjectID(), "fries", guests(2), models.NewMoney(450, "USD"), false, base.Add(4 * time.Minute), nil},
+	)
+	return docs
+}
+
+func names(docs []queryDoc) []string {
+	found := []string{}
+	for _, doc := range docs {
+		found = append(found, doc.Name)
+	}
+	return found
+}
+
+func TestCollectionQueryFilters(t *testing.T) {
+	docs := queryDocs(t)
+	base := time.Date(2024, 5, 10, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{"all", Query{}, []string{"soup", "burger", "steak", "salad", "fries"}},
+		{"eq string", Query{}.Where("name", OpEq, "steak"), []string{"steak"}},
+		{"eq int", Query{}.Where("guests", OpEq, 4), []string{"burger", "salad"}},
+		{"eq of another type", Query{}.Where("guests", OpEq, "4"), []string{}},
+		{"eq bool", Query{}.Where("available", OpEq, false), []string{"steak", "fries"}},
+		{"eq null", Query{}.NotDeleted(), []string{"soup", "burger", "steak", "fries"}},
+		{"in", Query{}.Where("name", OpIn, []string{"fries", "soup", "pasta"}), []string{"soup", "fries"}},
+		{"gte int skips missing", Query{}.Where("guests", OpGte, 3), []string{"burger", "salad"}},
+		{"lte nested money", Query{}.Where("price.amount", OpLte, int64(800)), []string{"soup", "salad", "fries"}},
+		{"time window", Query{}.
+			Where("created_at", OpGte, base.Add(time.Minute)).
+			Where("created_at", OpLt, base.Add(3*time.Minute)), []string{"burger", "steak"}},
+		{"every filter", Query{}.Where("price.amount", OpEq, int64(450)).Where("available", OpEq, true), []string{"soup"}},
+	}
+	for _, tt := range tests {
+		found, total := docs.query(tt.query)
+		if got := names(found); !reflect.DeepEqual(got, tt.want) || total != int64(len(tt.want)) {
+			t.Errorf("%s: found %v of %d, want %v", tt.name, got, total, tt.want)
+		}
+	}
+}
+
+func TestCollectionQuerySortAndPage(t *testing.T) {
+	docs := queryDocs(t)
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+		total int64
+	}{
+		{"ties keep insertion order", Query{Sort: []Sort{{Field: "price.amount"}}},
+			[]string{"soup", "fries", "salad", "burger", "steak"}, 5},
+		{"descending", Query{Sort: []Sort{{Field: "price.amount", Desc: true}}},
+			[]string{"steak", "burger", "salad", "soup", "fries"}, 5},
+		{"missing first", Query{Sort: []Sort{{Field: "guests"}, {Field: "name"}}},
+			[]string{"steak", "fries", "soup", "burger", "salad"}, 5},
+		{"two keys", Query{Sort: []Sort{{Field: "available", Desc: true}, {Field: "name"}}},
+			[]string{"burger", "salad", "soup", "fries", "steak"}, 5},
+		{"page", Query{Sort: []Sort{{Field: "name"}}, Offset: 1, Limit: 2},
+			[]string{"fries", "salad"}, 5},
+		{"last page", Query{Sort: []Sort{{Field: "name"}}, Offset: 4, Limit: 2},
+			[]string{"steak"}, 5},
+		{"past the end", Query{Offset: 10, Limit: 2}, []string{}, 5},
+		{"filtered page", Query{Sort: []Sort{{Field: "name"}}, Limit: 1}.NotDeleted(),
+			[]string{"burger"}, 4},
+	}
+	for _, tt := range tests {
+		found, total := docs.query(tt.query)
+		if got := names(found); !reflect.DeepEqual(got, tt.want) || total != tt.total {
+			t.Errorf("%s: found %v of %d, want %v of %d", tt.name, got, total, tt.want, tt.total)
+		}
+	}
+}
+
+func TestQueryMongo(t *testing.T) {
+	since := time.Date(2024, 5, 10, 18, 0, 0, 0, time.UTC)
+	query := Query{Sort: []Sort{{Field: "created_at", Desc: true}}, Offset: 40, Limit: 20}.
+		Where("created_at", OpGte, since).
+		Where("created_at", OpLt, since.Add(time.Hour)).
+		Where("status", OpIn, []string{"OPEN"}).
+		NotDeleted()
+
+	want := bson.M{
+		"created_at": bson.M{"$gte": since, "$lt": since.Add(time.Hour)},
+		"status":     bson.M{"$in": []string{"OPEN"}},
+		"deleted_at": bson.M{"$eq": nil},
+	}
+	if got := query.mongoFilter(); !reflect.DeepEqual(got, want) {
+		t.Errorf("filter is %v, want %v", got, want)
+	}
+
+	opts := query.findOptions()
+	wantSort := bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: 1}}
+	if !reflect.DeepEqual(opts.Sort, wantSort) {
+		t.Errorf("sort is %v, want %v", opts.Sort, wantSort)
+	}
+	if opts.Skip == nil || *opts.Skip != 40 || opts.Limit == nil || *opts.Limit != 20 {
+		t.Errorf("skip %v and limit %v, want 40 and 20", opts.Skip, opts.Limit)
+	}
+	if opts := (Query{}).findOptions(); opts.Skip != nil || opts.Limit != nil {
+		t.Errorf("a query without a page skips %v and limits %v, want neither", opts.Skip, opts.Limit)
+	}
+
+	// Where never shares the filters of the query it extends
+	base := Query{}.Where("a", OpEq, 1)
+	first, second := base.Where("b", OpEq, 2), base.Where("c", OpEq, 3)
+	if first.Filters[1].Field != "b" || second.Filters[1].Field != "c" {
+		t.Errorf("extending a query changed another one: %v and %v", first.Filters, second.Filters)
+	}
+}
@@ -0,0 +1,92 @@
+// Package repository stores the aggregates of the restaurant. Every
+// aggregate has an interface used by the controllers, a MongoDB
+// implementation used by the service and an in-memory implementation that
+// lets the whole HTTP API run without a database, e.g. in tests.
+package repository
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+var (
+	// ErrNotFound is returned when the requested document does not exist.
+	ErrNotFound = errors.New("document was not found")
+	// ErrConflict is returned by conditional updates when the document was
+	// changed since it was read.
+	ErrConflict = errors.New("document was changed concurrently")
+)
+
+// Repositories bundles one repository per aggregate.
+type Repositories struct {
+	Foods          FoodRepository
+	Menus          MenuRepository
+	Tables         TableRepository
+	Orders         OrderRepository
+	OrderItems     OrderItemRepository
+	Invoices       InvoiceRepository
+	Users          UserRepository
+	Reservations   ReservationRepository
+	Search         SearchRepository
+	Audit          AuditRepository
+	Notes          NoteRepository
+	Ingredients    IngredientRepository
+	StockMovements StockMovementRepository
+	Suppliers      SupplierRepository
+	PurchaseOrders PurchaseOrderRepository
+}
+
+// NewMongo returns repositories backed by the collections of db.
+func NewMongo(db *mongo.Database) *Repositories {
+	return &Repositories{
+		Foods:          &mongoFoodRepository{db.Collection("food")},
+		Menus:          &mongoMenuRepository{db.Collection("menu")},
+		Tables:         &mongoTableRepository{db.Collection("table")},
+		Orders:         &mongoOrderRepository{db.Collection("order")},
+		OrderItems:     &mongoOrderItemRepository{db.Collection("orderItem")},
+		Invoices:       &mongoInvoiceRepository{db.Collection("invoice")},
+		Users:          &mongoUserRepository{db.Collection("user"), db.Collection("bootstrap")},
+		Reservations:   &mongoReservationRepository{db.Collection("reservation")},
+		Search:         &mongoSearchRepository{db.Collection("food"), db.Collection("menu")},
+		Audit:          &mongoAuditRepository{db.Collection("audit")},
+		Notes:          &mongoNoteRepository{db.Collection("note")},
+		Ingredients:    &mongoIngredientRepository{db.Collection("ingredient")},
+		StockMovements: &mongoStockMovementRepository{db.Collection("stockMovement")},
+		Suppliers:      &mongoSupplierRepository{db.Collection("supplier")},
+		PurchaseOrders: &mongoPurchaseOrderRepository{db.Collection("purchaseOrder")},
+	}
+}
+
+// NewMemory returns empty repositories that keep their documents in memory.
+func NewMemory() *Repositories {
+	foods := newCollection(func(f *models.Food) string { return f.Food_id })
+	menus := newCollection(func(m *models.Menu) string { return m.Menu_id })
+	return &Repositories{
+		Foods:          &memoryFoodRepository{foods},
+		Menus:          &memoryMenuRepository{menus},
+		Tables:         &memoryTableRepository{newCollection(func(t *models.Table) string { return t.Table_id })},
+		Orders:         &memoryOrderRepository{newCollection(func(o *models.Order) string { return o.Order_id })},
+		OrderItems:     &memoryOrderItemRepository{newCollection(func(i *models.OrderItem) string { return i.Order_item_id })},
+		Invoices:       &memoryInvoiceRepository{newCollection(func(i *models.Invoice) string { return i.Invoice_id })},
+		Users:          &memoryUserRepository{users: newCollection(func(u *models.User) string { return u.User_id })},
+		Reservations:   &memoryReservationRepository{newCollection(func(r *models.Reservation) string { return r.Reservation_id })},
+		Search:         &memorySearchRepository{foods, menus},
+		Audit:          &memoryAuditRepository{newCollection(func(e *models.AuditEntry) string { return e.Audit_id })},
+		Notes:          &memoryNoteRepository{newCollection(func(n *models.Note) string { return n.Note_id })},
+		Ingredients:    &memoryIngredientRepository{newCollection(func(i *models.Ingredient) string { return i.Ingredient_id })},
+		StockMovements: &memoryStockMovementRepository{newCollection(func(m *models.StockMovement) string { return m.Movement_id })},
+		Suppliers:      &memorySupplierRepository{newCollection(func(s *models.Supplier) string { return s.Supplier_id })},
+		PurchaseOrders: &memoryPurchaseOrderRepository{newCollection(func(p *models.PurchaseOrder) string { return p.Purchase_order_id })},
+	}
+}
+
+// notFound maps the driver's missing document error to ErrNotFound.
+func notFound(err error) error {
+	if err == mongo.ErrNoDocuments {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+type ReservationRepository interface {
+	// List returns the page of reservations selected by query and how many
+	// reservations match its filters.
+	List(ctx context.Context, query Query) ([]models.Reservation, int64, error)
+	Get(ctx context.Context, reservationId string) (models.Reservation, error)
+	Create(ctx context.Context, reservation models.Reservation) error
+	// Update stores the guest, party, time, table and update time of the
+	// reservation, provided it is at version when that is not nil. It
+	// returns the stored reservation, or ErrConflict when it is at another
+	// version.
+	Update(ctx context.Context, version *int, reservation models.Reservation) (models.Reservation, error)
+	// SetStatus moves the reservation to status, provided it still has the
+	// status from. Otherwise it returns ErrConflict.
+	SetStatus(ctx context.Context, reservationId, from, status string, at time.Time) error
+}
+
+type mongoReservationRepository struct {
+	reservations *mongo.Collection
+}
+
+func (r *mongoReservationRepository) List(ctx context.Context, query Query) ([]models.Reservation, int64, error) {
+	return findPage[models.Reservation](ctx, r.reservations, query)
+}
+
+func (r *mongoReservationRepository) Get(ctx context.Context, reservationId string) (models.Reservation, error) {
+	var reservation models.Reservation
+	err := r.reservations.FindOne(ctx, bson.M{"reservation_id": reservationId}).Decode(&reservation)
+	return reservation, notFound(err)
+}
+
+func (r *mongoReservationRepository) Create(ctx context.Context, reservation models.Reservation) error {
+	_, err := r.reservations.InsertOne(ctx, reservation)
+	return err
+}
+
+func (r *mongoReservationRepository) Update(ctx context.Context, version *int, reservation models.Reservation) (models.Reservation, error) {
+	return updateVersioned[models.Reservation](ctx, r.reservations, "reservation_id", reservation.Reservation_id, version, bson.D{
+		{Key: "guest_name", Value: reservation.Guest_name},
+		{Key: "phone", Value: reservation.Phone},
+		{Key: "party_size", Value: reservation.Party_size},
+		{Key: "start_time", Value: reservation.Start_time},
+		{Key: "duration_minutes", Value: reservation.Duration_minutes},
+		{Key: "end_time", Value: reservation.End_time},
+		{Key: "table_id", Value: reservation.Table_id},
+		{Key: "updated_at", Value: reservation.Updated_at},
+	})
+}
+
+func (r *mongoReservationRepository) SetStatus(ctx context.Context, reservationId, from, status string, at time.Time) error {
+	result, err := r.reservations.UpdateOne(
+		ctx,
+		bson.M{"reservation_id": reservationId, "status": from},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "status", Value: status},
+				{Key: "updated_at", Value: at},
+			}},
+			{Key: "$inc", Value: bumpVersion},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+type memoryReservationRepository struct {
+	reservations *collection[models.Reservation]
+}
+
+func (r *memoryReservationRepository) List(ctx context.Context, query Query) ([]models.Reservation, int64, error) {
+	reservations, total := r.reservations.query(query)
+	return reservations, total, nil
+}
+
+func (r *memoryReservationRepository) Get(ctx context.Context, reservationId string) (models.Reservation, error) {
+	return r.reservations.get(reservationId)
+}
+
+func (r *memoryReservationRepository) Create(ctx context.Context, reservation models.Reservation) error {
+	r.reservations.insert(reservation)
+	return nil
+}
+
+func (r *memoryReservationRepository) Update(ctx context.Context, version *int, reservation models.Reservation) (models.Reservation, error) {
+	return r.reservations.update(reservation.Reservation_id, func(stored *models.Reservation) error {
+		if err := nextVersion(&stored.Version, version); err != nil {
+			return err
+		}
+		stored.Guest_name = reservation.Guest_name
+		stored.Phone = reservation.Phone
+		stored.Party_size = reservation.Party_size
+		stored.Start_time = reservation.Start_time
+		stored.Duration_minutes = reservation.Duration_minutes
+		stored.End_time = reservation.End_time
+		stored.Table_id = reservation.Table_id
+		stored.Updated_at = reservation.Updated_at
+		return nil
+	})
+}
+
+func (r *memoryReservationRepository) SetStatus(ctx context.Context, reservationId, from, status string, at time.Time) error {
+	_, err := r.reservations.update(reservationId, func(reservation *models.Reservation) error {
+		if !sameString(reservation.Status, &from) {
+			return ErrConflict
+		}
+		reservation.Status = &status
+		reservation.Updated_at = at
+		reservation.Version++
+		return nil
+	})
+	if err == ErrNotFound {
+		return ErrConflict
+	}
+	return err
+}
@@ -0,0 +1,386 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+// SearchQuery selects the foods and menus matching a free text.
+type SearchQuery struct {
+	Text string
+	// Menu_id keeps only that menu and its foods when set.
+	Menu_id string
+	// Available keeps only the foods of that availability when set, menus
+	// are not filtered by it.
+	Available *bool
+	// Limit caps the number of foods and of menus returned.
+	Limit int
+}
+
+// FoodHit is a food matching a search and its relevance.
+type FoodHit struct {
+	Food  models.Food
+	Score float64
+}
+
+// MenuHit is a menu matching a search and its relevance.
+type MenuHit struct {
+	Menu  models.Menu
+	Score float64
+}
+
+type SearchResult struct {
+	Foods []FoodHit
+	Menus []MenuHit
+}
+
+type SearchRepository interface {
+	// Search returns the foods and menus that are not deleted and match
+	// every word of the text, the most relevant first. The name and
+	// category of its menu are searched along with a food.
+	Search(ctx context.Context, query SearchQuery) (SearchResult, error)
+}
+
+// Weights of the searched fields, a word of the name counts more than one
+// of the description or of the menu a food belongs to.
+const (
+	nameWeight        = 3
+	categoryWeight    = 2
+	descriptionWeight = 1
+	menuWeight        = 1
+)
+
+// searchCandidates bounds the documents of a collection MongoDB hands over
+// for ranking per search.
+const searchCandidates = 200
+
+type mongoSearchRepository struct {
+	foods *mongo.Collection
+	menus *mongo.Collection
+}
+
+// Search collects candidates with the text index, which finds whole words
+// and their stems, and with prefix expressions, which find the words still
+// being typed or mistyped, then ranks them as the in-memory store does.
+func (r *mongoSearchRepository) Search(ctx context.Context, query SearchQuery) (SearchResult, error) {
+	terms := searchTerms(query.Text)
+	if len(terms) == 0 {
+		return rankSearch(query, nil, nil), nil
+	}
+
+	menuFilter := bson.M{"deleted_at": nil}
+	foodFilter := bson.M{"deleted_at": nil}
+	if query.Menu_id != "" {
+		menuFilter["menu_id"] = query.Menu_id
+		foodFilter["menu_id"] = query.Menu_id
+	}
+	if query.Available != nil && *query.Available {
+		foodFilter["available"] = bson.M{"$ne": false}
+	} else if query.Available != nil {
+		foodFilter["available"] = false
+	}
+
+	menus := map[string]models.Menu{}
+	found, err := findCandidates[models.Menu](ctx, r.menus, menuFilter, terms, "name", "category")
+	if err != nil {
+		return SearchResult{}, err
+	}
+	for _, menu := range found {
+		menus[menu.Menu_id] = menu
+	}
+
+	foods, err := findCandidates[models.Food](ctx, r.foods, foodFilter, terms, "name", "description")
+	if err != nil {
+		return SearchResult{}, err
+	}
+	// a food may match by the name or category of its menu only
+	if len(menus) > 0 {
+		menuIds := make([]string, 0, len(menus))
+		for menuId := range menus {
+			menuIds = append(menuIds, menuId)
+		}
+		byMenu, err := findAll[models.Food](ctx, r.foods,
+			bson.M{"$and": bson.A{foodFilter, bson.M{"menu_id": bson.M{"$in": menuIds}}}},
+			options.Find().SetLimit(searchCandidates))
+		if err != nil {
+			return SearchResult{}, err
+		}
+		foods = append(foods, byMenu...)
+	}
+
+	var missing []string
+	for _, food := range foods {
+		if food.Menu_id != nil {
+			if _, ok := menus[*food.Menu_id]; !ok {
+				missing = append(missing, *food.Menu_id)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		found, err := findAll[models.Menu](ctx, r.menus, bson.M{"menu_id": bson.M{"$in": missing}}, nil)
+		if err != nil {
+			return SearchResult{}, err
+		}
+		for _, menu := range found {
+			menus[menu.Menu_id] = menu
+		}
+	}
+
+	menuList := make([]models.Menu, 0, len(menus))
+	for _, menu := range menus {
+		menuList = append(menuList, menu)
+	}
+	return rankSearch(query, foods, menuList), nil
+}
+
+// findCandidates returns the documents matching filter that the text index
+// or a prefix of one of the terms finds in the fields. Only the first two
+// letters of a term make its prefix so that a typo further on still finds
+// the word.
+func findCandidates[T any](ctx context.Context, collection *mongo.Collection, filter bson.M, terms []string, fields ...string) ([]T, error) {
+	score := bson.M{"score": bson.M{"$meta": "textScore"}}
+	byText, err := findAll[T](ctx, collection,
+		bson.M{"$and": bson.A{filter, bson.M{"$text": bson.M{"$search": strings.Join(terms, " ")}}}},
+		options.Find().SetProjection(score).SetSort(score).SetLimit(searchCandidates))
+	if err != nil {
+		return nil, err
+	}
+
+	var prefixes bson.A
+	for _, term := range terms {
+		prefix := []rune(term)
+		if len(prefix) > 2 {
+			prefix = prefix[:2]
+		}
+		pattern := primitive.Regex{Pattern: `\b` + regexp.QuoteMeta(string(prefix)), Options: "i"}
+		for _, field := range fields {
+			prefixes = append(prefixes, bson.M{field: pattern})
+		}
+	}
+	byPrefix, err := findAll[T](ctx, collection,
+		bson.M{"$and": bson.A{filter, bson.M{"$or": prefixes}}},
+		options.Find().SetLimit(searchCandidates))
+	if err != nil {
+		return nil, err
+	}
+	return append(byText, byPrefix...), nil
+}
+
+func findAll[T any](ctx context.Context, collection *mongo.Collection, filter interface{}, opts *options.FindOptions) ([]T, error) {
+	if opts == nil {
+		opts = options.Find()
+	}
+	result, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	docs := []T{}
+	if err := result.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+type memorySearchRepository struct {
+	foods *collection[models.Food]
+	menus *collection[models.Menu]
+}
+
+func (r *memorySearchRepository) Search(ctx context.Context, query SearchQuery) (SearchResult, error) {
+	return rankSearch(query, r.foods.find(nil), r.menus.find(nil)), nil
+}
+
+// rankSearch keeps the candidate foods and menus that pass the filters of
+// the query and match all of its words, best first. Candidates may be
+// repeated, menus must include the menu of every food.
+func rankSearch(query SearchQuery, foods []models.Food, menus []models.Menu) SearchResult {
+	terms := searchTerms(query.Text)
+	result := SearchResult{Foods: []FoodHit{}, Menus: []MenuHit{}}
+	if len(terms) == 0 {
+		return result
+	}
+
+	menusById := map[string]models.Menu{}
+	for _, menu := range menus {
+		if _, seen := menusById[menu.Menu_id]; seen {
+			continue
+		}
+		menusById[menu.Menu_id] = menu
+		if menu.Deleted_at != nil || (query.Menu_id != "" && menu.Menu_id != query.Menu_id) {
+			continue
+		}
+		score := matchScore(terms, []searchField{
+			{menu.Name, nameWeight},
+			{menu.Category, categoryWeight},
+		})
+		if score > 0 {
+			result.Menus = append(result.Menus, MenuHit{menu, score})
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, food := range foods {
+		if seen[food.Food_id] {
+			continue
+		}
+		seen[food.Food_id] = true
+		if food.Deleted_at != nil {
+			continue
+		}
+		if query.Menu_id != "" && (food.Menu_id == nil || *food.Menu_id != query.Menu_id) {
+			continue
+		}
+		if query.Available != nil && (food.Available == nil || *food.Available) != *query.Available {
+			continue
+		}
+		fields := []searchField{{deref(food.Name), nameWeight}, {deref(food.Description), descriptionWeight}}
+		if food.Menu_id != nil {
+			menu := menusById[*food.Menu_id]
+			fields = append(fields, searchField{menu.Name, menuWeight}, searchField{menu.Category, menuWeight})
+		}
+		if score := matchScore(terms, fields); score > 0 {
+			result.Foods = append(result.Foods, FoodHit{food, score})
+		}
+	}
+
+	sort.SliceStable(result.Foods, func(i, j int) bool {
+		a, b := result.Foods[i], result.Foods[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return deref(a.Food.Name) < deref(b.Food.Name)
+	})
+	sort.SliceStable(result.Menus, func(i, j int) bool {
+		a, b := result.Menus[i], result.Menus[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Menu.Name < b.Menu.Name
+	})
+	if query.Limit > 0 && len(result.Foods) > query.Limit {
+		result.Foods = result.Foods[:query.Limit]
+	}
+	if query.Limit > 0 && len(result.Menus) > query.Limit {
+		result.Menus = result.Menus[:query.Limit]
+	}
+	return result
+}
+
+type searchField struct {
+	text   string
+	weight float64
+}
+
+// matchScore rates the fields against the terms. Every term has to match a
+// word of one of the fields; the score sums the best weighted match of each
+// term and is 0 when one of them matches nothing.
+func matchScore(terms []string, fields []searchField) float64 {
+	total := 0.0
+	for _, term := range terms {
+		best := 0.0
+		for _, field := range fields {
+			for _, word := range searchTerms(field.text) {
+				if score := termScore(term, word) * field.weight; score > best {
+					best = score
+				}
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return math.Round(total*100) / 100
+}
+
+// termScore rates how close a searched term is to a word: 1 for the same
+// word, 0.8 for a word it starts, as while the word is being typed, and 0.6
+// or 0.4 for a word, or the start of one, one or two typos away. Typos are
+// tolerated in terms of at least 4 letters, two of them from 8 letters, and
+// only after the first two letters.
+func termScore(term, word string) float64 {
+	if term == word {
+		return 1
+	}
+	if strings.HasPrefix(word, term) {
+		return 0.8
+	}
+	t, w := []rune(term), []rune(word)
+	if len(t) < 4 || len(w) < 2 || t[0] != w[0] || t[1] != w[1] {
+		return 0
+	}
+	edits := editDistance(t, w)
+	if len(w) > len(t) {
+		if prefix := editDistance(t, w[:len(t)]); prefix < edits {
+			edits = prefix
+		}
+	}
+	switch {
+	case edits == 1:
+		return 0.6
+	case edits == 2 && len(t) >= 8:
+		return 0.4
+	}
+	return 0
+}
+
+// editDistance counts the insertions, deletions, substitutions and swaps of
+// neighbouring letters turning a into b.
+func editDistance(a, b []rune) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(a)][len(b)]
+}
+
+func min(values ...int) int {
+	smallest := values[0]
+	for _, value := range values[1:] {
+		if value < smallest {
+			smallest = value
+		}
+	}
+	return smallest
+}
+
+// searchTerms splits text into lower case words.
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"burger", "burger", 0},
+		{"burgr", "burger", 1},
+		{"burgers", "burger", 1},
+		{"burgor", "burger", 1},
+		{"burgre", "burger", 1},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"crème", "creme", 1},
+	}
+	for _, tt := range tests {
+		if got := editDistance([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTermScore(t *testing.T) {
+	tests := []struct {
+		term, word string
+		want       float64
+	}{
+		{"burger", "burger", 1},
+		{"bur", "burger", 0.8},
+		{"b", "burger", 0.8},
+		{"burgr", "burger", 0.6},
+		{"burgre", "burger", 0.6},
+		{"piza", "pizza", 0.6},
+		// a typo in a word still being typed
+		{"chese", "cheeseburger", 0.6},
+		{"margherta", "margherita", 0.6},
+		{"margerta", "margherita", 0.4},
+		{"marghr", "margherita", 0.6},
+		{"bugr", "burger", 0.6},
+		// two typos need 8 letters
+		{"burgxy", "burger", 0},
+		// short terms must match exactly or as a prefix
+		{"pza", "pizza", 0},
+		// the first two letters must be right
+		{"brger", "burger", 0},
+		{"vurger", "burger", 0},
+		{"burger", "cheeseburger", 0},
+		{"salmon", "lemon", 0},
+	}
+	for _, tt := range tests {
+		if got := termScore(tt.term, tt.word); got != tt.want {
+			t.Errorf("termScore(%q, %q) = %v, want %v", tt.term, tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	got := searchTerms("  Crème-Brûlée, 2 x BBQ's! ")
+	want := []string{"crème", "brûlée", "2", "x", "bbq", "s"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("searchTerms = %q, want %q", got, want)
+	}
+	if got := searchTerms(" -- "); len(got) != 0 {
+		t.Errorf("searchTerms of punctuation = %q, want none", got)
+	}
+}
+
+func TestMatchScore(t *testing.T) {
+	fields := []searchField{{"Veggie Burger", nameWeight}, {"grilled halloumi", descriptionWeight}}
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{"burger", 3},
+		{"burger halloumi", 4},
+		{"veg burgr", 2.4 + 1.8},
+		{"halloumi", 1},
+		{"burger steak", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := matchScore(searchTerms(tt.text), fields); got != tt.want {
+			t.Errorf("matchScore(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRankSearch(t *testing.T) {
+	text := func(s string) *string { return &s }
+	no := false
+	deleted := time.Date(2024, 5, 10, 18, 0, 0, 0, time.UTC)
+	menus := []models.Menu{
+		{Menu_id: "dinner", Name: "Dinner", Category: "Mains"},
+		{Menu_id: "drinks", Name: "Drinks", Category: "Beverages"},
+		{Menu_id: "brunch", Name: "Burger Brunch", Category: "Mains", Deleted_at: &deleted},
+	}
+	foods := []models.Food{
+		{Food_id: "cheeseburger", Name: text("Cheeseburger"), Description: text("beef patty"), Menu_id: text("dinner")},
+		{Food_id: "salad", Name: text("Burger Salad"), Menu_id: text("dinner"), Available: &no},
+		{Food_id: "cola", Name: text("Cola"), Description: text("sweet and fizzy"), Menu_id: text("drinks")},
+		{Food_id: "deluxe", Name: text("Burger Deluxe"), Menu_id: text("dinner"), Deleted_at: &deleted},
+		{Food_id: "veggie", Name: text("Veggie burger"), Menu_id: text("drinks")},
+	}
+	// candidates may come from several lookups
+	foods = append(foods, foods[4])
+
+	yes := true
+	tests := []struct {
+		name      string
+		query     SearchQuery
+		wantFoods []string
+		wantMenus []string
+	}{
+		{"by name, then alphabetically", SearchQuery{Text: "burger"}, []string{"salad", "veggie"}, []string{}},
+		{"typo", SearchQuery{Text: "burgr"}, []string{"salad", "veggie"}, []string{}},
+		{"available only", SearchQuery{Text: "burger", Available: &yes}, []string{"veggie"}, []string{}},
+		{"unavailable only", SearchQuery{Text: "burger", Available: &no}, []string{"salad"}, []string{}},
+		{"one menu", SearchQuery{Text: "burger", Menu_id: "drinks"}, []string{"veggie"}, []string{}},
+		{"by menu", SearchQuery{Text: "mains"}, []string{"salad", "cheeseburger"}, []string{"dinner"}},
+		{"name outranks menu", SearchQuery{Text: "drinks"}, []string{"cola", "veggie"}, []string{"drinks"}},
+		{"every word", SearchQuery{Text: "dinner burger"}, []string{"salad"}, []string{}},
+		{"description", SearchQuery{Text: "fizzy"}, []string{"cola"}, []string{}},
+		{"limit", SearchQuery{Text: "burger", Limit: 1}, []string{"salad"}, []string{}},
+		{"nothing", SearchQuery{Text: "sushi"}, []string{}, []string{}},
+		{"no words", SearchQuery{Text: " ? "}, []string{}, []string{}},
+	}
+	for _, tt := range tests {
+		result := rankSearch(tt.query, foods, menus)
+		gotFoods, gotMenus := []string{}, []string{}
+		for _, hit := range result.Foods {
+			gotFoods = append(gotFoods, hit.Food.Food_id)
+		}
+		for _, hit := range result.Menus {
+			gotMenus = append(gotMenus, hit.Menu.Menu_id)
+		}
+		if !reflect.DeepEqual(gotFoods, tt.wantFoods) || !reflect.DeepEqual(gotMenus, tt.wantMenus) {
+			t.Errorf("%s: found foods %v and menus %v, want %v and %v", tt.name, gotFoods, gotMenus, tt.wantFoods, tt.wantMenus)
+		}
+	}
+
+	result := rankSearch(SearchQuery{Text: "mains"}, foods, menus)
+	if result.Menus[0].Score != 2 || result.Foods[0].Score != 1 {
+		t.Errorf("a category scores %v on the menu and %v on its food, want 2 and 1", result.Menus[0].Score, result.Foods[0].Score)
+	}
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+// StockMovementRepository is append-only, movements are never changed or
+// removed.
+type StockMovementRepository interface {
+	Append(ctx context.Context, movements ...models.StockMovement) error
+	// List returns the page of movements selected by query and how many
+	// movements match its filters.
+	List(ctx context.Context, query Query) ([]models.StockMovement, int64, error)
+}
+
+type mongoStockMovementRepository struct {
+	movements *mongo.Collection
+}
+
+func (r *mongoStockMovementRepository) Append(ctx context.Context, movements ...models.StockMovement) error {
+	if len(movements) == 0 {
+		return nil
+	}
+	docs := []interface{}{}
+	for _, movement := range movements {
+		docs = append(docs, movement)
+	}
+	_, err := r.movements.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *mongoStockMovementRepository) List(ctx context.Context, query Query) ([]models.StockMovement, int64, error) {
+	return findPage[models.StockMovement](ctx, r.movements, query)
+}
+
+type memoryStockMovementRepository struct {
+	movements *collection[models.StockMovement]
+}
+
+func (r *memoryStockMovementRepository) Append(ctx context.Context, movements ...models.StockMovement) error {
+	r.movements.insert(movements...)
+	return nil
+}
+
+func (r *memoryStockMovementRepository) List(ctx context.Context, query Query) ([]models.StockMovement, int64, error) {
+	movements, total := r.movements.query(query)
+	return movements, total, nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+type SupplierRepository interface {
+	// List returns the page of suppliers selected by query and how many
+	// suppliers match its filters.
+	List(ctx context.Context, query Query) ([]models.Supplier, int64, error)
+	Get(ctx context.Context, supplierId string) (models.Supplier, error)
+	Create(ctx context.Context, supplier models.Supplier) error
+	// Update sets the name, contact name, email and phone of changes unless
+	// empty, its lead time unless nil, and its update time, provided the
+	// supplier is at version when that is not nil. It returns ErrConflict
+	// when the supplier is at another version.
+	Update(ctx context.Context, supplierId string, version *int, changes models.Supplier) (models.Supplier, error)
+	// Delete marks the supplier deleted by the user at the given time. It
+	// returns ErrConflict when the supplier is already deleted.
+	Delete(ctx context.Context, supplierId, deletedBy string, at time.Time) (models.Supplier, error)
+	// Restore clears the deletion of the supplier. It returns ErrConflict
+	// when the supplier is not deleted.
+	Restore(ctx context.Context, supplierId string) (models.Supplier, error)
+}
+
+type mongoSupplierRepository struct {
+	suppliers *mongo.Collection
+}
+
+func (r *mongoSupplierRepository) List(ctx context.Context, query Query) ([]models.Supplier, int64, error) {
+	return findPage[models.Supplier](ctx, r.suppliers, query)
+}
+
+func (r *mongoSupplierRepository) Get(ctx context.Context, supplierId string) (models.Supplier, error) {
+	var supplier models.Supplier
+	err := r.suppliers.FindOne(ctx, bson.M{"supplier_id": supplierId}).Decode(&supplier)
+	return supplier, notFound(err)
+}
+
+func (r *mongoSupplierRepository) Create(ctx context.Context, supplier models.Supplier) error {
+	_, err := r.suppliers.InsertOne(ctx, supplier)
+	return err
+}
+
+func (r *mongoSupplierRepository) Update(ctx context.Context, supplierId string, version *int, changes models.Supplier) (models.Supplier, error) {
+	var updateObj primitive.D
+
+	if changes.Name != "" {
+		updateObj = append(updateObj, bson.E{Key: "name", Value: changes.Name})
+	}
+	if changes.Contact_name != "" {
+		updateObj = append(updateObj, bson.E{Key: "contact_name", Value: changes.Contact_name})
+	}
+	if changes.Email != "" {
+		updateObj = append(updateObj, bson.E{Key: "email", Value: changes.Email})
+	}
+	if changes.Phone != "" {
+		updateObj = append(updateObj, bson.E{Key: "phone", Value: changes.Phone})
+	}
+	if changes.Lead_time_days != nil {
+		updateObj = append(updateObj, bson.E{Key: "lead_time_days", Value: changes.Lead_time_days})
+	}
+	updateObj = append(updateObj, bson.E{Key: "updated_at", Value: changes.Updated_at})
+
+	return updateVersioned[models.Supplier](ctx, r.suppliers, "supplier_id", supplierId, version, updateObj)
+}
+
+func (r *mongoSupplierRepository) Delete(ctx context.Context, supplierId, deletedBy string, at time.Time) (models.Supplier, error) {
+	return softDelete[models.Supplier](ctx, r.suppliers, "supplier_id", supplierId, deletedBy, at)
+}
+
+func (r *mongoSupplierRepository) Restore(ctx context.Context, supplierId string) (models.Supplier, error) {
+	return restore[models.Supplier](ctx, r.suppliers, "supplier_id", supplierId)
+}
+
+type memorySupplierRepository struct {
+	suppliers *collection[models.Supplier]
+}
+
+func (r *memorySupplierRepository) List(ctx context.Context, query Query) ([]models.Supplier, int64, error) {
+	suppliers, total := r.suppliers.query(query)
+	return suppliers, total, nil
+}
+
+func (r *memorySupplierRepository) Get(ctx context.Context, supplierId string) (models.Supplier, error) {
+	return r.suppliers.get(supplierId)
+}
+
+func (r *memorySupplierRepository) Create(ctx context.Context, supplier models.Supplier) error {
+	r.suppliers.insert(supplier)
+	return nil
+}
+
+func (r *memorySupplierRepository) Update(ctx context.Context, supplierId string, version *int, changes models.Supplier) (models.Supplier, error) {
+	return r.suppliers.update(supplierId, func(supplier *models.Supplier) error {
+		if err := nextVersion(&supplier.Version, version); err != nil {
+			return err
+		}
+		if changes.Name != "" {
+			supplier.Name = changes.Name
+		}
+		if changes.Contact_name != "" {
+			supplier.Contact_name = changes.Contact_name
+		}
+		if changes.Email != "" {
+			supplier.Email = changes.Email
+		}
+		if changes.Phone != "" {
+			supplier.Phone = changes.Phone
+		}
+		if changes.Lead_time_days != nil {
+			supplier.Lead_time_days = changes.Lead_time_days
+		}
+		supplier.Updated_at = changes.Updated_at
+		return nil
+	})
+}
+
+func (r *memorySupplierRepository) Delete(ctx context.Context, supplierId, deletedBy string, at time.Time) (models.Supplier, error) {
+	return r.suppliers.update(supplierId, func(supplier *models.Supplier) error {
+		if supplier.Deleted_at != nil {
+			return ErrConflict
+		}
+		supplier.Deleted_at, supplier.Deleted_by = &at, &deletedBy
+		supplier.Version++
+		return nil
+	})
+}
+
+func (r *memorySupplierRepository) Restore(ctx context.Context, supplierId string) (models.Supplier, error) {
+	return r.suppliers.update(supplierId, func(supplier *models.Supplier) error {
+		if supplier.Deleted_at == nil {
+			return ErrConflict
+		}
+		supplier.Deleted_at, supplier.Deleted_by = nil, nil
+		supplier.Version++
+		return nil
+	})
+}
@@ -0,0 +1,390 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+type TableRepository interface {
+	// List returns the page of tables selected by query and how many
+	// tables match its filters.
+	List(ctx context.Context, query Query) ([]models.Table, int64, error)
+	Get(ctx context.Context, tableId string) (models.Table, error)
+	// GetMany returns the tables with the given IDs keyed by ID, missing ones
+	// are left out.
+	GetMany(ctx context.Context, tableIds []string) (map[string]models.Table, error)
+	Create(ctx context.Context, table models.Table) error
+	// Update sets the fields of changes that are not nil and its update
+	// time, provided the table is at version when that is not nil. It
+	// returns ErrConflict when the table is at another version.
+	Update(ctx context.Context, tableId string, version *int, changes models.Table) (models.Table, error)
+	SetStatus(ctx context.Context, tableId, status string, at time.Time) error
+	// Available lists the tables that are not deleted, fit partySize guests
+	// and have no booked slot overlapping start to end, smallest first.
+	Available(ctx context.Context, partySize int, start, end time.Time) ([]models.Table, error)
+	// ClaimSlot books the table for the slot if no slot of another
+	// reservation overlaps it, replacing the reservation's own slot on the
+	// table if it has one. The check and the write are atomic, so two
+	// overlapping claims cannot both succeed. It reports whether the slot
+	// was booked.
+	ClaimSlot(ctx context.Context, tableId string, slot models.TableSlot) (bool, error)
+	// ReleaseSlot removes the reservation's slot from the table.
+	ReleaseSlot(ctx context.Context, tableId, reservationId string) error
+	// Delete marks the table deleted by the user at the given time. It
+	// returns ErrConflict when the table is already deleted.
+	Delete(ctx context.Context, tableId, deletedBy string, at time.Time) (models.Table, error)
+	// Restore clears the deletion of the table. It returns ErrConflict
+	// when the table is not deleted.
+	Restore(ctx context.Context, tableId string) (models.Table, error)
+}
+
+type mongoTableRepository struct {
+	tables *mongo.Collection
+}
+
+func (r *mongoTableRepository) List(ctx context.Context, query Query) ([]models.Table, int64, error) {
+	return findPage[models.Table](ctx, r.tables, query)
+}
+
+func (r *mongoTableRepository) Get(ctx context.Context, tableId string) (models.Table, error) {
+	var table models.Table
+	err := r.tables.FindOne(ctx, bson.M{"table_id": tableId}).Decode(&table)
+	return table, notFound(err)
+}
+
+func (r *mongoTableRepository) GetMany(ctx context.Context, tableIds []string) (map[string]models.Table, error) {
+	result, err := r.tables.Find(ctx, bson.M{"table_id": bson.M{"$in": tableIds}})
+	if err != nil {
+		return nil, err
+	}
+	var tables []models.Table
+	if err := result.All(ctx, &tables); err != nil {
+		return nil, err
+	}
+	found := map[string]models.Table{}
+	for _, table := range tables {
+		found[table.Table_id] = table
+	}
+	return found, nil
+}
+
+func (r *mongoTableRepository) Create(ctx context.Context, table models.Table) error {
+	_, err := r.tables.InsertOne(ctx, table)
+	return err
+}
+
+func (r *mongoTableRepository) Update(ctx context.Context, tableId string, version *int, changes models.Table) (models.Table, error) {
+	var updateObj primitive.D
+
+	if changes.Number_of_guests != nil {
+		updateObj = append(updateObj, bson.E{Key: "number_of_guests", Value: changes.Number_of_guests})
+	}
+	if changes.Table_number != nil {
+		updateObj = append(updateObj, bson.E{Key: "table_number", Value: changes.Table_number})
+	}
+	if changes.Status != nil {
+		updateObj = append(updateObj, bson.E{Key: "status", Value: changes.Status})
+	}
+	if changes.Section != nil {
+		updateObj = append(updateObj, bson.E{Key: "section", Value: changes.Section})
+	}
+	if changes.Position_x != nil {
+		updateObj = append(updateObj, bson.E{Key: "position_x", Value: changes.Position_x})
+	}
+	if changes.Position_y != nil {
+		updateObj = append(updateObj, bson.E{Key: "position_y", Value: changes.Position_y})
+	}
+	if changes.Shape != nil {
+		updateObj = append(updateObj, bson.E{Key: "shape", Value: changes.Shape})
+	}
+	if changes.Min_capacity != nil {
+		updateObj = append(updateObj, bson.E{Key: "min_capacity", Value: changes.Min_capacity})
+	}
+	if changes.Max_capacity != nil {
+		updateObj = append(updateObj, bson.E{Key: "max_capacity", Value: changes.Max_capacity})
+	}
+	updateObj = append(updateObj, bson.E{Key: "updated_at", Value: changes.Updated_at})
+
+	return updateVersioned[models.Table](ctx, r.tables, "table_id", tableId, version, updateObj)
+}
+
+func (r *mongoTableRepository) SetStatus(ctx context.Context, tableId, status string, at time.Time) error {
+	result, err := r.tables.UpdateOne(
+		ctx,
+		bson.M{"table_id": tableId},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "status", Value: status},
+				{Key: "updated_at", Value: at},
+			}},
+			{Key: "$inc", Value: bumpVersion},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// overlapping matches the booked slots of other reservations that overlap
+// the window from start to end.
+func overlapping(reservationId string, start, end time.Time) bson.M {
+	return bson.M{"$elemMatch": bson.M{
+		"reservation_id": bson.M{"$ne": reservationId},
+		"start_time":     bson.M{"$lt": end},
+		"end_time":       bson.M{"$gt": start},
+	}}
+}
+
+func (r *mongoTableRepository) Available(ctx context.Context, partySize int, start, end time.Time) ([]models.Table, error) {
+	result, err := r.tables.Find(
+		ctx,
+		bson.M{
+			"$or": bson.A{
+				bson.M{"number_of_guests": bson.M{"$gte": partySize}},
+				bson.M{"max_capacity": bson.M{"$gte": partySize}},
+			},
+			"min_capacity": bson.M{"$not": bson.M{"$gt": partySize}},
+			"booked_slots": bson.M{"$not": overlapping("", start, end)},
+			"deleted_at":   nil,
+		},
+		options.Find().SetSort(bson.D{{Key: "number_of_guests", Value: 1}, {Key: "table_number", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	tables := []models.Table{}
+	if err = result.All(ctx, &tables); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+func (r *mongoTableRepository) ClaimSlot(ctx context.Context, tableId string, slot models.TableSlot) (bool, error) {
+	free := bson.M{"$not": overlapping(slot.Reservation_id, slot.Start_time, slot.End_time)}
+
+	// the reservation already holds a slot on this table, move it
+	result, err := r.tables.UpdateOne(
+		ctx,
+		bson.M{"table_id": tableId, "booked_slots.reservation_id": slot.Reservation_id, "booked_slots": free},
+		bson.M{"$set": bson.M{"booked_slots.$[slot]": slot}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"slot.reservation_id": slot.Reservation_id}},
+		}),
+	)
+	if err != nil {
+		return false, err
+	}
+	if result.MatchedCount == 1 {
+		return true, nil
+	}
+
+	result, err = r.tables.UpdateOne(
+		ctx,
+		bson.M{
+			"table_id":                    tableId,
+			"booked_slots.reservation_id": bson.M{"$ne": slot.Reservation_id},
+			"booked_slots":                free,
+		},
+		bson.M{"$push": bson.M{"booked_slots": slot}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *mongoTableRepository) ReleaseSlot(ctx context.Context, tableId, reservationId string) error {
+	_, err := r.tables.UpdateOne(
+		ctx,
+		bson.M{"table_id": tableId},
+		bson.M{"$pull": bson.M{"booked_slots": bson.M{"reservation_id": reservationId}}},
+	)
+	return err
+}
+
+func (r *mongoTableRepository) Delete(ctx context.Context, tableId, deletedBy string, at time.Time) (models.Table, error) {
+	return softDelete[models.Table](ctx, r.tables, "table_id", tableId, deletedBy, at)
+}
+
+func (r *mongoTableRepository) Restore(ctx context.Context, tableId string) (models.Table, error) {
+	return restore[models.Table](ctx, r.tables, "table_id", tableId)
+}
+
+type memoryTableRepository struct {
+	tables *collection[models.Table]
+}
+
+func (r *memoryTableRepository) List(ctx context.Context, query Query) ([]models.Table, int64, error) {
+	tables, total := r.tables.query(query)
+	return tables, total, nil
+}
+
+func (r *memoryTableRepository) Get(ctx context.Context, tableId string) (models.Table, error) {
+	return r.tables.get(tableId)
+}
+
+func (r *memoryTableRepository) GetMany(ctx context.Context, tableIds []string) (map[string]models.Table, error) {
+	return r.tables.getMany(tableIds), nil
+}
+
+func (r *memoryTableRepository) Create(ctx context.Context, table models.Table) error {
+	r.tables.insert(table)
+	return nil
+}
+
+func (r *memoryTableRepository) Update(ctx context.Context, tableId string, version *int, changes models.Table) (models.Table, error) {
+	return r.tables.update(tableId, func(table *models.Table) error {
+		if err := nextVersion(&table.Version, version); err != nil {
+			return err
+		}
+		if changes.Number_of_guests != nil {
+			table.Number_of_guests = changes.Number_of_guests
+		}
+		if changes.Table_number != nil {
+			table.Table_number = changes.Table_number
+		}
+		if changes.Status != nil {
+			table.Status = changes.Status
+		}
+		if changes.Section != nil {
+			table.Section = changes.Section
+		}
+		if changes.Position_x != nil {
+			table.Position_x = changes.Position_x
+		}
+		if changes.Position_y != nil {
+			table.Position_y = changes.Position_y
+		}
+		if changes.Shape != nil {
+			table.Shape = changes.Shape
+		}
+		if changes.Min_capacity != nil {
+			table.Min_capacity = changes.Min_capacity
+		}
+		if changes.Max_capacity != nil {
+			table.Max_capacity = changes.Max_capacity
+		}
+		table.Updated_at = changes.Updated_at
+		return nil
+	})
+}
+
+func (r *memoryTableRepository) SetStatus(ctx context.Context, tableId, status string, at time.Time) error {
+	_, err := r.tables.update(tableId, func(table *models.Table) error {
+		table.Status = &status
+		table.Updated_at = at
+		table.Version++
+		return nil
+	})
+	return err
+}
+
+// fits tells whether the table seats a party of partySize, the same way the
+// MongoDB query of Available does.
+func fits(table *models.Table, partySize int) bool {
+	seats := (table.Number_of_guests != nil && *table.Number_of_guests >= partySize) ||
+		(table.Max_capacity != nil && *table.Max_capacity >= partySize)
+	return seats && (table.Min_capacity == nil || *table.Min_capacity <= partySize)
+}
+
+// isFree tells whether no slot of another reservation overlaps start to end.
+func isFree(table *models.Table, reservationId string, start, end time.Time) bool {
+	for _, slot := range table.Booked_slots {
+		if slot.Reservation_id != reservationId && slot.Start_time.Before(end) && slot.End_time.After(start) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *memoryTableRepository) Available(ctx context.Context, partySize int, start, end time.Time) ([]models.Table, error) {
+	tables := r.tables.find(func(table *models.Table) bool {
+		return table.Deleted_at == nil && fits(table, partySize) && isFree(table, "", start, end)
+	})
+	sort.SliceStable(tables, func(i, j int) bool {
+		a, b := tables[i], tables[j]
+		if guests(a.Number_of_guests) != guests(b.Number_of_guests) {
+			return guests(a.Number_of_guests) < guests(b.Number_of_guests)
+		}
+		return guests(a.Table_number) < guests(b.Table_number)
+	})
+	return tables, nil
+}
+
+func guests(n *int) int {
+	if n == nil {
+		return 0
+	}
+	return *n
+}
+
+func (r *memoryTableRepository) ClaimSlot(ctx context.Context, tableId string, slot models.TableSlot) (bool, error) {
+	_, err := r.tables.update(tableId, func(table *models.Table) error {
+		if !isFree(table, slot.Reservation_id, slot.Start_time, slot.End_time) {
+			return ErrConflict
+		}
+		for i := range table.Booked_slots {
+			if table.Booked_slots[i].Reservation_id == slot.Reservation_id {
+				table.Booked_slots[i] = slot
+				return nil
+			}
+		}
+		table.Booked_slots = append(table.Booked_slots, slot)
+		return nil
+	})
+	if err == ErrConflict || err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *memoryTableRepository) ReleaseSlot(ctx context.Context, tableId, reservationId string) error {
+	_, err := r.tables.update(tableId, func(table *models.Table) error {
+		slots := []models.TableSlot{}
+		for _, slot := range table.Booked_slots {
+			if slot.Reservation_id != reservationId {
+				slots = append(slots, slot)
+			}
+		}
+		table.Booked_slots = slots
+		return nil
+	})
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func (r *memoryTableRepository) Delete(ctx context.Context, tableId, deletedBy string, at time.Time) (models.Table, error) {
+	return r.tables.update(tableId, func(table *models.Table) error {
+		if table.Deleted_at != nil {
+			return ErrConflict
+		}
+		table.Deleted_at, table.Deleted_by = &at, &deletedBy
+		table.Version++
+		return nil
+	})
+}
+
+func (r *memoryTableRepository) Restore(ctx context.Context, tableId string) (models.Table, error) {
+	return r.tables.update(tableId, func(table *models.Table) error {
+		if table.Deleted_at == nil {
+			return ErrConflict
+		}
+		table.Deleted_at, table.Deleted_by = nil, nil
+		table.Version++
+		return nil
+	})
+}
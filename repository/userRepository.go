@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+type UserRepository interface {
+	// List returns the page of users selected by query and how many
+	// users match its filters.
+	List(ctx context.Context, query Query) ([]models.User, int64, error)
+	Get(ctx context.Context, userId string) (models.User, error)
+	GetByEmail(ctx context.Context, email string) (models.User, error)
+	Count(ctx context.Context) (int64, error)
+	EmailExists(ctx context.Context, email string) (bool, error)
+	PhoneExists(ctx context.Context, phone string) (bool, error)
+	Create(ctx context.Context, user models.User) error
+	// ClaimBootstrap reserves the ADMIN role the first account signs up
+	// with for the user. It reports false when another user claimed it
+	// already, so of two concurrent first sign ups only one becomes ADMIN.
+	ClaimBootstrap(ctx context.Context, userId string) (bool, error)
+	// ReleaseBootstrap gives the claim of the user back, when the account
+	// could not be created after all.
+	ReleaseBootstrap(ctx context.Context, userId string) error
+	// SetRole changes the role of the user, provided the user is at version
+	// when that is not nil. Tokens carry the role, so it also clears the
+	// stored tokens and bumps the token version, as RevokeTokens does. It
+	// returns the updated user, or ErrConflict when the user is at another
+	// version.
+	SetRole(ctx context.Context, userId string, version *int, role string, at time.Time) (models.User, error)
+	// SetTokens stores the token pair issued to the user.
+	SetTokens(ctx context.Context, userId, token, refreshToken string, at time.Time) error
+	// RotateTokens replaces the user's token pair, but only if
+	// currentRefreshToken is still the one stored. It reports false when
+	// the token has already been rotated or revoked.
+	RotateTokens(ctx context.Context, userId, currentRefreshToken, token, refreshToken string, at time.Time) (bool, error)
+	// RevokeTokens clears the stored tokens of the user and bumps its token
+	// version, so every token issued before is rejected.
+	RevokeTokens(ctx context.Context, userId string, at time.Time) error
+	TokenVersion(ctx context.Context, userId string) (int, error)
+}
+
+type mongoUserRepository struct {
+	users *mongo.Collection
+	// bootstrap holds the single claim on the ADMIN role of the first
+	// account, its unique _id makes claiming atomic.
+	bootstrap *mongo.Collection
+}
+
+// bootstrapClaim is the _id of the claim in the bootstrap collection.
+const bootstrapClaim = "admin"
+
+func (r *mongoUserRepository) List(ctx context.Context, query Query) ([]models.User, int64, error) {
+	return findPage[models.User](ctx, r.users, query)
+}
+
+func (r *mongoUserRepository) Get(ctx context.Context, userId string) (models.User, error) {
+	var user models.User
+	err := r.users.FindOne(ctx, bson.M{"user_id": userId}).Decode(&user)
+	return user, notFound(err)
+}
+
+func (r *mongoUserRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	err := r.users.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	return user, notFound(err)
+}
+
+func (r *mongoUserRepository) Count(ctx context.Context) (int64, error) {
+	return r.users.CountDocuments(ctx, bson.M{})
+}
+
+func (r *mongoUserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	count, err := r.users.CountDocuments(ctx, bson.M{"email": email})
+	return count > 0, err
+}
+
+func (r *mongoUserRepository) PhoneExists(ctx context.Context, phone string) (bool, error) {
+	count, err := r.users.CountDocuments(ctx, bson.M{"phone": phone})
+	return count > 0, err
+}
+
+func (r *mongoUserRepository) Create(ctx context.Context, user models.User) error {
+	_, err := r.users.InsertOne(ctx, user)
+	return err
+}
+
+func (r *mongoUserRepository) ClaimBootstrap(ctx context.Context, userId string) (bool, error) {
+	_, err := r.bootstrap.InsertOne(ctx, bson.M{"_id": bootstrapClaim, "user_id": userId})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *mongoUserRepository) ReleaseBootstrap(ctx context.Context, userId string) error {
+	_, err := r.bootstrap.DeleteOne(ctx, bson.M{"_id": bootstrapClaim, "user_id": userId})
+	return err
+}
+
+func (r *mongoUserRepository) SetRole(ctx context.Context, userId string, version *int, role string, at time.Time) (models.User, error) {
+	var user models.User
+	err := r.users.FindOneAndUpdate(
+		ctx,
+		atVersion(bson.M{"user_id": userId}, version),
+		bson.M{
+			"$set": bson.D{
+				{Key: "role", Value: role},
+				{Key: "token", Value: nil},
+				{Key: "refresh_token", Value: nil},
+				{Key: "updated_at", Value: at},
+			},
+			"$inc": bson.M{"version": 1, "token_version": 1},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != mongo.ErrNoDocuments {
+		return user, err
+	}
+	return user, missingOrConflict(ctx, r.users, "user_id", userId)
+}
+
+func (r *mongoUserRepository) SetTokens(ctx context.Context, userId, token, refreshToken string, at time.Time) error {
+	return r.set(ctx, userId, bson.D{
+		{Key: "token", Value: token},
+		{Key: "refresh_token", Value: refreshToken},
+		{Key: "updated_at", Value: at},
+	})
+}
+
+func (r *mongoUserRepository) set(ctx context.Context, userId string, fields bson.D) error {
+	result, err := r.users.UpdateOne(
+		ctx,
+		bson.M{"user_id": userId},
+		bson.D{{Key: "$set", Value: fields}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepository) RotateTokens(ctx context.Context, userId, currentRefreshToken, token, refreshToken string, at time.Time) (bool, error) {
+	result, err := r.users.UpdateOne(
+		ctx,
+		bson.M{"user_id": userId, "refresh_token": currentRefreshToken},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "token", Value: token},
+			{Key: "refresh_token", Value: refreshToken},
+			{Key: "updated_at", Value: at},
+		}}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *mongoUserRepository) RevokeTokens(ctx context.Context, userId string, at time.Time) error {
+	_, err := r.users.UpdateOne(
+		ctx,
+		bson.M{"user_id": userId},
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "token", Value: nil},
+				{Key: "refresh_token", Value: nil},
+				{Key: "updated_at", Value: at},
+			}},
+			{Key: "$inc", Value: bson.D{{Key: "token_version", Value: 1}}},
+		},
+	)
+	return err
+}
+
+func (r *mongoUserRepository) TokenVersion(ctx context.Context, userId string) (int, error) {
+	var user struct {
+		Token_version int `bson:"token_version"`
+	}
+	err := r.users.FindOne(
+		ctx,
+		bson.M{"user_id": userId},
+		options.FindOne().SetProjection(bson.M{"token_version": 1}),
+	).Decode(&user)
+	return user.Token_version, notFound(err)
+}
+
+type memoryUserRepository struct {
+	users *collection[models.User]
+	mu    sync.Mutex
+	// bootstrappedBy is the user holding the claim on the ADMIN role of the
+	// first account.
+	bootstrappedBy string
+}
+
+func (r *memoryUserRepository) List(ctx context.Context, query Query) ([]models.User, int64, error) {
+	users, total := r.users.query(query)
+	return users, total, nil
+}
+
+func (r *memoryUserRepository) Get(ctx context.Context, userId string) (models.User, error) {
+	return r.users.get(userId)
+}
+
+func (r *memoryUserRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	users := r.users.find(func(user *models.User) bool { return sameString(user.Email, &email) })
+	if len(users) == 0 {
+		return models.User{}, ErrNotFound
+	}
+	return users[0], nil
+}
+
+func (r *memoryUserRepository) Count(ctx context.Context) (int64, error) {
+	return r.users.count(nil), nil
+}
+
+func (r *memoryUserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	return r.users.count(func(user *models.User) bool { return sameString(user.Email, &email) }) > 0, nil
+}
+
+func (r *memoryUserRepository) PhoneExists(ctx context.Context, phone string) (bool, error) {
+	return r.users.count(func(user *models.User) bool { return sameString(user.Phone, &phone) }) > 0, nil
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user models.User) error {
+	r.users.insert(user)
+	return nil
+}
+
+func (r *memoryUserRepository) ClaimBootstrap(ctx context.Context, userId string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.bootstrappedBy != "" {
+		return false, nil
+	}
+	r.bootstrappedBy = userId
+	return true, nil
+}
+
+func (r *memoryUserRepository) ReleaseBootstrap(ctx context.Context, userId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.bootstrappedBy == userId {
+		r.bootstrappedBy = ""
+	}
+	return nil
+}
+
+func (r *memoryUserRepository) SetRole(ctx context.Context, userId string, version *int, role string, at time.Time) (models.User, error) {
+	return r.users.update(userId, func(user *models.User) error {
+		if err := nextVersion(&user.Version, version); err != nil {
+			return err
+		}
+		user.Role = &role
+		user.Token = nil
+		user.Refresh_Token = nil
+		user.Token_version++
+		user.Updated_at = at
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) SetTokens(ctx context.Context, userId, token, refreshToken string, at time.Time) error {
+	_, err := r.users.update(userId, func(user *models.User) error {
+		user.Token = &token
+		user.Refresh_Token = &refreshToken
+		user.Updated_at = at
+		return nil
+	})
+	return err
+}
+
+func (r *memoryUserRepository) RotateTokens(ctx context.Context, userId, currentRefreshToken, token, refreshToken string, at time.Time) (bool, error) {
+	_, err := r.users.update(userId, func(user *models.User) error {
+		if !sameString(user.Refresh_Token, &currentRefreshToken) {
+			return ErrConflict
+		}
+		user.Token = &token
+		user.Refresh_Token = &refreshToken
+		user.Updated_at = at
+		return nil
+	})
+	if err == ErrConflict || err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *memoryUserRepository) RevokeTokens(ctx context.Context, userId string, at time.Time) error {
+	_, err := r.users.update(userId, func(user *models.User) error {
+		user.Token = nil
+		user.Refresh_Token = nil
+		user.Token_version++
+		user.Updated_at = at
+		return nil
+	})
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func (r *memoryUserRepository) TokenVersion(ctx context.Context, userId string) (int, error) {
+	user, err := r.users.get(userId)
+	return user.Token_version, err
+}
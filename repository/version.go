@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Every write to a food, menu, table, order, ordered item, invoice,
+// reservation, ingredient, supplier, purchase order or user role increments
+// the version of the document, so a client can tell whether the copy it
+// holds is still current. Documents stored before versions existed have none and are at
+// version 0.
+
+// bumpVersion is the update incrementing the version of a document.
+var bumpVersion = bson.M{"version": 1}
+
+// atVersion narrows filter to the documents at version when it is not nil.
+func atVersion(filter bson.M, version *int) bson.M {
+	if version == nil {
+		return filter
+	}
+	if *version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	} else {
+		filter["version"] = *version
+	}
+	return filter
+}
+
+// updateVersioned sets the fields of the document whose idField is id and
+// increments its version, provided it is at version when that is not nil.
+// It returns the updated document, ErrNotFound when there is none and
+// ErrConflict when it is at another version.
+func updateVersioned[T any](ctx context.Context, collection *mongo.Collection, idField, id string, version *int, set primitive.D) (T, error) {
+	var doc T
+	err := collection.FindOneAndUpdate(
+		ctx,
+		atVersion(bson.M{idField: id}, version),
+		bson.M{"$set": set, "$inc": bumpVersion},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err != mongo.ErrNoDocuments {
+		return doc, err
+	}
+	return doc, missingOrConflict(ctx, collection, idField, id)
+}
+
+// missingOrConflict tells why a conditional write matched nothing: there is
+// no document whose idField is id, or it is not in the state required.
+func missingOrConflict(ctx context.Context, collection *mongo.Collection, idField, id string) error {
+	count, err := collection.CountDocuments(ctx, bson.M{idField: id})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrConflict
+}
+
+// nextVersion is the in-memory counterpart of a versioned write: it
+// increments current, provided it equals version when that is not nil.
+// Otherwise it returns ErrConflict.
+func nextVersion(current *int, version *int) error {
+	if version != nil && *current != *version {
+		return ErrConflict
+	}
+	*current++
+	return nil
+}
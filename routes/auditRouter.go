@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/middleware"
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+func AuditRoutes(in *gin.Engine, controller *controllers.Controller) {
+	in.GET("/audit", middleware.Authorization(models.RoleAdmin), controller.GetAudit())
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/middleware"
+)
+
+// EventStreamPath is the route of the event stream, which accepts the
+// token as a query parameter.
+const EventStreamPath = "/events/stream"
+
+// EventRoutes must be registered before the global Authentication
+// middleware, the stream authenticates by itself so it can accept the token
+// as a query parameter.
+func EventRoutes(in *gin.Engine, controller *controllers.Controller, authentication gin.HandlerFunc) {
+	in.GET(EventStreamPath, middleware.TokenFromQuery(), authentication, controller.StreamEvents())
+}
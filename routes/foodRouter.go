@@ -3,12 +3,16 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 
-	controller "github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/middleware"
+	"github.com/minhtran241/restaurant-management/models"
 )
 
-func FoodRoutes(in *gin.Engine) {
+func FoodRoutes(in *gin.Engine, controller *controllers.Controller) {
 	in.GET("/foods", controller.GetFoods())
 	in.GET("/foods/:food_id", controller.GetFood())
-	in.POST("/foods", controller.CreateFood())
-	in.PATCH("/foods/:food_id", controller.UpdateFood())
+	in.POST("/foods", middleware.Authorization(models.RoleManager), controller.CreateFood())
+	in.PATCH("/foods/:food_id", middleware.Authorization(models.RoleManager), controller.UpdateFood())
+	in.DELETE("/foods/:food_id", middleware.Authorization(models.RoleManager), controller.DeleteFood())
+	in.POST("/foods/:food_id/restore", middleware.Authorization(models.RoleManager), controller.RestoreFood())
 }
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/controllers"
+)
+
+// HealthRoutes must be registered before the global Authentication
+// middleware, the orchestrator probes them without a token.
+func HealthRoutes(in *gin.Engine, controller *controllers.Controller) {
+	in.GET("/healthz", controller.Liveness())
+	in.GET("/readyz", controller.Readiness())
+}
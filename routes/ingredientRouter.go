@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/middleware"
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+func IngredientRoutes(in *gin.Engine, controller *controllers.Controller) {
+	in.GET("/ingredients", controller.GetIngredients())
+	in.GET("/ingredients/reorder", middleware.Authorization(models.RoleManager), controller.GetReorderReport())
+	in.GET("/ingredients/:ingredient_id", controller.GetIngredient())
+	in.GET("/ingredients/:ingredient_id/movements", controller.GetStockMovements())
+	in.POST("/ingredients", middleware.Authorization(models.RoleManager), controller.CreateIngredient())
+	in.PATCH("/ingredients/:ingredient_id", middleware.Authorization(models.RoleManager), controller.UpdateIngredient())
+	in.POST("/ingredients/:ingredient_id/adjustments", middleware.Authorization(models.RoleChef, models.RoleManager), controller.AdjustStock())
+	in.DELETE("/ingredients/:ingredient_id", middleware.Authorization(models.RoleManager), controller.DeleteIngredient())
+	in.POST("/ingredients/:ingredient_id/restore", middleware.Authorization(models.RoleManager), controller.RestoreIngredient())
+}
@@ -3,12 +3,18 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 
-	controller "github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/middleware"
+	"github.com/minhtran241/restaurant-management/models"
 )
 
-func InvoiceRoutes(in *gin.Engine) {
+func InvoiceRoutes(in *gin.Engine, controller *controllers.Controller) {
 	in.GET("/invoices", controller.GetInvoices())
 	in.GET("/invoices/:invoice_id", controller.GetInvoice())
-	in.POST("/invoices", controller.CreateInvoice())
-	in.PATCH("/invoices/:invoice_id", controller.UpdateInvoice())
-}
\ No newline at end of file
+	in.POST("/invoices", middleware.Authorization(models.RoleCashier, models.RoleManager), controller.CreateInvoice())
+	in.PATCH("/invoices/:invoice_id", middleware.Authorization(models.RoleCashier, models.RoleManager), controller.UpdateInvoice())
+	in.DELETE("/invoices/:invoice_id", middleware.Authorization(models.RoleManager), controller.DeleteInvoice())
+	in.POST("/invoices/:invoice_id/restore", middleware.Authorization(models.RoleManager), controller.RestoreInvoice())
+	in.POST("/invoices/:invoice_id/payments", middleware.Authorization(models.RoleCashier, models.RoleManager), controller.RecordPayment())
+	in.POST("/invoices/:invoice_id/splits", middleware.Authorization(models.RoleCashier, models.RoleManager), controller.SplitInvoice())
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/middleware"
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+func KitchenRoutes(in *gin.Engine, controller *controllers.Controller) {
+	in.GET("/kitchen/tickets", controller.GetKitchenTickets())
+	in.POST("/kitchen/items/:order_item_id/bump", middleware.Authorization(models.RoleChef, models.RoleWaiter, models.RoleManager), controller.BumpOrderItem())
+	in.POST("/kitchen/items/:order_item_id/status", middleware.Authorization(models.RoleChef, models.RoleWaiter, models.RoleManager), controller.UpdateOrderItemStatus())
+}
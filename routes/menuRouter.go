@@ -3,12 +3,17 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 
-	controller "github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/middleware"
+	"github.com/minhtran241/restaurant-management/models"
 )
 
-func MenuRoutes(in *gin.Engine) {
+func MenuRoutes(in *gin.Engine, controller *controllers.Controller) {
 	in.GET("/menus", controller.GetMenus())
+	in.GET("/menus/active", controller.GetActiveMenus())
 	in.GET("/menus/:menu_id", controller.GetMenu())
-	in.POST("/menus", controller.CreateMenu())
-	in.PATCH("/menus/:menu_id", controller.UpdateMenu())
-}
\ No newline at end of file
+	in.POST("/menus", middleware.Authorization(models.RoleManager), controller.CreateMenu())
+	in.PATCH("/menus/:menu_id", middleware.Authorization(models.RoleManager), controller.UpdateMenu())
+	in.DELETE("/menus/:menu_id", middleware.Authorization(models.RoleManager), controller.DeleteMenu())
+	in.POST("/menus/:menu_id/restore", middleware.Authorization(models.RoleManager), controller.RestoreMenu())
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/controllers"
+)
+
+func NoteRoutes(in *gin.Engine, controller *controllers.Controller) {
+	in.GET("/notes", controller.GetNotes())
+	in.GET("/notes/:note_id", controller.GetNote())
+	in.POST("/notes", controller.CreateNote())
+	in.PATCH("/notes/:note_id", controller.UpdateNote())
+	in.DELETE("/notes/:note_id", controller.DeleteNote())
+	in.POST("/notes/:note_id/restore", controller.RestoreNote())
+}
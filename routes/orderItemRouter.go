@@ -3,13 +3,17 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 
-	controller "github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/middleware"
+	"github.com/minhtran241/restaurant-management/models"
 )
 
-func OrderItemRoutes(in *gin.Engine) {
+func OrderItemRoutes(in *gin.Engine, controller *controllers.Controller) {
 	in.GET("/orderItems", controller.GetOrderItems())
-	in.GET("/orderItems/:orderItem_id", controller.GetOrderItem())
+	in.GET("/orderItems/:order_item_id", controller.GetOrderItem())
 	in.GET("/orderItems-order/:order_id", controller.GetOrderItemsByOrder())
 	in.POST("/orderItems", controller.CreateOrderItem())
-	in.PATCH("/orderItems/:orderItem_id", controller.UpdateOrderItem())
+	in.PATCH("/orderItems/:order_item_id", controller.UpdateOrderItem())
+	in.DELETE("/orderItems/:order_item_id", middleware.Authorization(models.RoleManager), controller.DeleteOrderItem())
+	in.POST("/orderItems/:order_item_id/restore", middleware.Authorization(models.RoleManager), controller.RestoreOrderItem())
 }
\ No newline at end of file
@@ -3,12 +3,17 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 
-	controller "github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/middleware"
+	"github.com/minhtran241/restaurant-management/models"
 )
 
-func OrderRoutes(in *gin.Engine) {
+func OrderRoutes(in *gin.Engine, controller *controllers.Controller) {
 	in.GET("/orders", controller.GetOrders())
 	in.GET("/orders/:order_id", controller.GetOrder())
 	in.POST("/orders", controller.CreateOrder())
 	in.PATCH("/orders/:order_id", controller.UpdateOrder())
+	in.DELETE("/orders/:order_id", middleware.Authorization(models.RoleManager), controller.DeleteOrder())
+	in.POST("/orders/:order_id/restore", middleware.Authorization(models.RoleManager), controller.RestoreOrder())
+	in.POST("/orders/:order_id/transitions", controller.TransitionOrder())
 }
\ No newline at end of file
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/middleware"
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+func PurchaseOrderRoutes(in *gin.Engine, controller *controllers.Controller) {
+	in.GET("/purchaseOrders", controller.GetPurchaseOrders())
+	in.GET("/purchaseOrders/:purchase_order_id", controller.GetPurchaseOrder())
+	in.POST("/purchaseOrders", middleware.Authorization(models.RoleManager), controller.CreatePurchaseOrder())
+	in.PATCH("/purchaseOrders/:purchase_order_id", middleware.Authorization(models.RoleManager), controller.UpdatePurchaseOrder())
+	in.POST("/purchaseOrders/:purchase_order_id/receipts", middleware.Authorization(models.RoleChef, models.RoleManager), controller.ReceivePurchaseOrder())
+	in.DELETE("/purchaseOrders/:purchase_order_id", middleware.Authorization(models.RoleManager), controller.DeletePurchaseOrder())
+	in.POST("/purchaseOrders/:purchase_order_id/restore", middleware.Authorization(models.RoleManager), controller.RestorePurchaseOrder())
+}
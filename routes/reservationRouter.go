@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+func ReservationRoutes(in *gin.Engine, controller *controllers.Controller) {
+	in.GET("/reservations", controller.GetReservations())
+	in.GET("/reservations/availability", controller.GetAvailableTables())
+	in.GET("/reservations/:reservation_id", controller.GetReservation())
+	in.POST("/reservations", controller.CreateReservation())
+	in.POST("/reservations/no-shows", controller.MarkNoShows())
+	in.PATCH("/reservations/:reservation_id", controller.UpdateReservation())
+	in.POST("/reservations/:reservation_id/seat", controller.ChangeReservationStatus(models.ReservationSeated))
+	in.POST("/reservations/:reservation_id/complete", controller.ChangeReservationStatus(models.ReservationCompleted))
+	in.POST("/reservations/:reservation_id/cancel", controller.ChangeReservationStatus(models.ReservationCancelled))
+	in.POST("/reservations/:reservation_id/no-show", controller.ChangeReservationStatus(models.ReservationNoShow))
+}
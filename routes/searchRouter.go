@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/controllers"
+)
+
+func SearchRoutes(in *gin.Engine, controller *controllers.Controller) {
+	in.GET("/search", controller.Search())
+}
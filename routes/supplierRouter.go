@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/middleware"
+	"github.com/minhtran241/restaurant-management/models"
+)
+
+func SupplierRoutes(in *gin.Engine, controller *controllers.Controller) {
+	in.GET("/suppliers", controller.GetSuppliers())
+	in.GET("/suppliers/:supplier_id", controller.GetSupplier())
+	in.POST("/suppliers", middleware.Authorization(models.RoleManager), controller.CreateSupplier())
+	in.PATCH("/suppliers/:supplier_id", middleware.Authorization(models.RoleManager), controller.UpdateSupplier())
+	in.DELETE("/suppliers/:supplier_id", middleware.Authorization(models.RoleManager), controller.DeleteSupplier())
+	in.POST("/suppliers/:supplier_id/restore", middleware.Authorization(models.RoleManager), controller.RestoreSupplier())
+}
@@ -3,12 +3,17 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 
-	controller "github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/middleware"
+	"github.com/minhtran241/restaurant-management/models"
 )
 
-func TableRoutes(in *gin.Engine) {
+func TableRoutes(in *gin.Engine, controller *controllers.Controller) {
 	in.GET("/tables", controller.GetTables())
 	in.GET("/tables/:table_id", controller.GetTable())
 	in.POST("/tables", controller.CreateTable())
 	in.PATCH("/tables/:table_id", controller.UpdateTable())
+	in.DELETE("/tables/:table_id", middleware.Authorization(models.RoleManager), controller.DeleteTable())
+	in.POST("/tables/:table_id/restore", middleware.Authorization(models.RoleManager), controller.RestoreTable())
+	in.GET("/floor", controller.GetFloor())
 }
\ No newline at end of file
@@ -3,12 +3,20 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 
-	controller "github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/controllers"
+	"github.com/minhtran241/restaurant-management/middleware"
+	"github.com/minhtran241/restaurant-management/models"
 )
 
-func UserRoutes(in *gin.Engine) {
-	in.GET("/users", controller.GetUsers())
-	in.GET("/users/:user_id", controller.GetUser())
+func UserRoutes(in *gin.Engine, controller *controllers.Controller, authentication gin.HandlerFunc) {
 	in.POST("/users/signup", controller.SignUp())
-	in.POST("users/login", controller.Login())
-}
\ No newline at end of file
+	in.POST("/users/login", controller.Login())
+	in.POST("/users/refresh", controller.RefreshToken())
+
+	// user routes are registered before the global Authentication middleware,
+	// so the protected ones have to carry it themselves
+	in.GET("/users", authentication, middleware.Authorization(models.RoleAdmin), controller.GetUsers())
+	in.GET("/users/:user_id", authentication, controller.GetUser())
+	in.POST("/users/logout", authentication, controller.Logout())
+	in.PATCH("/users/:user_id/role", authentication, middleware.Authorization(models.RoleAdmin), controller.UpdateUserRole())
+}